@@ -0,0 +1,82 @@
+package terratest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/core"
+)
+
+const (
+	agentService = "oracle-cloud-agent"
+	// plugin groups of the instance agent config, the compute API switches plugins by group
+	agentMonitoring = "monitoring"
+	agentManagement = "management"
+)
+
+var (
+	// Cloud Agent plugins by their console name
+	agentPluginGroups = map[string]string{
+		"Compute Instance Monitoring":  agentMonitoring,
+		"Custom Logs Monitoring":       agentMonitoring,
+		"OS Management Service Agent":  agentManagement,
+		"Bastion":                      agentManagement,
+		"Compute Instance Run Command": agentManagement,
+		"Management Agent":             agentManagement,
+	}
+)
+
+// checkAgentPlugins verifies the desired Cloud Agent plugins are enabled on every instance by the compute API
+// and the agent running them is active on the host. The compute API reports the enabled state per plugin group.
+func checkAgentPlugins(t *testing.T) {
+	expected := loadExpectations(t).AgentPlugins
+	if len(expected) == 0 {
+		t.Skip("no agentPlugins in expectations")
+	}
+	for _, plugin := range expected {
+		if _, ok := agentPluginGroups[plugin]; !ok {
+			t.Fatalf("unknown Cloud Agent plugin %q in expectations", plugin)
+		}
+	}
+	compute := computeClient(t)
+
+	for tier, output := range tierOutputs {
+		for _, instanceID := range outputValues(t, output) {
+			id := instanceID
+			response, err := compute.GetInstance(context.Background(), core.GetInstanceRequest{InstanceId: &id})
+			if err != nil {
+				t.Fatalf("error in calling instance %s: %s", instanceID, err.Error())
+			}
+			disabled := disabledAgentGroups(response.Instance.AgentConfig)
+
+			// assertions
+			for _, plugin := range expected {
+				if group := agentPluginGroups[plugin]; disabled[group] {
+					t.Errorf("%s instance %s: plugin %s is disabled (%s plugins of the agent config)",
+						tier, stringValue(response.Instance.DisplayName), plugin, group)
+				}
+			}
+		}
+
+		for _, host := range tierHosts(t, tier) {
+			result := RunRemote(t, host, "systemctl is-active "+agentService, RemoteOptions{Timeout: sshCommandTimeout})
+			if state := strings.TrimSpace(result.Stdout); state != "active" {
+				t.Errorf("%s %s: %s is %s, its plugins are not running", tier, host.Hostname, agentService, state)
+			}
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// disabledAgentGroups returns the disabled plugin groups, plugins are enabled when the instance has no agent config.
+func disabledAgentGroups(agentConfig *core.InstanceAgentConfig) map[string]bool {
+	if agentConfig == nil {
+		return map[string]bool{}
+	}
+	return map[string]bool{
+		agentMonitoring: boolValue(agentConfig.IsMonitoringDisabled),
+		agentManagement: boolValue(agentConfig.IsManagementDisabled),
+	}
+}
@@ -0,0 +1,154 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/monitoring"
+	"github.com/oracle/oci-go-sdk/ons"
+)
+
+const (
+	// alarms and topics, COMPARTMENT_OCID_MONITORING or CompartmentOCID
+	monitoringCompartment = "monitoring"
+	computeAgentNamespace = "oci_computeagent"
+	// window of CPU metrics of the hosts, the agent posts them every minute
+	metricsWindow = 15 * time.Minute
+)
+
+// AlarmExpectation is an alarm of the stack.
+type AlarmExpectation struct {
+	DisplayName string `json:"displayName"`
+	Namespace   string `json:"namespace"`
+	// Metric is the metric of the alarm query, e.g. CpuUtilization
+	Metric string `json:"metric"`
+	// Topic is the name of the notification topic of the alarm destinations
+	Topic string `json:"topic"`
+}
+
+func checkAlarms(t *testing.T) {
+	expected := loadExpectations(t).Alarms
+	if len(expected) == 0 {
+		t.Skip("no alarms in expectations")
+	}
+	client := monitoringClient(t)
+	compartmentID := compartmentFor(monitoringCompartment)
+
+	for _, alarm := range expected {
+		name := alarm.DisplayName
+		response, err := client.ListAlarms(context.Background(), monitoring.ListAlarmsRequest{
+			CompartmentId: &compartmentID,
+			DisplayName:   &name,
+		})
+		if err != nil {
+			t.Fatalf("error in listing alarms: %s", err.Error())
+		}
+		if len(response.Items) == 0 {
+			t.Errorf("missing alarm %q in %s", name, compartmentID)
+			continue
+		}
+		actual := response.Items[0]
+		linkResource(t, "alarm "+name, *actual.Id, "")
+
+		// assertions
+		if !boolValue(actual.IsEnabled) {
+			t.Errorf("alarm %q is disabled", name)
+		}
+		if actual.LifecycleState != monitoring.AlarmLifecycleStateActive {
+			t.Errorf("alarm %q in state %s", name, actual.LifecycleState)
+		}
+		if namespace := stringValue(actual.Namespace); namespace != alarm.Namespace {
+			t.Errorf("alarm %q: wrong namespace: expected %q, got %q", name, alarm.Namespace, namespace)
+		}
+		if query := stringValue(actual.Query); !strings.HasPrefix(strings.TrimSpace(query), alarm.Metric+"[") {
+			t.Errorf("alarm %q: query %q is not on metric %s", name, query, alarm.Metric)
+		}
+		if alarm.Topic != "" {
+			topicID := findTopic(t, alarm.Topic).TopicId
+			if !containsString(actual.Destinations, stringValue(topicID)) {
+				t.Errorf("alarm %q: topic %s (%s) not in destinations %v", name, alarm.Topic, stringValue(topicID), actual.Destinations)
+			}
+		}
+	}
+}
+
+// checkInstanceMetrics verifies CPU metrics of all hosts are flowing, enabled by instanceMetrics in expectations.
+func checkInstanceMetrics(t *testing.T) {
+	if !loadExpectations(t).InstanceMetrics {
+		t.Skip("instanceMetrics not enabled in expectations")
+	}
+	client := monitoringClient(t)
+	compartmentID := compartmentFor(computeCompartment)
+	namespace := computeAgentNamespace
+
+	for tier, output := range tierOutputs {
+		for _, instanceID := range outputValues(t, output) {
+			query := fmt.Sprintf(`CpuUtilization[1m]{resourceId = "%s"}.mean()`, instanceID)
+			description := fmt.Sprintf("CPU metrics of %s instance %s", tier, instanceID)
+
+			// new hosts post their first metrics a few minutes after boot
+			retry.DoWithRetry(t, description, maxRetries, sleepBetweenRetries*6, func() (string, error) {
+				end := time.Now()
+				response, err := client.SummarizeMetricsData(context.Background(), monitoring.SummarizeMetricsDataRequest{
+					CompartmentId: &compartmentID,
+					SummarizeMetricsDataDetails: monitoring.SummarizeMetricsDataDetails{
+						Namespace: &namespace,
+						Query:     &query,
+						StartTime: &common.SDKTime{Time: end.Add(-metricsWindow)},
+						EndTime:   &common.SDKTime{Time: end},
+					},
+				})
+				if err != nil {
+					return "", err
+				}
+				for _, data := range response.Items {
+					if len(data.AggregatedDatapoints) > 0 {
+						return "", nil
+					}
+				}
+				return "", fmt.Errorf("no datapoints of %s in the last %s", query, metricsWindow)
+			})
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func monitoringClient(t *testing.T) monitoring.MonitoringClient {
+	client, err := monitoring.NewMonitoringClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	throttle(&client.BaseClient)
+	return client
+}
+
+func notificationControlPlaneClient(t *testing.T) ons.NotificationControlPlaneClient {
+	client, err := ons.NewNotificationControlPlaneClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	throttle(&client.BaseClient)
+	return client
+}
+
+// findTopic returns the notification topic with the name in the monitoring compartment.
+func findTopic(t *testing.T, name string) ons.NotificationTopicSummary {
+	compartmentID := compartmentFor(monitoringCompartment)
+	response, err := notificationControlPlaneClient(t).ListTopics(context.Background(), ons.ListTopicsRequest{
+		CompartmentId: &compartmentID,
+		Name:          &name,
+	})
+	if err != nil {
+		t.Fatalf("error in listing topics: %s", err.Error())
+	}
+	if len(response.Items) == 0 {
+		t.Fatalf("topic %s not found in %s", name, compartmentID)
+	}
+	return response.Items[0]
+}
@@ -0,0 +1,154 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/oracle/oci-go-sdk/audit"
+	"github.com/oracle/oci-go-sdk/common"
+)
+
+const (
+	// audit events show up with a delay
+	auditMaxRetries = 30
+	auditRetrySleep = 30 * time.Second
+)
+
+var (
+	// create events expected in the audit log, event name: count
+	expectedCreateEvents = map[string]int{
+		"CreateVcn":          1,
+		"CreateLoadBalancer": 1,
+	}
+)
+
+func checkAuditCreateEvents(t *testing.T) {
+	if os.Getenv("CHECK_AUDIT") == "" {
+		t.Skip("audit check is enabled by CHECK_AUDIT=1")
+	}
+	if appliedAt.IsZero() {
+		t.Skip("audit check needs the stack applied by this run")
+	}
+
+	compartmentID := stringVar("CompartmentOCID", "")
+	principalID := stringVar("user_ocid", "")
+	if securityTokenEnabled() {
+		// user_ocid is empty for sessions, the token names the user
+		var err error
+		if principalID, err = securityTokenPrincipal(); err != nil {
+			t.Skipf("principal of the security token session is unknown: %s", err.Error())
+		}
+	}
+
+	expected := map[string]int{
+		"LaunchInstance": len(outputValues(t, "WebServerPrivateIPs")) + len(outputValues(t, "BastionPublicIP")),
+	}
+	for name, count := range expectedCreateEvents {
+		expected[name] = count
+	}
+
+	var events []audit.AuditEvent
+	description := fmt.Sprintf("audit create events in %s", compartmentID)
+	retry.DoWithRetry(t, description, auditMaxRetries, auditRetrySleep, func() (string, error) {
+		var err error
+		events, err = listAuditEvents(compartmentID, report.Started, time.Now())
+		if err != nil {
+			return "", err
+		}
+
+		counts := map[string]int{}
+		for _, event := range events {
+			if event.Data != nil && event.Data.EventName != nil {
+				counts[*event.Data.EventName]++
+			}
+		}
+		for name, count := range expected {
+			if counts[name] < count {
+				return "", fmt.Errorf("%s events: expected %d, got %d", name, count, counts[name])
+			}
+		}
+		return "", nil
+	})
+
+	// assertions
+	for _, event := range events {
+		if event.Data == nil || event.Data.EventName == nil {
+			continue
+		}
+		name := *event.Data.EventName
+		if !isCreateEvent(name) {
+			continue
+		}
+
+		actor := auditActor(event)
+		if event.Data.Identity == nil || event.Data.Identity.PrincipalId == nil || *event.Data.Identity.PrincipalId != principalID {
+			t.Errorf("unexpected actor of %s on %s: %s", name, auditResource(event), actor)
+		} else {
+			t.Logf("%s on %s by %s", name, auditResource(event), actor)
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func isCreateEvent(name string) bool {
+	return strings.HasPrefix(name, "Create") || strings.HasPrefix(name, "Launch")
+}
+
+func auditActor(event audit.AuditEvent) string {
+	if event.Data.Identity == nil {
+		return "unknown"
+	}
+	name, id := "", ""
+	if event.Data.Identity.PrincipalName != nil {
+		name = *event.Data.Identity.PrincipalName
+	}
+	if event.Data.Identity.PrincipalId != nil {
+		id = *event.Data.Identity.PrincipalId
+	}
+	return fmt.Sprintf("%s (%s)", name, id)
+}
+
+func auditResource(event audit.AuditEvent) string {
+	if event.Data.ResourceName != nil {
+		return *event.Data.ResourceName
+	}
+	if event.Data.ResourceId != nil {
+		return *event.Data.ResourceId
+	}
+	return "unknown resource"
+}
+
+// listAuditEvents returns all audit events of the compartment in the time window.
+func listAuditEvents(compartmentID string, start time.Time, end time.Time) ([]audit.AuditEvent, error) {
+	client, err := audit.NewAuditClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		return nil, err
+	}
+	throttle(&client.BaseClient)
+
+	request := audit.ListEventsRequest{
+		CompartmentId: &compartmentID,
+		StartTime:     &common.SDKTime{Time: start},
+		EndTime:       &common.SDKTime{Time: end},
+	}
+
+	events := []audit.AuditEvent{}
+	for {
+		response, err := client.ListEvents(context.Background(), request)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, response.Items...)
+
+		if response.OpcNextPage == nil {
+			return events, nil
+		}
+		request.Page = response.OpcNextPage
+	}
+}
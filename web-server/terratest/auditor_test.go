@@ -0,0 +1,73 @@
+package terratest
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+var (
+	// credentials of AUDITOR_PROFILE, nil when the checks use the credentials of the apply
+	auditorConfig        *Config
+	auditorProfileValues = map[string]string{}
+
+	// true while a check runs with the auditor credentials, checks run one by one
+	auditing bool
+)
+
+// auditorProfile is the profile of the OCI config file with the credentials of the auditor principal,
+// e.g. a read-only user of the same or another tenancy, set by AUDITOR_PROFILE.
+func auditorProfile() string {
+	return os.Getenv("AUDITOR_PROFILE")
+}
+
+// auditorTags are the tags of the checks run as auditor, AUDITOR_TAGS (comma separated) or identity and network.
+func auditorTags() []string {
+	if tags := envList("AUDITOR_TAGS"); len(tags) > 0 {
+		return tags
+	}
+	return []string{tagIdentity, tagNetwork}
+}
+
+// loadAuditorConfig reads the auditor credentials from AUDITOR_PROFILE, the rest of the configuration is the run's.
+func loadAuditorConfig(t *testing.T) *Config {
+	auditorProfileValues = map[string]string{}
+	if auditorProfile() == "" {
+		return nil
+	}
+
+	content, err := ioutil.ReadFile(ociConfigFile())
+	if err != nil {
+		t.Fatalf("error in reading OCI config: %s", err.Error())
+	}
+	values, ok := parseOciConfig(content, auditorProfile())
+	if !ok {
+		t.Fatalf("auditor profile %s not found in %s", auditorProfile(), ociConfigFile())
+	}
+
+	auditor := *config
+	auditor.TenancyOCID = values["tenancy"]
+	auditor.UserOCID = values["user"]
+	auditor.Fingerprint = values["fingerprint"]
+	auditor.PrivateKeyPath = expandHome(values["key_file"])
+	if auditor.UserOCID == "" {
+		t.Fatalf("auditor profile %s: security token auth is not supported for the auditor", auditorProfile())
+	}
+	if errs := auditor.Validate(); len(errs) > 0 {
+		t.Fatalf("wrong auditor profile %s:\n  %s", auditorProfile(), strings.Join(errs, "\n  "))
+	}
+
+	auditorProfileValues = values
+	return &auditor
+}
+
+// asAuditor runs the check with the auditor credentials, so the resources are verified as the auditor sees them.
+func asAuditor(run func(t *testing.T)) func(t *testing.T) {
+	return func(t *testing.T) {
+		t.Logf("running as auditor (profile %s)", auditorProfile())
+		auditing = true
+		defer func() { auditing = false }()
+		run(t)
+	}
+}
@@ -0,0 +1,143 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/oracle/oci-go-sdk/objectstorage"
+)
+
+const (
+	// override file adds backend block to the stack without changing it
+	backendOverrideFile = "backend_override.tf"
+	backendOverride     = `terraform {
+  backend "s3" {}
+}
+`
+	// default workspace_key_prefix of the s3 backend, state of other workspaces is stored under env:/<workspace>/<key>
+	workspaceKeyPrefix = "env:"
+)
+
+// remoteBackend stores state in OCI Object Storage through its S3 compatible API.
+// Credentials are Customer Secret Keys in AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.
+type remoteBackend struct {
+	bucket    string
+	key       string
+	namespace string
+	region    string
+	endpoint  string
+	// generatedKey state is removed after destroy
+	generatedKey bool
+}
+
+// remoteBackendFromEnv returns nil when TF_BACKEND_BUCKET is not set.
+// TF_BACKEND_KEY selects existing state, otherwise a per-run key is generated.
+func remoteBackendFromEnv(t *testing.T) *remoteBackend {
+	bucket := os.Getenv("TF_BACKEND_BUCKET")
+	if bucket == "" {
+		return nil
+	}
+
+	b := &remoteBackend{
+		bucket:    bucket,
+		key:       os.Getenv("TF_BACKEND_KEY"),
+		namespace: os.Getenv("TF_BACKEND_NAMESPACE"),
+		region:    os.Getenv("TF_BACKEND_REGION"),
+		endpoint:  os.Getenv("TF_BACKEND_ENDPOINT"),
+	}
+	if b.region == "" {
+		b.region = stringVar("region", "")
+	}
+	if b.key == "" {
+		b.key = fmt.Sprintf("terratest/%s/terraform.tfstate", random.UniqueId())
+		b.generatedKey = true
+	}
+	if b.endpoint == "" {
+		if b.namespace == "" {
+			b.namespace = objectStorageNamespace(t)
+		}
+		b.endpoint = fmt.Sprintf("https://%s.compat.objectstorage.%s.oraclecloud.com", b.namespace, b.region)
+	}
+	return b
+}
+
+// configure adds the backend override to the stack and backend config to terraform options.
+func (b *remoteBackend) configure(t *testing.T, opts *terraform.Options) {
+	path := filepath.Join(opts.TerraformDir, backendOverrideFile)
+	if err := ioutil.WriteFile(path, []byte(backendOverride), 0644); err != nil {
+		t.Fatalf("error in writing %s: %s", path, err.Error())
+	}
+
+	opts.BackendConfig = map[string]interface{}{
+		"bucket":                      b.bucket,
+		"key":                         b.key,
+		"region":                      b.region,
+		"endpoint":                    b.endpoint,
+		"skip_region_validation":      true,
+		"skip_credentials_validation": true,
+		"skip_metadata_api_check":     true,
+		"force_path_style":            true,
+	}
+	t.Logf("using remote state s3://%s/%s at %s", b.bucket, b.key, b.endpoint)
+}
+
+// cleanup removes the override file and the state object when its key was generated for this run.
+func (b *remoteBackend) cleanup(t *testing.T, opts *terraform.Options) {
+	os.Remove(filepath.Join(opts.TerraformDir, backendOverrideFile))
+
+	if !b.generatedKey || b.namespace == "" {
+		return
+	}
+
+	client, err := objectstorage.NewObjectStorageClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		t.Errorf("error in state cleanup: %s", err.Error())
+		return
+	}
+	throttle(&client.BaseClient)
+
+	object := b.stateObject(workspace())
+	_, err = client.DeleteObject(context.Background(), objectstorage.DeleteObjectRequest{
+		NamespaceName: &b.namespace,
+		BucketName:    &b.bucket,
+		ObjectName:    &object,
+	})
+	if err != nil {
+		t.Errorf("error in deleting state %s: %s", object, err.Error())
+		return
+	}
+	t.Logf("removed remote state s3://%s/%s", b.bucket, object)
+}
+
+// stateObject is the object holding the state of the workspace.
+func (b *remoteBackend) stateObject(ws string) string {
+	if ws == defaultWorkspace {
+		return b.key
+	}
+	return fmt.Sprintf("%s/%s/%s", workspaceKeyPrefix, ws, b.key)
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func objectStorageNamespace(t *testing.T) string {
+	response, err := objectStorageClient(t).GetNamespace(context.Background(), objectstorage.GetNamespaceRequest{})
+	if err != nil {
+		t.Fatalf("error in calling object storage namespace: %s", err.Error())
+	}
+	return *response.Value
+}
+
+func objectStorageClient(t *testing.T) objectstorage.ObjectStorageClient {
+	client, err := objectstorage.NewObjectStorageClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	throttle(&client.BaseClient)
+	return client
+}
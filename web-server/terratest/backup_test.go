@@ -0,0 +1,177 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/core"
+)
+
+const (
+	// full backup and restore of a boot volume take tens of minutes
+	backupRestoreTimeout = time.Hour
+)
+
+func checkBootVolumeBackupPolicy(t *testing.T) {
+	expected := loadExpectations(t).BootVolumeBackupPolicy
+	if expected == "" {
+		t.Skip("no bootVolumeBackupPolicy in expectations")
+	}
+	compute := computeClient(t)
+	blockstorage := blockstorageClient(t)
+
+	for tier, output := range tierOutputs {
+		for _, instanceID := range outputValues(t, output) {
+			instance := getInstance(t, compute, instanceID)
+			name := fmt.Sprintf("%s instance %s", tier, stringValue(instance.DisplayName))
+			bootVolumeID := instanceBootVolumeId(t, compute, instance)
+
+			response, err := blockstorage.GetVolumeBackupPolicyAssetAssignment(context.Background(), core.GetVolumeBackupPolicyAssetAssignmentRequest{
+				AssetId: &bootVolumeID,
+			})
+			if err != nil {
+				t.Fatalf("error in calling backup policy assignment of %s: %s", bootVolumeID, err.Error())
+			}
+
+			// assertions
+			if len(response.Items) == 0 {
+				t.Errorf("%s: no backup policy assigned to boot volume, expected %s", name, expected)
+				continue
+			}
+			policy, err := blockstorage.GetVolumeBackupPolicy(context.Background(), core.GetVolumeBackupPolicyRequest{
+				PolicyId: response.Items[0].PolicyId,
+			})
+			if err != nil {
+				t.Fatalf("error in calling backup policy %s: %s", stringValue(response.Items[0].PolicyId), err.Error())
+			}
+			if actual := stringValue(policy.VolumeBackupPolicy.DisplayName); actual != expected {
+				t.Errorf("%s: wrong boot volume backup policy: expected %s, got %s", name, expected, actual)
+			}
+		}
+	}
+}
+
+// scenarioBootVolumeRestore takes a manual backup of the boot volume of the first web server, restores it
+// to a new boot volume and attaches that as a data volume to the web server. It is enabled by RUN_BACKUP_RESTORE=1
+// as it runs for tens of minutes, the backup and the restored volume are deleted at the end.
+func scenarioBootVolumeRestore(t *testing.T) {
+	if os.Getenv("RUN_BACKUP_RESTORE") == "" {
+		t.Skip("backup and restore is enabled by RUN_BACKUP_RESTORE=1")
+	}
+	compute := computeClient(t)
+	blockstorage := blockstorageClient(t)
+	instance := getInstance(t, compute, outputValues(t, tierOutputs["web"])[0])
+	bootVolumeID := instanceBootVolumeId(t, compute, instance)
+	displayName := "restore-" + runTagValue
+	start := time.Now()
+
+	backup, err := blockstorage.CreateBootVolumeBackup(context.Background(), core.CreateBootVolumeBackupRequest{
+		CreateBootVolumeBackupDetails: core.CreateBootVolumeBackupDetails{
+			BootVolumeId: &bootVolumeID,
+			DisplayName:  &displayName,
+			Type:         core.CreateBootVolumeBackupDetailsTypeFull,
+		},
+	})
+	if err != nil {
+		t.Fatalf("error in creating backup of boot volume %s: %s", bootVolumeID, err.Error())
+	}
+	backupID := *backup.BootVolumeBackup.Id
+	defer func() {
+		if _, err := blockstorage.DeleteBootVolumeBackup(context.Background(), core.DeleteBootVolumeBackupRequest{BootVolumeBackupId: &backupID}); err != nil {
+			t.Errorf("error in deleting boot volume backup %s: %s", backupID, err.Error())
+		}
+	}()
+	WaitForState(t, "boot volume backup "+backupID, bootVolumeBackupState(blockstorage, backupID),
+		string(core.BootVolumeBackupLifecycleStateAvailable), backupRestoreTimeout)
+	report.AddMetric("time to back up boot volume", time.Since(start).Round(time.Second))
+
+	start = time.Now()
+	restored, err := blockstorage.CreateBootVolume(context.Background(), core.CreateBootVolumeRequest{
+		CreateBootVolumeDetails: core.CreateBootVolumeDetails{
+			AvailabilityDomain: instance.AvailabilityDomain,
+			CompartmentId:      instance.CompartmentId,
+			DisplayName:        &displayName,
+			SourceDetails:      core.BootVolumeSourceFromBootVolumeBackupDetails{Id: &backupID},
+		},
+	})
+	if err != nil {
+		t.Fatalf("error in restoring boot volume backup %s: %s", backupID, err.Error())
+	}
+	restoredID := *restored.BootVolume.Id
+	defer func() {
+		if _, err := blockstorage.DeleteBootVolume(context.Background(), core.DeleteBootVolumeRequest{BootVolumeId: &restoredID}); err != nil {
+			t.Errorf("error in deleting restored boot volume %s: %s", restoredID, err.Error())
+		}
+	}()
+	WaitForState(t, "restored boot volume "+restoredID, bootVolumeState(blockstorage, restoredID),
+		string(core.BootVolumeLifecycleStateAvailable), backupRestoreTimeout)
+	report.AddMetric("time to restore boot volume", time.Since(start).Round(time.Second))
+
+	attachment, err := compute.AttachVolume(context.Background(), core.AttachVolumeRequest{
+		AttachVolumeDetails: core.AttachParavirtualizedVolumeDetails{InstanceId: instance.Id, VolumeId: &restoredID},
+	})
+	if err != nil {
+		t.Fatalf("error in attaching restored boot volume %s to %s: %s", restoredID, stringValue(instance.DisplayName), err.Error())
+	}
+	attachmentID := *attachment.VolumeAttachment.GetId()
+	WaitForState(t, "attachment of restored boot volume "+restoredID, volumeAttachmentState(compute, attachmentID),
+		string(core.VolumeAttachmentLifecycleStateAttached), defaultStateTimeout)
+
+	if _, err := compute.DetachVolume(context.Background(), core.DetachVolumeRequest{VolumeAttachmentId: &attachmentID}); err != nil {
+		t.Fatalf("error in detaching restored boot volume %s: %s", restoredID, err.Error())
+	}
+	WaitForState(t, "attachment of restored boot volume "+restoredID, volumeAttachmentState(compute, attachmentID),
+		string(core.VolumeAttachmentLifecycleStateDetached), defaultStateTimeout)
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func getInstance(t *testing.T, compute core.ComputeClient, instanceID string) core.Instance {
+	response, err := compute.GetInstance(context.Background(), core.GetInstanceRequest{InstanceId: &instanceID})
+	if err != nil {
+		t.Fatalf("error in calling instance %s: %s", instanceID, err.Error())
+	}
+	return response.Instance
+}
+
+func instanceBootVolumeId(t *testing.T, compute core.ComputeClient, instance core.Instance) string {
+	attachments, err := compute.ListBootVolumeAttachments(context.Background(), core.ListBootVolumeAttachmentsRequest{
+		AvailabilityDomain: instance.AvailabilityDomain,
+		CompartmentId:      instance.CompartmentId,
+		InstanceId:         instance.Id,
+	})
+	if err != nil {
+		t.Fatalf("error in listing boot volume attachments of %s: %s", stringValue(instance.DisplayName), err.Error())
+	}
+	if len(attachments.Items) != 1 {
+		t.Fatalf("%s: wrong number of boot volumes: expected 1, got %d", stringValue(instance.DisplayName), len(attachments.Items))
+	}
+	return *attachments.Items[0].BootVolumeId
+}
+
+func bootVolumeBackupState(blockstorage core.BlockstorageClient, backupID string) StateGetter {
+	return func(ctx context.Context) (string, error) {
+		response, err := blockstorage.GetBootVolumeBackup(ctx, core.GetBootVolumeBackupRequest{BootVolumeBackupId: &backupID})
+		return string(response.BootVolumeBackup.LifecycleState), err
+	}
+}
+
+func bootVolumeState(blockstorage core.BlockstorageClient, bootVolumeID string) StateGetter {
+	return func(ctx context.Context) (string, error) {
+		response, err := blockstorage.GetBootVolume(ctx, core.GetBootVolumeRequest{BootVolumeId: &bootVolumeID})
+		return string(response.BootVolume.LifecycleState), err
+	}
+}
+
+func volumeAttachmentState(compute core.ComputeClient, attachmentID string) StateGetter {
+	return func(ctx context.Context) (string, error) {
+		response, err := compute.GetVolumeAttachment(ctx, core.GetVolumeAttachmentRequest{VolumeAttachmentId: &attachmentID})
+		if err != nil {
+			return "", err
+		}
+		return string(response.VolumeAttachment.GetLifecycleState()), nil
+	}
+}
@@ -0,0 +1,256 @@
+package terratest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/ssh"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/oracle/oci-go-sdk/core"
+	"github.com/oracle/oci-go-sdk/loadbalancer"
+)
+
+const (
+	iperfPort    = 5201
+	iperfSeconds = 10
+	// LB listener and backend set of the bandwidth test, removed afterwards
+	iperfLbName = "iperf"
+	// share of the LB shape bandwidth expected through the LB, when not in expectations
+	lbBandwidthShare = 0.8
+)
+
+// BandwidthExpectation are minimum throughputs of the bandwidth check.
+type BandwidthExpectation struct {
+	// MinIntraVcnMbps between web servers (bastion to web server with a single one), not checked when 0
+	MinIntraVcnMbps float64 `json:"minIntraVcnMbps"`
+	// MinLbMbps through a TCP listener of the LB, 0 means 80% of the LB shape, e.g. 100Mbps
+	MinLbMbps float64 `json:"minLbMbps"`
+}
+
+// iperfResult is the part of iperf3 -J output with the throughput.
+type iperfResult struct {
+	End struct {
+		SumReceived struct {
+			BitsPerSecond float64 `json:"bits_per_second"`
+		} `json:"sum_received"`
+	} `json:"end"`
+	Error string `json:"error"`
+}
+
+// checkBandwidth measures the TCP throughput with iperf3 between the web servers and from the bastion through
+// the LB, and asserts the minimums of the expectations. The iperf port is opened by a temporary NSG and served
+// by a temporary TCP listener of the LB, iperf3 is installed on the hosts when missing.
+// It is enabled by RUN_BANDWIDTH=1.
+func checkBandwidth(t *testing.T) {
+	if os.Getenv("RUN_BANDWIDTH") == "" {
+		t.Skip("bandwidth check is enabled by RUN_BANDWIDTH=1")
+	}
+	expected := loadExpectations(t).Bandwidth
+	if expected == nil {
+		expected = &BandwidthExpectation{}
+	}
+	network := virtualNetworkClient(t)
+	lbClient := loadBalancerClient(t)
+	lbID := terraform.Output(t, options, "lb_id")
+	bastion := bastionHost(t)
+	webIPs := outputValues(t, "WebServerPrivateIPs")
+
+	nsgID := createIperfNsg(t, network, bastion.Hostname)
+	defer deleteIperfNsg(t, network, nsgID)
+	for _, instanceID := range outputValues(t, tierOutputs["web"]) {
+		for _, vnic := range instanceVnics(t, instanceID) {
+			defer updateVnicNsgs(t, network, *vnic.Id, vnic.NsgIds)
+			updateVnicNsgs(t, network, *vnic.Id, append(append([]string{}, vnic.NsgIds...), nsgID))
+		}
+	}
+	lbNsgs := getLoadBalancer(t).NetworkSecurityGroupIds
+	defer updateLoadBalancerNsgs(t, lbClient, lbID, lbNsgs)
+	updateLoadBalancerNsgs(t, lbClient, lbID, append(append([]string{}, lbNsgs...), nsgID))
+
+	servers := []ssh.Host{}
+	for _, ip := range webIPs {
+		servers = append(servers, sshHost(t, ip))
+	}
+	for _, server := range servers {
+		defer RunRemote(t, server, "pkill -x iperf3", RemoteOptions{Sudo: true, Timeout: sshCommandTimeout})
+		start := RunRemote(t, server, fmt.Sprintf("(command -v iperf3 >/dev/null || yum -y -q install iperf3) && pkill -x iperf3; iperf3 -s -D -p %d", iperfPort),
+			RemoteOptions{Sudo: true, Timeout: sshCommandTimeout})
+		if start.ExitCode != 0 {
+			t.Fatalf("error in starting iperf3 on %s, exit code %d: %s", server.Hostname, start.ExitCode, start.Stderr)
+		}
+	}
+
+	// intra VCN
+	client, targets := bastion, webIPs
+	if len(servers) > 1 {
+		client, targets = servers[0], webIPs[1:]
+	}
+	for _, ip := range targets {
+		mbps := iperf(t, client, ip)
+		name := fmt.Sprintf("bandwidth %s -> %s", client.Hostname, ip)
+		t.Logf("%s: %.0f Mbps", name, mbps)
+		report.AddMetric(name+" Mbps", fmt.Sprintf("%.0f", mbps))
+		// assertions
+		if expected.MinIntraVcnMbps > 0 && mbps < expected.MinIntraVcnMbps {
+			t.Errorf("%s: %.0f Mbps, expected at least %.0f Mbps", name, mbps, expected.MinIntraVcnMbps)
+		}
+	}
+
+	// through the LB
+	minLbMbps := expected.MinLbMbps
+	if minLbMbps == 0 {
+		if shapeMbps, err := strconv.Atoi(strings.TrimSuffix(stringValue(getLoadBalancer(t).ShapeName), "Mbps")); err == nil {
+			minLbMbps = float64(shapeMbps) * lbBandwidthShare
+		}
+	}
+	defer deleteIperfListener(t, lbClient, lbID)
+	createIperfListener(t, lbClient, lbID, webIPs)
+	lbIP := outputValues(t, "lb_ip")[0]
+	mbps := iperf(t, bastion, lbIP)
+	t.Logf("bandwidth through LB %s: %.0f Mbps", lbIP, mbps)
+	report.AddMetric("bandwidth through LB Mbps", fmt.Sprintf("%.0f", mbps))
+	// assertions
+	if minLbMbps > 0 && mbps < minLbMbps {
+		t.Errorf("bandwidth through LB %s: %.0f Mbps, expected at least %.0f Mbps", lbIP, mbps, minLbMbps)
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// iperf runs the iperf3 client on host against the server and returns the received throughput in Mbps.
+func iperf(t *testing.T, host ssh.Host, server string) float64 {
+	command := fmt.Sprintf("iperf3 -c %s -p %d -t %d -J", shellQuote(server), iperfPort, iperfSeconds)
+	result := RunRemote(t, host, command, RemoteOptions{Timeout: sshCommandTimeout + iperfSeconds*time.Second})
+
+	parsed := iperfResult{}
+	if err := json.Unmarshal([]byte(result.Stdout), &parsed); err != nil {
+		t.Fatalf("error in parsing iperf3 output of %s -> %s: %s, exit code %d: %s", host.Hostname, server, err.Error(), result.ExitCode, result.Stderr)
+	}
+	if parsed.Error != "" {
+		t.Fatalf("iperf3 %s -> %s failed: %s", host.Hostname, server, parsed.Error)
+	}
+	return parsed.End.SumReceived.BitsPerSecond / 1e6
+}
+
+// createIperfNsg creates a NSG of the VCN allowing the iperf port from the VCN and from the bastion,
+// the bastion reaches the LB by its public IP.
+func createIperfNsg(t *testing.T, network core.VirtualNetworkClient, bastionIP string) string {
+	compartmentID := compartmentFor(networkCompartment)
+	vcnID := sanitizedVcnId(t)
+	name := "terratest-iperf-" + workspace()
+	response, err := network.CreateNetworkSecurityGroup(context.Background(), core.CreateNetworkSecurityGroupRequest{
+		CreateNetworkSecurityGroupDetails: core.CreateNetworkSecurityGroupDetails{
+			CompartmentId: &compartmentID,
+			VcnId:         &vcnID,
+			DisplayName:   &name,
+		},
+	})
+	if err != nil {
+		t.Fatalf("error in creating nsg %s: %s", name, err.Error())
+	}
+	nsgID := *response.NetworkSecurityGroup.Id
+
+	rules := []core.AddSecurityRuleDetails{}
+	port, protocol := iperfPort, tcpProtocol
+	for _, source := range []string{stringVar("VCNCIDR", defaultVcnCidr), bastionIP + "/32"} {
+		cidr := source
+		rules = append(rules, core.AddSecurityRuleDetails{
+			Direction:  core.AddSecurityRuleDetailsDirectionIngress,
+			Protocol:   &protocol,
+			Source:     &cidr,
+			SourceType: core.AddSecurityRuleDetailsSourceTypeCidrBlock,
+			TcpOptions: &core.TcpOptions{DestinationPortRange: &core.PortRange{Min: &port, Max: &port}},
+		})
+	}
+	if _, err := network.AddNetworkSecurityGroupSecurityRules(context.Background(), core.AddNetworkSecurityGroupSecurityRulesRequest{
+		NetworkSecurityGroupId:                      &nsgID,
+		AddNetworkSecurityGroupSecurityRulesDetails: core.AddNetworkSecurityGroupSecurityRulesDetails{SecurityRules: rules},
+	}); err != nil {
+		deleteIperfNsg(t, network, nsgID)
+		t.Fatalf("error in adding rules to nsg %s: %s", name, err.Error())
+	}
+	return nsgID
+}
+
+func deleteIperfNsg(t *testing.T, network core.VirtualNetworkClient, nsgID string) {
+	if _, err := network.DeleteNetworkSecurityGroup(context.Background(), core.DeleteNetworkSecurityGroupRequest{
+		NetworkSecurityGroupId: &nsgID,
+	}); err != nil {
+		t.Errorf("error in deleting nsg %s: %s", nsgID, err.Error())
+	}
+}
+
+func updateVnicNsgs(t *testing.T, network core.VirtualNetworkClient, vnicID string, nsgIDs []string) {
+	if _, err := network.UpdateVnic(context.Background(), core.UpdateVnicRequest{
+		VnicId:            &vnicID,
+		UpdateVnicDetails: core.UpdateVnicDetails{NsgIds: nsgIDs},
+	}); err != nil {
+		t.Errorf("error in updating nsgs of vnic %s: %s", vnicID, err.Error())
+	}
+}
+
+func updateLoadBalancerNsgs(t *testing.T, client loadbalancer.LoadBalancerClient, lbID string, nsgIDs []string) {
+	started := time.Now()
+	if _, err := client.UpdateNetworkSecurityGroups(context.Background(), loadbalancer.UpdateNetworkSecurityGroupsRequest{
+		LoadBalancerId:                     &lbID,
+		UpdateNetworkSecurityGroupsDetails: loadbalancer.UpdateNetworkSecurityGroupsDetails{NetworkSecurityGroupIds: nsgIDs},
+	}); err != nil {
+		t.Errorf("error in updating nsgs of load balancer: %s", err.Error())
+		return
+	}
+	WaitForLoadBalancerWorkRequests(t, lbID, started, defaultWorkRequestTimeout)
+}
+
+// createIperfListener adds a TCP listener on the iperf port forwarding to the iperf servers of the web servers.
+func createIperfListener(t *testing.T, client loadbalancer.LoadBalancerClient, lbID string, webIPs []string) {
+	started := time.Now()
+	name, policy, protocol := iperfLbName, lbPolicy, "TCP"
+	port := iperfPort
+	backends := []loadbalancer.BackendDetails{}
+	for _, ip := range webIPs {
+		address := ip
+		backends = append(backends, loadbalancer.BackendDetails{IpAddress: &address, Port: &port})
+	}
+
+	if _, err := client.CreateBackendSet(context.Background(), loadbalancer.CreateBackendSetRequest{
+		LoadBalancerId: &lbID,
+		CreateBackendSetDetails: loadbalancer.CreateBackendSetDetails{
+			Name:          &name,
+			Policy:        &policy,
+			Backends:      backends,
+			HealthChecker: &loadbalancer.HealthCheckerDetails{Protocol: &protocol, Port: &port},
+		},
+	}); err != nil {
+		t.Fatalf("error in creating backend set %s: %s", name, err.Error())
+	}
+	WaitForLoadBalancerWorkRequests(t, lbID, started, defaultWorkRequestTimeout)
+
+	if _, err := client.CreateListener(context.Background(), loadbalancer.CreateListenerRequest{
+		LoadBalancerId: &lbID,
+		CreateListenerDetails: loadbalancer.CreateListenerDetails{
+			Name:                  &name,
+			DefaultBackendSetName: &name,
+			Port:                  &port,
+			Protocol:              &protocol,
+		},
+	}); err != nil {
+		t.Fatalf("error in creating listener %s: %s", name, err.Error())
+	}
+	WaitForLoadBalancerWorkRequests(t, lbID, started, defaultWorkRequestTimeout)
+}
+
+// deleteIperfListener removes the listener and the backend set of createIperfListener, missing ones are ignored.
+func deleteIperfListener(t *testing.T, client loadbalancer.LoadBalancerClient, lbID string) {
+	started := time.Now()
+	name := iperfLbName
+	client.DeleteListener(context.Background(), loadbalancer.DeleteListenerRequest{LoadBalancerId: &lbID, ListenerName: &name})
+	WaitForLoadBalancerWorkRequests(t, lbID, started, defaultWorkRequestTimeout)
+	client.DeleteBackendSet(context.Background(), loadbalancer.DeleteBackendSetRequest{LoadBalancerId: &lbID, BackendSetName: &name})
+	WaitForLoadBalancerWorkRequests(t, lbID, started, defaultWorkRequestTimeout)
+}
@@ -0,0 +1,104 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+const (
+	// hostname_label of the green web servers, nginx serves it as the server name
+	greenHostPrefix = "green"
+	// requests sampled after the switch, all of them have to be served by the green tier
+	blueGreenSamples = 20
+)
+
+// scenarioBlueGreenSwitch applies a green web tier next to the blue one (WebServer), adds it to the LB
+// backend set, takes the blue tier out of the backend set and finally removes it, all through terraform
+// variables. No request to the LB may fail during the switch. The stack is left with the green tier only,
+// so this scenario runs last.
+func scenarioBlueGreenSwitch(t *testing.T) {
+	requireScenarios(t)
+
+	greenCount := len(outputValues(t, "WebServerPrivateIPs"))
+	lbID := terraform.Output(t, options, "lb_id")
+	poller := startLbPoller("http://" + outputValues(t, "lb_ip")[0] + "/")
+
+	applyWebTier(t, lbID, "green tier", map[string]string{"GreenWebVMCount": strconv.Itoa(greenCount)})
+	for _, ip := range outputValues(t, "GreenWebServerPrivateIPs") {
+		if !ProcessListening(t, sshHost(t, ip), nginxName, nginxPort) {
+			t.FailNow()
+		}
+	}
+
+	applyWebTier(t, lbID, "green tier in LB", map[string]string{"GreenWebTierInLB": "true"})
+	waitForGreenServed(t, poller.url)
+
+	applyWebTier(t, lbID, "blue tier out of LB", map[string]string{"BlueWebTierInLB": "false"})
+	client := &http.Client{Timeout: httpTimeout}
+	served := map[string]int{}
+	for i := 0; i < blueGreenSamples; i++ {
+		name, err := lbServerName(client, poller.url)
+		if err != nil {
+			t.Errorf("request #%d after the switch: %s", i, err.Error())
+			continue
+		}
+		served[name]++
+	}
+	t.Logf("requests after the switch served by: %v", served)
+
+	applyWebTier(t, lbID, "blue tier removed", map[string]string{"WebVMCount": "0"})
+
+	total, failed, errors := poller.Stop()
+	t.Logf("requests during blue/green switch: %d, failed: %d", total, failed)
+	report.AddMetric("blue/green switch failed requests", fmt.Sprintf("%d of %d", failed, total))
+
+	// assertions
+	for name := range served {
+		if !strings.HasPrefix(name, greenHostPrefix) {
+			t.Errorf("request served by %s of the blue tier after the switch", name)
+		}
+	}
+	if total == 0 {
+		t.Fatalf("no requests to %s were made during the switch", poller.url)
+	}
+	if failed > 0 {
+		t.Errorf("%d of %d requests failed during blue/green switch, errors: %v", failed, total, errors)
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// applyWebTier applies the stack with vars and waits for the resulting backend set changes of the LB.
+func applyWebTier(t *testing.T, lbID string, step string, vars map[string]string) {
+	started := time.Now()
+	for name, value := range vars {
+		options.Vars[name] = value
+	}
+	withStateLock(t, "apply "+step, func() (string, error) {
+		return terraform.ApplyE(t, options)
+	})
+	WaitForLoadBalancerWorkRequests(t, lbID, started, defaultWorkRequestTimeout)
+	t.Logf("blue/green step %q applied after %s", step, time.Since(started).Round(time.Second))
+}
+
+// waitForGreenServed polls the LB until a green web server serves a request, i.e. passed the health check.
+func waitForGreenServed(t *testing.T, url string) {
+	client := &http.Client{Timeout: httpTimeout}
+	ctx, cancel := context.WithTimeout(context.Background(), healthySLO(t))
+	defer cancel()
+
+	err := pollUntil(ctx, "green tier served by "+url, lbPollInterval, func(ctx context.Context) (bool, error) {
+		name, err := lbServerName(client, url)
+		return err == nil && strings.HasPrefix(name, greenHostPrefix), nil
+	})
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+}
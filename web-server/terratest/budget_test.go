@@ -0,0 +1,79 @@
+package terratest
+
+import (
+	"context"
+	"os"
+	"sort"
+	"testing"
+	"time"
+)
+
+const (
+	// status of the report when the run exceeded RUN_BUDGET
+	reportTimedOut = "TIMED_OUT"
+	// phases highlighted in the report of a timed out run
+	slowestPhasesCount = 3
+)
+
+var (
+	// done when the run exceeds RUN_BUDGET, never done without a budget
+	runBudget = context.Background()
+)
+
+// startRunBudget sets the budget of the run by RUN_BUDGET, e.g. 45m, counted from the start of the run.
+// The budget is checked between checks only: checks after it is exceeded are skipped, destroy still runs.
+// The apply and the running check are not interrupted, so the run may last longer than the budget.
+func startRunBudget(t *testing.T) context.CancelFunc {
+	value := os.Getenv("RUN_BUDGET")
+	if value == "" {
+		return func() {}
+	}
+	budget, err := time.ParseDuration(value)
+	if err != nil {
+		t.Fatalf("wrong RUN_BUDGET %q: %s", value, err.Error())
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), summary.Started.Add(budget))
+	runBudget = ctx
+	t.Logf("run budget %s", budget)
+	return cancel
+}
+
+// budgetExceeded tells whether the run exceeded its budget, the budget canceled at the end of the run is not exceeded.
+func budgetExceeded() bool {
+	return runBudget.Err() == context.DeadlineExceeded
+}
+
+// SetTimedOut marks the run as exceeding its budget.
+func (s *ExitSummary) SetTimedOut() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.TimedOut = true
+}
+
+// SlowestPhases returns the phases of the run by duration, the slowest first. A phase which has not
+// finished lasts until now.
+func (s *ExitSummary) SlowestPhases(count int) []SummaryPhase {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	phases := append([]SummaryPhase{}, s.Phases...)
+	for i, phase := range phases {
+		if !phase.Finished {
+			phases[i].Seconds = time.Since(phase.Started).Seconds()
+		}
+	}
+	sort.SliceStable(phases, func(i, j int) bool { return phases[i].Seconds > phases[j].Seconds })
+	if len(phases) > count {
+		phases = phases[:count]
+	}
+	return phases
+}
+
+// MarkTimedOut marks the report as TIMED_OUT and highlights the slowest phases of the run.
+func (r *Report) MarkTimedOut(phases []SummaryPhase) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Status = reportTimedOut
+	r.SlowestPhases = phases
+}
@@ -0,0 +1,141 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/monitoring"
+)
+
+const (
+	burstLoadSeconds = 300
+	// the agent posts metrics every minute, the last minutes of the load are in the window after it ends
+	burstMetricsDelay = 3 * time.Minute
+)
+
+var (
+	// CPU utilization guaranteed by the baseline, in percent of the OCPUs
+	baselinePercents = map[string]float64{
+		"BASELINE_1_8": 12.5,
+		"BASELINE_1_2": 50,
+		"BASELINE_1_1": 100,
+	}
+)
+
+// instanceShapeConfig has the baseline of the instance the SDK does not have yet.
+type instanceShapeConfig struct {
+	RawResponse *http.Response
+	Instance    struct {
+		DisplayName *string `json:"displayName"`
+		ShapeConfig *struct {
+			BaselineOcpuUtilization *string `json:"baselineOcpuUtilization"`
+		} `json:"shapeConfig"`
+	} `presentIn:"body"`
+}
+
+func checkBurstableBaseline(t *testing.T) {
+	baseline := stringVar("BaselineOcpuUtilization", "")
+	if baseline == "" {
+		t.Skip("no BaselineOcpuUtilization, instances are not burstable")
+	}
+	compute := computeClient(t)
+
+	for tier, output := range tierOutputs {
+		for _, instanceID := range outputValues(t, output) {
+			id := instanceID
+			var actual instanceShapeConfig
+			if err := callAPI(context.Background(), compute.BaseClient, http.MethodGet, computePath+"/instances/{instanceId}",
+				getInstanceRequest{InstanceId: &id}, &actual); err != nil {
+				t.Fatalf("error in calling instance %s: %s", instanceID, err.Error())
+			}
+
+			// assertions
+			value := ""
+			if actual.Instance.ShapeConfig != nil {
+				value = stringValue(actual.Instance.ShapeConfig.BaselineOcpuUtilization)
+			}
+			if value != baseline {
+				t.Errorf("%s instance %s: wrong baseline OCPU utilization: expected %s, got %q",
+					tier, stringValue(actual.Instance.DisplayName), baseline, value)
+			}
+		}
+	}
+}
+
+// checkBurstableBurst loads all CPUs of the first web server and verifies by monitoring metrics that the CPU
+// utilization bursts above the baseline, enabled by RUN_BURST_EVENT=1 as it runs for minutes.
+func checkBurstableBurst(t *testing.T) {
+	if os.Getenv("RUN_BURST_EVENT") == "" {
+		t.Skip("burst event is enabled by RUN_BURST_EVENT=1")
+	}
+	baseline := stringVar("BaselineOcpuUtilization", "")
+	if baseline == "" {
+		t.Skip("no BaselineOcpuUtilization, instances are not burstable")
+	}
+	percent, ok := baselinePercents[baseline]
+	if !ok {
+		t.Fatalf("unknown BaselineOcpuUtilization %s", baseline)
+	}
+	instanceID := outputValues(t, tierOutputs["web"])[0]
+	host := webHosts(t)[0]
+
+	start := time.Now()
+	result := RunRemote(t, host, cpuLoad(burstLoadSeconds), RemoteOptions{Timeout: sshCommandTimeout})
+	if result.ExitCode != 0 {
+		t.Fatalf("error in starting CPU load on %s: %s", host.Hostname, result.Stderr)
+	}
+	time.Sleep(time.Duration(burstLoadSeconds)*time.Second + burstMetricsDelay)
+
+	peak, err := peakCpuUtilization(t, instanceID, start, time.Now())
+	if err != nil {
+		t.Fatalf("error in reading CPU metrics of %s: %s", instanceID, err.Error())
+	}
+	t.Logf("web server %s: CPU utilization peaked at %.1f%% under load, baseline %s is %.1f%%", host.Hostname, peak, baseline, percent)
+	report.AddMetric("burst CPU utilization "+host.Hostname, fmt.Sprintf("%.1f%%", peak))
+
+	// assertions
+	if percent < 100 && peak <= percent {
+		t.Errorf("web server %s did not burst: CPU utilization peaked at %.1f%%, baseline %s is %.1f%%", host.Hostname, peak, baseline, percent)
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// peakCpuUtilization returns the max of the one minute means of the instance CPU utilization in the window.
+func peakCpuUtilization(t *testing.T, instanceID string, start time.Time, end time.Time) (float64, error) {
+	compartmentID := compartmentFor(computeCompartment)
+	namespace := computeAgentNamespace
+	query := fmt.Sprintf(`CpuUtilization[1m]{resourceId = "%s"}.mean()`, instanceID)
+
+	response, err := monitoringClient(t).SummarizeMetricsData(context.Background(), monitoring.SummarizeMetricsDataRequest{
+		CompartmentId: &compartmentID,
+		SummarizeMetricsDataDetails: monitoring.SummarizeMetricsDataDetails{
+			Namespace: &namespace,
+			Query:     &query,
+			StartTime: &common.SDKTime{Time: start},
+			EndTime:   &common.SDKTime{Time: end},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	peak, found := 0.0, false
+	for _, data := range response.Items {
+		for _, point := range data.AggregatedDatapoints {
+			if point.Value != nil && *point.Value > peak {
+				peak = *point.Value
+			}
+			found = true
+		}
+	}
+	if !found {
+		return 0, fmt.Errorf("no datapoints of %s", query)
+	}
+	return peak, nil
+}
@@ -0,0 +1,111 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/oracle/oci-go-sdk/loadbalancer"
+)
+
+const (
+	// requests sampled to measure the traffic split of weighted backends
+	canaryRequests = 200
+	// max difference of the observed and the configured share of a backend
+	canaryTolerance = 0.1
+)
+
+// checkCanaryWeights samples requests to the LB and asserts that backends of different weights,
+// e.g. a canary, get their share of the traffic within canaryTolerance. It is skipped with equal weights.
+func checkCanaryWeights(t *testing.T) {
+	lbID := terraform.Output(t, options, "lb_id")
+	weights := backendWeights(t, loadBalancerClient(t), lbID)
+	if !differentWeights(weights) {
+		t.Skipf("backends of %s have the same weight: %v", lbBackendSetName, weights)
+	}
+	totalWeight := 0
+	for _, weight := range weights {
+		totalWeight += weight
+	}
+
+	url := "http://" + outputValues(t, "lb_ip")[0] + "/"
+	// a new connection per request, so that the LB balances each of them
+	transport := httpTransport()
+	transport.DisableKeepAlives = true
+	client := &http.Client{Timeout: httpTimeout, Transport: transport}
+	served := map[string]int{}
+	failed := []string{}
+	for i := 0; i < canaryRequests; i++ {
+		address, err := lbServerAddress(client, url)
+		if err != nil {
+			failed = append(failed, err.Error())
+			continue
+		}
+		served[address]++
+	}
+	sampled := canaryRequests - len(failed)
+	t.Logf("weights %v, %d requests served by: %v", weights, sampled, served)
+	report.AddMetric("canary traffic split", fmt.Sprintf("%v of %d requests, weights %v", served, sampled, weights))
+
+	// assertions
+	if len(failed) > 0 {
+		t.Errorf("%d of %d requests failed, errors: %v", len(failed), canaryRequests, failed)
+	}
+	if sampled == 0 {
+		t.Fatalf("no requests to %s were served", url)
+	}
+	for backend, weight := range weights {
+		expected := float64(weight) / float64(totalWeight)
+		observed := float64(served[backend]) / float64(sampled)
+		if math.Abs(observed-expected) > canaryTolerance {
+			t.Errorf("backend %s of weight %d: expected %.0f%% of requests, got %.0f%% (tolerance %.0f%%)",
+				backend, weight, expected*100, observed*100, canaryTolerance*100)
+		}
+	}
+	for backend := range served {
+		if _, ok := weights[backend]; !ok {
+			t.Errorf("request served by %s, which is not a serving backend of %s", backend, lbBackendSetName)
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// backendWeights returns weights of the backends getting traffic, i.e. not offline, drained or backup, by name (ip:port).
+func backendWeights(t *testing.T, client loadbalancer.LoadBalancerClient, lbID string) map[string]int {
+	backendSetName := lbBackendSetName
+	response, err := client.ListBackends(context.Background(), loadbalancer.ListBackendsRequest{
+		LoadBalancerId: &lbID,
+		BackendSetName: &backendSetName,
+	})
+	if err != nil {
+		t.Fatalf("error in listing backends of %s: %s", lbBackendSetName, err.Error())
+	}
+
+	weights := map[string]int{}
+	for _, backend := range response.Items {
+		if boolValue(backend.Offline) || boolValue(backend.Drain) || boolValue(backend.Backup) {
+			continue
+		}
+		weight := 1
+		if backend.Weight != nil {
+			weight = *backend.Weight
+		}
+		weights[stringValue(backend.Name)] = weight
+	}
+	return weights
+}
+
+func differentWeights(weights map[string]int) bool {
+	first := -1
+	for _, weight := range weights {
+		if first >= 0 && weight != first {
+			return true
+		}
+		first = weight
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+package terratest
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/oracle/oci-go-sdk/common"
+)
+
+const (
+	// certificates, COMPARTMENT_OCID_CERTIFICATES or CompartmentOCID
+	certificatesCompartment = "certificates"
+	// the SDK has no certificates packages, the checks call the REST APIs of the services
+	certificatesManagementEndpointTemplate = "https://certificatesmanagement.{region}.oci.{secondLevelDomain}"
+	certificatesEndpointTemplate           = "https://certificates.{region}.oci.{secondLevelDomain}"
+	certificatesPath                       = "/20210224"
+	lbPath                                 = "/20170115"
+	tlsDialTimeout                         = 10 * time.Second
+)
+
+// CertificateExpectation is a certificate of OCI Certificates served by a TLS listener of the load balancer.
+type CertificateExpectation struct {
+	Listener string `json:"listener"`
+	Name     string `json:"name"`
+	// MinValidityDays is the min remaining validity of the current version
+	MinValidityDays int `json:"minValidityDays"`
+}
+
+type certificateSummary struct {
+	Id                    *string `json:"id"`
+	Name                  *string `json:"name"`
+	LifecycleState        string  `json:"lifecycleState"`
+	CurrentVersionSummary *struct {
+		VersionNumber *int64 `json:"versionNumber"`
+		Validity      *struct {
+			TimeOfValidityNotAfter *common.SDKTime `json:"timeOfValidityNotAfter"`
+		} `json:"validity"`
+	} `json:"currentVersionSummary"`
+}
+
+type listCertificatesRequest struct {
+	CompartmentId *string `mandatory:"true" contributesTo:"query" name:"compartmentId"`
+	Name          *string `mandatory:"false" contributesTo:"query" name:"name"`
+}
+
+type listCertificatesResponse struct {
+	RawResponse           *http.Response
+	CertificateCollection struct {
+		Items []certificateSummary `json:"items"`
+	} `presentIn:"body"`
+}
+
+type getCertificateBundleRequest struct {
+	CertificateId *string `mandatory:"true" contributesTo:"path" name:"certificateId"`
+}
+
+type getCertificateBundleResponse struct {
+	RawResponse       *http.Response
+	CertificateBundle struct {
+		CertificatePem *string `json:"certificatePem"`
+		CertChainPem   *string `json:"certChainPem"`
+	} `presentIn:"body"`
+}
+
+type getLoadBalancerRequest struct {
+	LoadBalancerId *string `mandatory:"true" contributesTo:"path" name:"loadBalancerId"`
+}
+
+// getLoadBalancerResponse has the certificate ids of the listeners the SDK does not have yet.
+type getLoadBalancerResponse struct {
+	RawResponse  *http.Response
+	LoadBalancer struct {
+		Listeners map[string]struct {
+			Port             *int `json:"port"`
+			SslConfiguration *struct {
+				CertificateIds []string `json:"certificateIds"`
+			} `json:"sslConfiguration"`
+		} `json:"listeners"`
+	} `presentIn:"body"`
+}
+
+func checkListenerCertificates(t *testing.T) {
+	expected := loadExpectations(t).Certificates
+	if len(expected) == 0 {
+		t.Skip("no certificates in expectations")
+	}
+	lbID := terraform.Output(t, options, "lb_id")
+	var lb getLoadBalancerResponse
+	if err := callAPI(context.Background(), loadBalancerClient(t).BaseClient, http.MethodGet, lbPath+"/loadBalancers/{loadBalancerId}",
+		getLoadBalancerRequest{LoadBalancerId: &lbID}, &lb); err != nil {
+		t.Fatalf("error in calling load balancer %s: %s", lbID, err.Error())
+	}
+
+	for _, certificate := range expected {
+		actual := findCertificate(t, certificate.Name)
+		linkResource(t, "certificate "+certificate.Name, *actual.Id, "")
+
+		// assertions
+		if actual.LifecycleState != "ACTIVE" {
+			t.Errorf("certificate %s in state %s", certificate.Name, actual.LifecycleState)
+		}
+		if actual.CurrentVersionSummary != nil && actual.CurrentVersionSummary.Validity != nil {
+			notAfter := actual.CurrentVersionSummary.Validity.TimeOfValidityNotAfter.Time
+			days := int(time.Until(notAfter).Hours() / 24)
+			report.AddMetric("days to expiry of "+certificate.Name, days)
+			if days < certificate.MinValidityDays {
+				t.Errorf("certificate %s expires %s, in %d days, expected at least %d", certificate.Name, notAfter.Format(time.RFC3339), days, certificate.MinValidityDays)
+			}
+		}
+
+		listener, ok := lb.LoadBalancer.Listeners[certificate.Listener]
+		if !ok {
+			t.Errorf("missing listener %q", certificate.Listener)
+			continue
+		}
+		if listener.SslConfiguration == nil || !containsString(listener.SslConfiguration.CertificateIds, *actual.Id) {
+			t.Errorf("listener %s does not use certificate %s (%s)", certificate.Listener, certificate.Name, *actual.Id)
+			continue
+		}
+
+		served, err := servedChain(fmt.Sprintf("%s:%d", outputValues(t, "lb_ip")[0], *listener.Port))
+		if err != nil {
+			t.Fatalf("error in TLS handshake with listener %s: %s", certificate.Listener, err.Error())
+		}
+		managed := certificateBundle(t, *actual.Id)
+		// servers usually leave out the self-signed root of the chain
+		if len(managed) > 0 && len(served) == len(managed)-1 && isSelfSigned(managed[len(managed)-1]) {
+			managed = managed[:len(served)]
+		}
+		if len(served) != len(managed) {
+			t.Errorf("listener %s serves %d certificates, managed chain of %s has %d", certificate.Listener, len(served), certificate.Name, len(managed))
+			continue
+		}
+		for i := range managed {
+			if !bytes.Equal(served[i].Raw, managed[i].Raw) {
+				t.Errorf("listener %s: served certificate %d %q differs from %q of %s",
+					certificate.Listener, i, served[i].Subject.CommonName, managed[i].Subject.CommonName, certificate.Name)
+			}
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func findCertificate(t *testing.T, name string) certificateSummary {
+	compartmentID := compartmentFor(certificatesCompartment)
+	var response listCertificatesResponse
+	err := callAPI(context.Background(), apiClient(t, certificatesManagementEndpointTemplate), http.MethodGet, certificatesPath+"/certificates",
+		listCertificatesRequest{CompartmentId: &compartmentID, Name: &name}, &response)
+	if err != nil {
+		t.Fatalf("error in listing certificates: %s", err.Error())
+	}
+	if len(response.CertificateCollection.Items) == 0 {
+		t.Fatalf("certificate %s not found in %s", name, compartmentID)
+	}
+	return response.CertificateCollection.Items[0]
+}
+
+// certificateBundle returns the current version of the certificate followed by its chain.
+func certificateBundle(t *testing.T, certificateID string) []*x509.Certificate {
+	var response getCertificateBundleResponse
+	err := callAPI(context.Background(), apiClient(t, certificatesEndpointTemplate), http.MethodGet, certificatesPath+"/certificateBundles/{certificateId}",
+		getCertificateBundleRequest{CertificateId: &certificateID}, &response)
+	if err != nil {
+		t.Fatalf("error in calling bundle of certificate %s: %s", certificateID, err.Error())
+	}
+
+	bundle := response.CertificateBundle
+	certificates, err := parsePemCertificates(stringValue(bundle.CertificatePem) + "\n" + stringValue(bundle.CertChainPem))
+	if err != nil {
+		t.Fatalf("error in parsing bundle of certificate %s: %s", certificateID, err.Error())
+	}
+	return certificates
+}
+
+// servedChain returns the certificates sent by the server in the TLS handshake, the leaf first.
+func servedChain(address string) ([]*x509.Certificate, error) {
+	dialer := &net.Dialer{Timeout: tlsDialTimeout}
+	// the chain is compared with the managed certificate, it is not verified against the system roots
+	conn, err := tls.DialWithDialer(dialer, "tcp", address, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates, nil
+}
+
+func isSelfSigned(certificate *x509.Certificate) bool {
+	return bytes.Equal(certificate.RawSubject, certificate.RawIssuer)
+}
+
+func parsePemCertificates(content string) ([]*x509.Certificate, error) {
+	certificates := []*x509.Certificate{}
+	rest := []byte(content)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return certificates, nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certificates = append(certificates, certificate)
+	}
+}
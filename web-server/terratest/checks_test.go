@@ -0,0 +1,217 @@
+package terratest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+const (
+	// check tags
+	tagNetwork    = "network"
+	tagSsh        = "ssh"
+	tagSecurity   = "security"
+	tagLB         = "lb"
+	tagHost       = "host"
+	tagCompute    = "compute"
+	tagMonitoring = "monitoring"
+	tagIdentity   = "identity"
+	tagAudit      = "audit"
+	tagReport     = "report"
+	// quick checks that the stack serves at all
+	tagSmoke = "smoke"
+	// destructive scenarios, they replace resources of the stack
+	tagChaos = "chaos"
+	// load tests
+	tagLoad = "load"
+)
+
+var (
+	suiteName = flag.String("suite", os.Getenv("SUITE"), "suite of checks: smoke, full (default) or nightly, which adds the destructive chaos and load scenarios")
+
+	smokeSuite   = Suite{Name: "smoke", Tags: []string{tagSmoke}}
+	fullSuite    = Suite{Name: "full", SkipTags: []string{tagChaos, tagLoad}}
+	nightlySuite = Suite{Name: "nightly"}
+	suites       = []Suite{smokeSuite, fullSuite, nightlySuite}
+)
+
+// Suite is a named subset of checks, selected by -suite or SUITE.
+type Suite struct {
+	Name string
+	// Tags of the checks in the suite, all checks when empty
+	Tags     []string
+	SkipTags []string
+}
+
+// Check is a named subtest of the stack, selected at runtime by its name or tags.
+type Check struct {
+	Name string
+	Tags []string
+	Run  func(t *testing.T)
+}
+
+// checks run in this order, e.g. the health check waits for the stack to converge, scenarios come last.
+var checks = []Check{
+	{"checkLoadBalancerHealthy", []string{tagLB}, checkLoadBalancerHealthy},
+	{"sshBastion", []string{tagSmoke, tagSsh}, sshBastion},
+	{"sshWeb", []string{tagSmoke, tagSsh}, sshWeb},
+	{"netstatNginx", []string{tagSsh, tagHost}, netstatNginx},
+	{"curlWebServer", []string{tagSmoke, tagSsh}, curlWebServer},
+	{"checkVpn", []string{tagNetwork}, checkVpn},
+	{"checkGetAllAvailabilityDomains", []string{tagIdentity}, checkGetAllAvailabilityDomains},
+	{"checkSubnetsCount", []string{tagNetwork}, checkSubnetsCount},
+	{"checkSubnetsInVcn", []string{tagNetwork}, checkSubnetsInVcn},
+	{"checkLoadBalancerCurl", []string{tagSmoke, tagLB}, checkLoadBalancerCurl},
+	{"checkVcnGateways", []string{tagNetwork}, checkVcnGateways},
+	{"checkDhcpOptions", []string{tagNetwork}, checkDhcpOptions},
+	{"checkWebDnsResolution", []string{tagNetwork, tagSsh}, checkWebDnsResolution},
+	{"checkDnsResolver", []string{tagNetwork, tagSsh, tagReport}, checkDnsResolver},
+	{"checkWebEgress", []string{tagNetwork, tagSsh}, checkWebEgress},
+	{"checkLoadBalancerConfiguration", []string{tagLB}, checkLoadBalancerConfiguration},
+	{"checkSessionPersistence", []string{tagLB}, checkSessionPersistence},
+	{"checkIndexGolden", []string{tagLB, tagSsh}, checkIndexGolden},
+	{"checkResponseHeaders", []string{tagLB, tagSsh, tagSecurity}, checkResponseHeaders},
+	{"checkGzipResponse", []string{tagLB, tagSsh}, checkGzipResponse},
+	{"checkKeepAlive", []string{tagLB}, checkKeepAlive},
+	{"checkAuditCreateEvents", []string{tagAudit}, checkAuditCreateEvents},
+	{"logConsoleLinks", []string{tagReport}, logConsoleLinks},
+	{"checkResourceCompartments", []string{tagIdentity}, checkResourceCompartments},
+	{"checkQuotaPolicies", []string{tagIdentity}, checkQuotaPolicies},
+	{"checkSecurityPolicies", []string{tagSecurity, tagNetwork}, checkSecurityPolicies},
+	{"checkTierReachability", []string{tagSecurity, tagNetwork}, checkTierReachability},
+	{"checkBastionTcpProbes", []string{tagSecurity, tagNetwork, tagSsh}, checkBastionTcpProbes},
+	{"checkPublicIpExposure", []string{tagSecurity, tagNetwork}, checkPublicIpExposure},
+	{"checkInstanceVnics", []string{tagNetwork}, checkInstanceVnics},
+	{"checkPackages", []string{tagHost, tagSsh}, checkPackages},
+	{"checkOsVersion", []string{tagHost, tagSsh}, checkOsVersion},
+	{"checkTimeSync", []string{tagHost, tagSsh}, checkTimeSync},
+	{"checkBootVolumes", []string{tagHost, tagSsh}, checkBootVolumes},
+	{"checkHostFirewalls", []string{tagHost, tagSsh, tagSecurity}, checkHostFirewalls},
+	{"checkSelinux", []string{tagHost, tagSsh, tagSecurity}, checkSelinux},
+	{"checkPatchingPosture", []string{tagHost, tagSecurity}, checkPatchingPosture},
+	{"checkAgentPlugins", []string{tagHost, tagSsh}, checkAgentPlugins},
+	{"checkAlarms", []string{tagMonitoring}, checkAlarms},
+	{"checkInstanceMetrics", []string{tagMonitoring}, checkInstanceMetrics},
+	{"checkNotifications", []string{tagMonitoring}, checkNotifications},
+	{"checkNotificationDelivery", []string{tagMonitoring}, checkNotificationDelivery},
+	{"checkFlowLogs", []string{tagMonitoring, tagNetwork}, checkFlowLogs},
+	{"checkAccessLogs", []string{tagMonitoring, tagLB}, checkAccessLogs},
+	{"checkAccessLogSearch", []string{tagMonitoring, tagLB}, checkAccessLogSearch},
+	{"checkFlowLogVerdicts", []string{tagMonitoring, tagNetwork, tagSecurity}, checkFlowLogVerdicts},
+	{"checkVault", []string{tagSecurity}, checkVault},
+	{"checkVaultSecrets", []string{tagSecurity}, checkVaultSecrets},
+	{"checkListenerCertificates", []string{tagLB, tagSecurity}, checkListenerCertificates},
+	{"checkWafPolicy", []string{tagLB, tagSecurity}, checkWafPolicy},
+	{"checkWafAttackSimulation", []string{tagLB, tagSecurity}, checkWafAttackSimulation},
+	{"checkBudget", []string{tagIdentity}, checkBudget},
+	{"checkCostTrackingTag", []string{tagIdentity, tagCompute}, checkCostTrackingTag},
+	{"checkInstancePlacement", []string{tagCompute}, checkInstancePlacement},
+	{"checkBurstableBaseline", []string{tagCompute}, checkBurstableBaseline},
+	{"checkBurstableBurst", []string{tagCompute, tagMonitoring, tagLoad}, checkBurstableBurst},
+	{"checkPreemptibleConfig", []string{tagCompute}, checkPreemptibleConfig},
+	{"checkMultiAdSpread", []string{tagNetwork, tagCompute}, checkMultiAdSpread},
+	{"checkBootVolumeBackupReplication", []string{tagCompute}, checkBootVolumeBackupReplication},
+	{"checkBucketReplication", []string{tagCompute}, checkBucketReplication},
+	{"checkBootVolumeBackupPolicy", []string{tagCompute}, checkBootVolumeBackupPolicy},
+	{"checkFileStorage", []string{tagCompute}, checkFileStorage},
+	{"checkFileStorageMount", []string{tagCompute, tagSsh}, checkFileStorageMount},
+	{"checkDatabase", []string{tagNetwork, tagSecurity}, checkDatabase},
+	{"checkDatabaseReachability", []string{tagNetwork, tagSecurity, tagSsh}, checkDatabaseReachability},
+	{"checkDatabaseCredentials", []string{tagSecurity, tagSsh}, checkDatabaseCredentials},
+	{"checkSteeringPolicy", []string{tagNetwork}, checkSteeringPolicy},
+	{"checkApprovedSender", []string{tagIdentity}, checkApprovedSender},
+	{"checkMailRelay", []string{tagSsh, tagNetwork}, checkMailRelay},
+	{"checkImageFreshness", []string{tagCompute}, checkImageFreshness},
+	{"checkConsoleConnection", []string{tagCompute, tagSecurity}, checkConsoleConnection},
+	{"checkConvergence", []string{tagHost}, checkConvergence},
+	{"checkCanaryWeights", []string{tagLB}, checkCanaryWeights},
+	{"checkIpv6", []string{tagNetwork, tagSsh}, checkIpv6},
+	{"checkTierLatency", []string{tagNetwork, tagSsh, tagReport}, checkTierLatency},
+	{"checkBandwidth", []string{tagNetwork, tagLB, tagReport}, checkBandwidth},
+	{"checkProvisioningSLO", []string{tagLB, tagReport}, checkProvisioningSLO},
+	{"checkInstancePool", []string{tagCompute}, checkInstancePool},
+	{"checkInstancePoolBackends", []string{tagCompute, tagLB}, checkInstancePoolBackends},
+	{"scenarioScaleOut", []string{tagCompute, tagLoad}, scenarioScaleOut},
+	{"scenarioBootVolumeRestore", []string{tagCompute, tagLoad}, scenarioBootVolumeRestore},
+	{"scenarioReservedIpsSurviveReplacement", []string{tagChaos}, scenarioReservedIpsSurviveReplacement},
+	{"scenarioRollingReplacement", []string{tagChaos}, scenarioRollingReplacement},
+	{"scenarioPoolInstanceReplacement", []string{tagChaos, tagCompute}, scenarioPoolInstanceReplacement},
+	{"scenarioPreemption", []string{tagChaos, tagCompute}, scenarioPreemption},
+	{"scenarioAdFailover", []string{tagChaos, tagLB}, scenarioAdFailover},
+	{"scenarioSteeringFailover", []string{tagChaos, tagNetwork}, scenarioSteeringFailover},
+	{"scenarioBlueGreenSwitch", []string{tagChaos, tagLB}, scenarioBlueGreenSwitch},
+}
+
+// Matches is true when any of the selectors is the name or a tag of the check.
+func (c Check) Matches(selectors []string) bool {
+	for _, selector := range selectors {
+		if selector == c.Name {
+			return true
+		}
+		for _, tag := range c.Tags {
+			if selector == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// selectedSuite returns the suite of -suite or SUITE, full when not set, so chaos and load scenarios
+// run only with an explicit -suite nightly.
+func selectedSuite(t *testing.T) Suite {
+	if *suiteName == "" {
+		return fullSuite
+	}
+	for _, suite := range suites {
+		if suite.Name == *suiteName {
+			return suite
+		}
+	}
+	t.Fatalf("unknown suite %q", *suiteName)
+	return Suite{}
+}
+
+// selectedChecks returns checks of the suite matching CHECKS (all when not set) and not matching SKIP_CHECKS,
+// both are comma separated names or tags, e.g. CHECKS=network,lb SKIP_CHECKS=chaos.
+func selectedChecks(suite Suite) []Check {
+	include := envList("CHECKS")
+	exclude := envList("SKIP_CHECKS")
+
+	selected := []Check{}
+	for _, check := range checks {
+		if len(suite.Tags) > 0 && !check.Matches(suite.Tags) {
+			continue
+		}
+		if check.Matches(suite.SkipTags) {
+			continue
+		}
+		if len(include) > 0 && !check.Matches(include) {
+			continue
+		}
+		if check.Matches(exclude) {
+			continue
+		}
+		selected = append(selected, check)
+	}
+	return selected
+}
+
+// String describes the suite in logs.
+func (s Suite) String() string {
+	return fmt.Sprintf("%s (tags %v, skipped tags %v)", s.Name, s.Tags, s.SkipTags)
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func envList(name string) []string {
+	values := []string{}
+	for _, value := range strings.Split(os.Getenv(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,188 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/core"
+)
+
+const (
+	// stack copies get 10.<index>.0.0/16, the default stack is 10.0.0.0/16
+	planBaseCidr     = "10.0.0.0/8"
+	planVcnNewBits   = 8
+	planSubnetBits   = 8
+	planSmallBits    = 12
+	planBastionStart = 100 * 16
+	planLBStart      = 200 * 16
+	planBastionCount = 3
+)
+
+// NetworkPlan are the CIDRs of one copy of the stack.
+type NetworkPlan struct {
+	VCN     string
+	Private string
+	Bastion []string
+	LB      string
+}
+
+// PlanNetwork computes CIDRs of the copy with the given index, copies never overlap each other.
+// Index 0 is the layout of the stack defaults.
+func PlanNetwork(index int) (NetworkPlan, error) {
+	vcn, err := CidrSubnet(planBaseCidr, planVcnNewBits, index)
+	if err != nil {
+		return NetworkPlan{}, err
+	}
+
+	plan := NetworkPlan{VCN: vcn}
+	if plan.Private, err = CidrSubnet(vcn, planSubnetBits, 0); err != nil {
+		return plan, err
+	}
+	for i := 0; i < planBastionCount; i++ {
+		bastion, err := CidrSubnet(vcn, planSmallBits, planBastionStart+i)
+		if err != nil {
+			return plan, err
+		}
+		plan.Bastion = append(plan.Bastion, bastion)
+	}
+	plan.LB, err = CidrSubnet(vcn, planSmallBits, planLBStart)
+	return plan, err
+}
+
+// Vars returns the plan as terraform variables.
+func (plan NetworkPlan) Vars() map[string]string {
+	bastion := ""
+	for i, cidr := range plan.Bastion {
+		if i > 0 {
+			bastion += ", "
+		}
+		bastion += fmt.Sprintf("%q", cidr)
+	}
+
+	return map[string]string{
+		"VCNCIDR":            plan.VCN,
+		"PrivateSubnetCIDR":  plan.Private,
+		"BastionSubnetCIDRs": "[" + bastion + "]",
+		"LBSubnetCIDR":       plan.LB,
+	}
+}
+
+// CidrSubnet works as the terraform cidrsubnet function, for IPv4 and IPv6 prefixes.
+func CidrSubnet(prefix string, newBits int, num int) (string, error) {
+	_, network, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return "", err
+	}
+
+	ip := network.IP.To4()
+	if ip == nil {
+		ip = network.IP.To16()
+	}
+	ones, bits := network.Mask.Size()
+	if ones+newBits > bits {
+		return "", fmt.Errorf("cannot extend %s by %d bits", prefix, newBits)
+	}
+	if num < 0 || (newBits < 63 && num >= 1<<uint(newBits)) {
+		return "", fmt.Errorf("subnet number %d does not fit %d bits", num, newBits)
+	}
+
+	address := new(big.Int).SetBytes(ip)
+	address.Or(address, new(big.Int).Lsh(big.NewInt(int64(num)), uint(bits-ones-newBits)))
+	subnet := make(net.IP, len(ip))
+	value := address.Bytes()
+	copy(subnet[len(subnet)-len(value):], value)
+	return fmt.Sprintf("%s/%d", subnet, ones+newBits), nil
+}
+
+// CidrOverlaps returns true when the networks share any address.
+func CidrOverlaps(a string, b string) bool {
+	_, aNet, err := net.ParseCIDR(a)
+	if err != nil {
+		return false
+	}
+	_, bNet, err := net.ParseCIDR(b)
+	if err != nil {
+		return false
+	}
+	return aNet.Contains(bNet.IP) || bNet.Contains(aNet.IP)
+}
+
+// checkVcnOverlap fails before apply when other VCN of the compartment overlaps the planned one,
+// e.g. a stack copy or a leftover of a previous run.
+func checkVcnOverlap(t *testing.T) {
+	client := virtualNetworkClient(t)
+	compartmentID := compartmentFor(networkCompartment)
+	planned := stringVar("VCNCIDR", defaultVcnCidr)
+	own := "Web VCN-" + workspace()
+
+	request := core.ListVcnsRequest{CompartmentId: &compartmentID}
+	for {
+		response, err := client.ListVcns(context.Background(), request)
+		if err != nil {
+			t.Fatalf("error in listing vcns: %s", err.Error())
+		}
+
+		for _, vcn := range response.Items {
+			// the VCN of the workspace itself when re-applied
+			if *vcn.DisplayName == own {
+				continue
+			}
+			if CidrOverlaps(planned, *vcn.CidrBlock) {
+				t.Fatalf("planned VCN %s overlaps %s of VCN %q (%s)", planned, *vcn.CidrBlock, *vcn.DisplayName, *vcn.Id)
+			}
+		}
+
+		if response.OpcNextPage == nil {
+			return
+		}
+		request.Page = response.OpcNextPage
+	}
+}
+
+func checkSubnetsInVcn(t *testing.T) {
+	client := virtualNetworkClient(t)
+	compartmentID := compartmentFor(networkCompartment)
+	vcnID := sanitizedVcnId(t)
+
+	vcn, err := client.GetVcn(context.Background(), core.GetVcnRequest{VcnId: &vcnID})
+	if err != nil {
+		t.Fatalf("error in calling vcn: %s", err.Error())
+	}
+
+	listed := []core.Subnet{}
+	WaitForCount(t, "subnets of vcn "+vcnID, listSubnets(client, compartmentID, vcnID, &listed), expectedSubnetCount, listConsistencyTimeout)
+
+	// assertions
+	subnets := map[string]string{}
+	ipv6Subnets := map[string]string{}
+	vcnIpv6 := stringValue(vcn.Vcn.Ipv6CidrBlock)
+	for _, subnet := range listed {
+		if !cidrContains(*vcn.Vcn.CidrBlock, *subnet.CidrBlock) {
+			t.Errorf("subnet %q %s is not inside VCN %s", *subnet.DisplayName, *subnet.CidrBlock, *vcn.Vcn.CidrBlock)
+		}
+		for name, cidr := range subnets {
+			if CidrOverlaps(cidr, *subnet.CidrBlock) {
+				t.Errorf("subnet %q %s overlaps subnet %q %s", *subnet.DisplayName, *subnet.CidrBlock, name, cidr)
+			}
+		}
+		subnets[*subnet.DisplayName] = *subnet.CidrBlock
+
+		// dual-stack VCN
+		if vcnIpv6 == "" {
+			continue
+		}
+		subnetIpv6 := stringValue(subnet.Ipv6CidrBlock)
+		if !cidrContains(vcnIpv6, subnetIpv6) {
+			t.Errorf("subnet %q IPv6 %q is not inside VCN %s", *subnet.DisplayName, subnetIpv6, vcnIpv6)
+		}
+		for name, cidr := range ipv6Subnets {
+			if CidrOverlaps(cidr, subnetIpv6) {
+				t.Errorf("subnet %q IPv6 %s overlaps subnet %q %s", *subnet.DisplayName, subnetIpv6, name, cidr)
+			}
+		}
+		ipv6Subnets[*subnet.DisplayName] = subnetIpv6
+	}
+}
@@ -0,0 +1,529 @@
+// Command ocicheck validates an existing environment of the web-server stack without terraform
+// and the Go test harness: VCN, subnets, load balancer health and SSH to bastion and web servers.
+//
+//	ocicheck -compartment ocid1.compartment... -region eu-frankfurt-1 -ssh-key ~/.ssh/id_rsa
+//
+// Resources are found in the compartment by display names of the stack (see -workspace).
+// Credentials are read like by the test suite: the profile OCI_CLI_PROFILE (or OCI_PROFILE) of
+// OCI_CLI_CONFIG_FILE (~/.oci/config), sessions of `oci session authenticate` with OCI_CLI_AUTH=security_token,
+// the key passphrase from OCI_CLI_PASSPHRASE. OCI_HTTPS_PROXY overrides HTTPS_PROXY for the API calls.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/core"
+	"github.com/oracle/oci-go-sdk/loadbalancer"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	sshPort        = "22"
+	sshDialTimeout = 30 * time.Second
+	lbHealthOK     = "OK"
+	// OCI_CLI_AUTH of sessions of `oci session authenticate`
+	securityTokenAuth = "security_token"
+	defaultOciProfile = "DEFAULT"
+)
+
+var (
+	compartmentID    = flag.String("compartment", os.Getenv("TF_VAR_CompartmentOCID"), "OCID of the compartment of the environment")
+	region           = flag.String("region", os.Getenv("TF_VAR_region"), "region of the environment")
+	workspace        = flag.String("workspace", "default", "terraform workspace, suffix of display names")
+	expectationsFile = flag.String("expectations", "", "expectations file of the test suite, its os section is checked over SSH")
+	subnetCount      = flag.Int("subnets", 3, "expected number of subnets in the VCN")
+	sshUser          = flag.String("ssh-user", "opc", "SSH user of the instances")
+	sshKey           = flag.String("ssh-key", os.Getenv("TF_VAR_ssh_private_key"), "private key of the instances, SSH checks are skipped when empty")
+	knownHostsFile   = flag.String("known-hosts", "", "known_hosts file to verify host keys, any host key is accepted when empty")
+
+	// display names of the instances without the workspace suffix, green web servers are not checked
+	bastionName   = regexp.MustCompile(`^bastion\d+$`)
+	webServerName = regexp.MustCompile(`^webServer\d+$`)
+)
+
+// environment is the state shared by checks, found in the compartment by the first checks.
+type environment struct {
+	config    common.ConfigurationProvider
+	vcn       *core.Vcn
+	bastionIP string
+	webIPs    []string
+	os        *osExpectation
+}
+
+// osExpectation is the os section of the expectations file.
+type osExpectation struct {
+	ID           string `json:"id"`
+	MajorVersion string `json:"majorVersion"`
+}
+
+type check struct {
+	name string
+	run  func(env *environment) error
+}
+
+func main() {
+	flag.Parse()
+	if *compartmentID == "" || *region == "" {
+		fmt.Fprintln(os.Stderr, "-compartment and -region are required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	config, err := configProvider()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error in loading OCI config: %s\n", err.Error())
+		os.Exit(2)
+	}
+	env := &environment{config: config}
+	if *expectationsFile != "" {
+		if err := loadOsExpectation(env, *expectationsFile); err != nil {
+			fmt.Fprintf(os.Stderr, "error in loading expectations: %s\n", err.Error())
+			os.Exit(2)
+		}
+	}
+
+	checks := []check{
+		{"vcn", checkVcn},
+		{"subnets", checkSubnets},
+		{"load balancer health", checkLoadBalancerHealth},
+		{"instances", findInstances},
+		{"ssh bastion", checkSshBastion},
+		{"ssh web", checkSshWeb},
+	}
+
+	failed := 0
+	for _, c := range checks {
+		start := time.Now()
+		err := c.run(env)
+		duration := time.Since(start).Round(time.Millisecond)
+		if err != nil {
+			failed++
+			fmt.Printf("FAIL %-22s %s: %s\n", c.name, duration, err.Error())
+		} else {
+			fmt.Printf("ok   %-22s %s\n", c.name, duration)
+		}
+	}
+
+	if failed > 0 {
+		fmt.Printf("%d of %d checks failed\n", failed, len(checks))
+		os.Exit(1)
+	}
+}
+
+func checkVcn(env *environment) error {
+	client, err := core.NewVirtualNetworkClientWithConfigurationProvider(env.config)
+	if err != nil {
+		return err
+	}
+	client.SetRegion(*region)
+	useOciProxy(&client.BaseClient)
+
+	name := "Web VCN-" + *workspace
+	response, err := client.ListVcns(context.Background(), core.ListVcnsRequest{
+		CompartmentId: compartmentID,
+		DisplayName:   &name,
+	})
+	if err != nil {
+		return err
+	}
+	if len(response.Items) != 1 {
+		return fmt.Errorf("expected 1 VCN %q, got %d", name, len(response.Items))
+	}
+
+	env.vcn = &response.Items[0]
+	if env.vcn.LifecycleState != core.VcnLifecycleStateAvailable {
+		return fmt.Errorf("VCN %s is %s", *env.vcn.Id, env.vcn.LifecycleState)
+	}
+	return nil
+}
+
+func checkSubnets(env *environment) error {
+	if env.vcn == nil {
+		return fmt.Errorf("no VCN")
+	}
+	client, err := core.NewVirtualNetworkClientWithConfigurationProvider(env.config)
+	if err != nil {
+		return err
+	}
+	client.SetRegion(*region)
+	useOciProxy(&client.BaseClient)
+
+	response, err := client.ListSubnets(context.Background(), core.ListSubnetsRequest{
+		CompartmentId: compartmentID,
+		VcnId:         env.vcn.Id,
+	})
+	if err != nil {
+		return err
+	}
+	if len(response.Items) != *subnetCount {
+		return fmt.Errorf("expected %d subnets, got %d", *subnetCount, len(response.Items))
+	}
+
+	_, vcnNet, err := net.ParseCIDR(*env.vcn.CidrBlock)
+	if err != nil {
+		return err
+	}
+	for _, subnet := range response.Items {
+		ip, subnetNet, err := net.ParseCIDR(*subnet.CidrBlock)
+		if err != nil {
+			return err
+		}
+		vcnOnes, _ := vcnNet.Mask.Size()
+		subnetOnes, _ := subnetNet.Mask.Size()
+		if !vcnNet.Contains(ip) || subnetOnes < vcnOnes {
+			return fmt.Errorf("subnet %q %s is not inside VCN %s", *subnet.DisplayName, *subnet.CidrBlock, *env.vcn.CidrBlock)
+		}
+	}
+	return nil
+}
+
+func checkLoadBalancerHealth(env *environment) error {
+	client, err := loadbalancer.NewLoadBalancerClientWithConfigurationProvider(env.config)
+	if err != nil {
+		return err
+	}
+	client.SetRegion(*region)
+	useOciProxy(&client.BaseClient)
+
+	name := "lb-web-" + *workspace
+	response, err := client.ListLoadBalancers(context.Background(), loadbalancer.ListLoadBalancersRequest{
+		CompartmentId: compartmentID,
+		DisplayName:   &name,
+	})
+	if err != nil {
+		return err
+	}
+	if len(response.Items) == 0 {
+		return fmt.Errorf("no load balancer %q", name)
+	}
+
+	for _, lb := range response.Items {
+		health, err := client.GetLoadBalancerHealth(context.Background(), loadbalancer.GetLoadBalancerHealthRequest{
+			LoadBalancerId: lb.Id,
+		})
+		if err != nil {
+			return err
+		}
+		if status := string(health.LoadBalancerHealth.Status); status != lbHealthOK {
+			return fmt.Errorf("load balancer %s is %s", *lb.Id, status)
+		}
+	}
+	return nil
+}
+
+// findInstances finds IPs of running bastions and web servers of the workspace.
+func findInstances(env *environment) error {
+	compute, err := core.NewComputeClientWithConfigurationProvider(env.config)
+	if err != nil {
+		return err
+	}
+	compute.SetRegion(*region)
+	useOciProxy(&compute.BaseClient)
+	network, err := core.NewVirtualNetworkClientWithConfigurationProvider(env.config)
+	if err != nil {
+		return err
+	}
+	network.SetRegion(*region)
+	useOciProxy(&network.BaseClient)
+
+	instances, err := compute.ListInstances(context.Background(), core.ListInstancesRequest{
+		CompartmentId:  compartmentID,
+		LifecycleState: core.InstanceLifecycleStateRunning,
+	})
+	if err != nil {
+		return err
+	}
+
+	suffix := "-" + *workspace
+	for _, instance := range instances.Items {
+		name := *instance.DisplayName
+		if !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		name = strings.TrimSuffix(name, suffix)
+		bastion := bastionName.MatchString(name)
+		if !bastion && !webServerName.MatchString(name) {
+			continue
+		}
+
+		attachments, err := compute.ListVnicAttachments(context.Background(), core.ListVnicAttachmentsRequest{
+			CompartmentId: compartmentID,
+			InstanceId:    instance.Id,
+		})
+		if err != nil {
+			return err
+		}
+		for _, attachment := range attachments.Items {
+			vnic, err := network.GetVnic(context.Background(), core.GetVnicRequest{VnicId: attachment.VnicId})
+			if err != nil {
+				return err
+			}
+			if vnic.IsPrimary == nil || !*vnic.IsPrimary {
+				continue
+			}
+			if bastion && vnic.PublicIp != nil && env.bastionIP == "" {
+				env.bastionIP = *vnic.PublicIp
+			} else if !bastion {
+				env.webIPs = append(env.webIPs, *vnic.PrivateIp)
+			}
+		}
+	}
+
+	if env.bastionIP == "" || len(env.webIPs) == 0 {
+		return fmt.Errorf("expected a bastion and web servers, got bastion %q and web servers %v", env.bastionIP, env.webIPs)
+	}
+	return nil
+}
+
+func checkSshBastion(env *environment) error {
+	if *sshKey == "" || env.bastionIP == "" {
+		return skipped()
+	}
+	return sshSmoke(env, env.bastionIP)
+}
+
+func checkSshWeb(env *environment) error {
+	if *sshKey == "" || env.bastionIP == "" {
+		return skipped()
+	}
+	for _, ip := range env.webIPs {
+		if err := sshSmoke(env, ip); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// configProvider returns the credentials of the selected profile of the OCI config file.
+func configProvider() (common.ConfigurationProvider, error) {
+	file := expandHome("~/.oci/config")
+	if value := os.Getenv("OCI_CLI_CONFIG_FILE"); value != "" {
+		file = expandHome(value)
+	}
+	profile := defaultOciProfile
+	for _, env := range []string{"OCI_PROFILE", "OCI_CLI_PROFILE"} {
+		if value := os.Getenv(env); value != "" {
+			profile = value
+			break
+		}
+	}
+
+	provider, err := common.ConfigurationProviderFromFileWithProfile(file, profile, os.Getenv("OCI_CLI_PASSPHRASE"))
+	if err != nil {
+		return nil, err
+	}
+	if os.Getenv("OCI_CLI_AUTH") != securityTokenAuth {
+		return provider, nil
+	}
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	tokenFile := profileValue(content, profile, "security_token_file")
+	if tokenFile == "" {
+		return nil, fmt.Errorf("security_token_file missing in profile %s of %s", profile, file)
+	}
+	return securityTokenProvider{ConfigurationProvider: provider, tokenFile: expandHome(tokenFile)}, nil
+}
+
+// securityTokenProvider signs requests by the session token instead of the API key of a user.
+type securityTokenProvider struct {
+	common.ConfigurationProvider
+	tokenFile string
+}
+
+// KeyID is the session token, read on every request as `oci session refresh` rewrites the file.
+func (p securityTokenProvider) KeyID() (string, error) {
+	token, err := ioutil.ReadFile(p.tokenFile)
+	if err != nil {
+		return "", err
+	}
+	return "ST$" + strings.TrimSpace(string(token)), nil
+}
+
+// profileValue returns the value of key in the profile of the OCI config file, inherited from DEFAULT.
+func profileValue(content []byte, profile string, key string) string {
+	values := map[string]string{}
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		pair := strings.SplitN(line, "=", 2)
+		if len(pair) != 2 || strings.TrimSpace(pair[0]) != key {
+			continue
+		}
+		if section == profile || section == defaultOciProfile {
+			values[section] = strings.TrimSpace(pair[1])
+		}
+	}
+	if value, ok := values[profile]; ok {
+		return value
+	}
+	return values[defaultOciProfile]
+}
+
+// useOciProxy sends the requests of the client through OCI_HTTPS_PROXY when set, HTTPS_PROXY and NO_PROXY apply otherwise.
+func useOciProxy(client *common.BaseClient) {
+	value := os.Getenv("OCI_HTTPS_PROXY")
+	dispatcher, ok := client.HTTPClient.(*http.Client)
+	if value == "" || !ok {
+		return
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	proxy, err := url.Parse(value)
+	if err != nil {
+		err = fmt.Errorf("wrong OCI_HTTPS_PROXY %q: %s", value, err.Error())
+		transport.Proxy = func(*http.Request) (*url.URL, error) { return nil, err }
+	} else {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	dispatcher.Transport = transport
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+func skipped() error {
+	if *sshKey == "" {
+		fmt.Println("     skipped, no -ssh-key")
+		return nil
+	}
+	return fmt.Errorf("no bastion")
+}
+
+// sshSmoke checks the user and the os of the host, web servers are reached through the bastion.
+func sshSmoke(env *environment, ip string) error {
+	out, err := runSsh(env, ip, "whoami")
+	if err != nil {
+		return err
+	}
+	if out != *sshUser {
+		return fmt.Errorf("%s: expected user %q, got %q", ip, *sshUser, out)
+	}
+
+	if env.os == nil {
+		return nil
+	}
+	out, err = runSsh(env, ip, ". /etc/os-release && echo \"$ID ${VERSION_ID%%.*}\"")
+	if err != nil {
+		return err
+	}
+	if expected := env.os.ID + " " + env.os.MajorVersion; out != expected {
+		return fmt.Errorf("%s: expected os %q, got %q", ip, expected, out)
+	}
+	return nil
+}
+
+func runSsh(env *environment, ip string, command string) (string, error) {
+	config, err := sshConfig()
+	if err != nil {
+		return "", err
+	}
+
+	bastion, err := ssh.Dial("tcp", net.JoinHostPort(env.bastionIP, sshPort), config)
+	if err != nil {
+		return "", err
+	}
+	defer bastion.Close()
+
+	client := bastion
+	if ip != env.bastionIP {
+		address := net.JoinHostPort(ip, sshPort)
+		conn, err := bastion.Dial("tcp", address)
+		if err != nil {
+			return "", err
+		}
+		clientConn, chans, reqs, err := ssh.NewClientConn(conn, address, config)
+		if err != nil {
+			return "", err
+		}
+		client = ssh.NewClient(clientConn, chans, reqs)
+		defer client.Close()
+	}
+
+	session, err := client.NewSession()
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+
+	out, err := session.CombinedOutput(command)
+	if err != nil {
+		return "", fmt.Errorf("%q on %s: %s: %s", command, ip, err.Error(), out)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func sshConfig() (*ssh.ClientConfig, error) {
+	key, err := ioutil.ReadFile(*sshKey)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if *knownHostsFile != "" {
+		if hostKeyCallback, err = knownhosts.New(*knownHostsFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return &ssh.ClientConfig{
+		User:            *sshUser,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         sshDialTimeout,
+	}, nil
+}
+
+func loadOsExpectation(env *environment, path string) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	expectations := struct {
+		Os *osExpectation `json:"os"`
+	}{}
+	if err := json.Unmarshal(content, &expectations); err != nil {
+		return err
+	}
+	if expectations.Os != nil && expectations.Os.ID != "" {
+		env.os = expectations.Os
+	}
+	return nil
+}
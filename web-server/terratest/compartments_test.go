@@ -0,0 +1,165 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/oracle/oci-go-sdk/core"
+	"github.com/oracle/oci-go-sdk/identity"
+)
+
+const (
+	networkCompartment      = "network"
+	computeCompartment      = "compute"
+	loadBalancerCompartment = "loadbalancer"
+)
+
+var (
+	compartmentRoles = []string{networkCompartment, computeCompartment, loadBalancerCompartment}
+)
+
+func checkResourceCompartments(t *testing.T) {
+	client := identityClient(t)
+	mainCompartmentID := stringVar("CompartmentOCID", "")
+
+	// hierarchy: every role compartment is the main one or its direct child
+	names := map[string]string{}
+	for _, role := range compartmentRoles {
+		id := compartmentFor(role)
+		response, err := client.GetCompartment(context.Background(), identity.GetCompartmentRequest{CompartmentId: &id})
+		if err != nil {
+			t.Fatalf("error in calling %s compartment %s: %s", role, id, err.Error())
+		}
+
+		names[id] = *response.Compartment.Name
+		t.Logf("%s compartment: %s (%s)", role, names[id], id)
+
+		if id != mainCompartmentID && *response.Compartment.CompartmentId != mainCompartmentID {
+			t.Errorf("%s compartment %s is not a child of %s", role, names[id], mainCompartmentID)
+		}
+	}
+
+	assertCompartment := func(resource string, role string, actual *string) {
+		expected := compartmentFor(role)
+		if *actual != expected {
+			t.Errorf("%s in wrong compartment: expected %s (%s), got %s", resource, names[expected], expected, *actual)
+		}
+	}
+
+	// network
+	network := virtualNetworkClient(t)
+	vcnID := sanitizedVcnId(t)
+	vcn, err := network.GetVcn(context.Background(), core.GetVcnRequest{VcnId: &vcnID})
+	if err != nil {
+		t.Fatalf("error in calling vcn: %s", err.Error())
+	}
+	assertCompartment("vcn", networkCompartment, vcn.Vcn.CompartmentId)
+
+	networkCompartmentID := compartmentFor(networkCompartment)
+	subnets, err := network.ListSubnets(context.Background(), core.ListSubnetsRequest{
+		CompartmentId: &networkCompartmentID,
+		VcnId:         &vcnID,
+	})
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	if len(subnets.Items) == 0 {
+		t.Errorf("no subnets of vcn %s in %s compartment", vcnID, networkCompartment)
+	}
+
+	// compute
+	compute := computeClient(t)
+	ids := append(outputValues(t, "WebServerIDs"), outputValues(t, "BastionIDs")...)
+	for _, id := range ids {
+		instanceID := id
+		instance, err := compute.GetInstance(context.Background(), core.GetInstanceRequest{InstanceId: &instanceID})
+		if err != nil {
+			t.Fatalf("error in calling instance %s: %s", id, err.Error())
+		}
+		assertCompartment("instance "+*instance.Instance.DisplayName, computeCompartment, instance.Instance.CompartmentId)
+	}
+
+	// load balancer
+	lb := getLoadBalancer(t)
+	assertCompartment("load balancer", loadBalancerCompartment, lb.CompartmentId)
+}
+
+// createRunCompartment creates a child compartment of parentID for this run and waits until it is ACTIVE.
+func createRunCompartment(t *testing.T, parentID string) string {
+	client := homeIdentityClient(t)
+	name := "terratest-" + random.UniqueId()
+	description := "terratest run " + report.Started.Format("2006-01-02 15:04:05")
+
+	response, err := client.CreateCompartment(context.Background(), identity.CreateCompartmentRequest{
+		CreateCompartmentDetails: identity.CreateCompartmentDetails{
+			CompartmentId: &parentID,
+			Name:          &name,
+			Description:   &description,
+		},
+	})
+	if err != nil {
+		t.Fatalf("error in creating compartment %s: %s", name, err.Error())
+	}
+
+	id := *response.Compartment.Id
+	waitForCompartmentState(t, id, identity.CompartmentLifecycleStateActive)
+	t.Logf("created run compartment %s (%s)", name, id)
+	return id
+}
+
+// deleteRunCompartment deletes the compartment, it has to be empty (after destroy).
+func deleteRunCompartment(t *testing.T, id string) {
+	client := homeIdentityClient(t)
+
+	description := fmt.Sprintf("delete compartment %s", id)
+	retry.DoWithRetry(t, description, maxRetries, sleepBetweenRetries, func() (string, error) {
+		_, err := client.DeleteCompartment(context.Background(), identity.DeleteCompartmentRequest{CompartmentId: &id})
+		return "", err
+	})
+	t.Logf("deletion of run compartment %s started", id)
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func createCompartmentEnabled() bool {
+	return os.Getenv("CREATE_COMPARTMENT") != ""
+}
+
+func waitForCompartmentState(t *testing.T, id string, state identity.CompartmentLifecycleStateEnum) {
+	client := homeIdentityClient(t)
+
+	description := fmt.Sprintf("compartment %s in state %s", id, state)
+	retry.DoWithRetry(t, description, maxRetries, sleepBetweenRetries, func() (string, error) {
+		response, err := client.GetCompartment(context.Background(), identity.GetCompartmentRequest{CompartmentId: &id})
+		if err != nil {
+			return "", err
+		}
+		if response.Compartment.LifecycleState != state {
+			return "", fmt.Errorf("compartment %s in state %s", id, response.Compartment.LifecycleState)
+		}
+		return "", nil
+	})
+}
+
+// compartmentFor returns compartment OCID of resources with the role,
+// set by COMPARTMENT_OCID_<ROLE> env var (e.g. COMPARTMENT_OCID_NETWORK), default is CompartmentOCID.
+func compartmentFor(role string) string {
+	if id := os.Getenv("COMPARTMENT_OCID_" + strings.ToUpper(role)); id != "" {
+		return id
+	}
+	return stringVar("CompartmentOCID", "")
+}
+
+func identityClient(t *testing.T) identity.IdentityClient {
+	client, err := identity.NewIdentityClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	throttle(&client.BaseClient)
+	return client
+}
@@ -0,0 +1,117 @@
+package terratest
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/retry"
+)
+
+const (
+	keepAliveRequests = 5
+)
+
+func checkGzipResponse(t *testing.T) {
+	// DisableCompression keeps the transport from decoding gzip transparently
+	transport := httpTransport()
+	transport.DisableCompression = true
+	client := &http.Client{Transport: transport, Timeout: httpTimeout}
+	url := "http://" + outputValues(t, "lb_ip")[0] + "/"
+
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	request.Header.Set("Accept-Encoding", "gzip")
+
+	response, err := client.Do(request)
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	// assertions
+	encoding := response.Header.Get("Content-Encoding")
+	if encoding != "gzip" {
+		t.Fatalf("wrong Content-Encoding from %s: expected %q, got %q", url, "gzip", encoding)
+	}
+
+	reader, err := gzip.NewReader(response.Body)
+	if err != nil {
+		t.Fatalf("response from %s is not gzip encoded: %s", url, err.Error())
+	}
+	body, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("error in decoding gzip response: %s", err.Error())
+	}
+
+	if !strings.Contains(string(body), serverNamePrefix) {
+		t.Fatalf("unexpected decoded body: %q", string(body))
+	}
+
+	// backends directly, to tell nginx from LB behavior
+	bastionHost := bastionHost(t)
+	for _, ip := range outputValues(t, "WebServerPrivateIPs") {
+		command := fmt.Sprintf("curl -s -D - -o /dev/null -H 'Accept-Encoding: gzip' http://%s:%s/", ip, nginxPort)
+		description := fmt.Sprintf("fetch gzip headers from %s", ip)
+
+		out := retry.DoWithRetry(t, description, maxRetries, sleepBetweenRetries, func() (string, error) {
+			return sshCommandE(t, bastionHost, command)
+		})
+
+		header, err := parseHeaders(out)
+		if err != nil {
+			t.Fatalf("error in parsing headers from %s: %s", ip, err.Error())
+		}
+		if header.Get("Content-Encoding") != "gzip" {
+			t.Errorf("%s: wrong Content-Encoding: expected %q, got %q", ip, "gzip", header.Get("Content-Encoding"))
+		}
+	}
+}
+
+func checkKeepAlive(t *testing.T) {
+	transport := httpTransport()
+	transport.MaxIdleConnsPerHost = 1
+	client := &http.Client{Transport: transport, Timeout: httpTimeout}
+	url := "http://" + outputValues(t, "lb_ip")[0] + "/"
+
+	reused := 0
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				reused++
+			}
+		},
+	}
+
+	for i := 0; i < keepAliveRequests; i++ {
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			t.Fatalf("error occured: %s", err.Error())
+		}
+		request = request.WithContext(httptrace.WithClientTrace(request.Context(), trace))
+
+		response, err := client.Do(request)
+		if err != nil {
+			t.Fatalf("error occured: %s", err.Error())
+		}
+		// body has to be drained to return the connection to the pool
+		ioutil.ReadAll(response.Body)
+		response.Body.Close()
+
+		if response.Close {
+			t.Fatalf("request #%d: server closed the connection (Connection: close)", i)
+		}
+	}
+
+	// assertions
+	expected := keepAliveRequests - 1
+	if reused != expected {
+		t.Fatalf("wrong number of reused connections: expected %d, got %d", expected, reused)
+	}
+}
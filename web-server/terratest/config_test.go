@@ -0,0 +1,213 @@
+package terratest
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+const (
+	envVarPrefix = "TF_VAR_"
+)
+
+var (
+	// configuration of the run, loaded by terraformEnvOptions
+	config *Config
+
+	ocidPattern = regexp.MustCompile(`^ocid1\.[a-z0-9]+\.oc[0-9]+\.[a-z0-9-]*\.[a-z0-9]+$`)
+)
+
+// Config is the configuration of the stack taken from TF_VAR_* env vars, then from the var files,
+// then from OCI_CLI_* env vars and the OCI config profile (fields with oci tag), then defaults.
+// Rules of the validate tag:
+//   - required: not empty
+//   - ocid: an OCID
+//   - file: an existing file
+//   - keyfile: an existing file, unless the run generates its ssh keys (EPHEMERAL_SSH_KEY)
+//   - apikey: required, unless security token auth is used (OCI_CLI_AUTH=security_token)
+//   - positive: greater than zero
+type Config struct {
+	Region          string `envconfig:"TF_VAR_region" oci:"region" validate:"required"`
+	TenancyOCID     string `envconfig:"TF_VAR_tenancy_ocid" oci:"tenancy" validate:"required,ocid"`
+	UserOCID        string `envconfig:"TF_VAR_user_ocid" oci:"user" validate:"apikey,ocid"`
+	CompartmentOCID string `envconfig:"TF_VAR_CompartmentOCID" validate:"required,ocid"`
+	Fingerprint     string `envconfig:"TF_VAR_fingerprint" oci:"fingerprint" validate:"required"`
+	PrivateKeyPath  string `envconfig:"TF_VAR_private_key_path" oci:"key_file" validate:"required,file"`
+	SshPublicKey    string `envconfig:"TF_VAR_ssh_public_key" validate:"keyfile"`
+	SshPrivateKey   string `envconfig:"TF_VAR_ssh_private_key" validate:"keyfile"`
+	// counts, defaults of the stack
+	WebVMCount     int `envconfig:"TF_VAR_WebVMCount" default:"1" validate:"positive"`
+	BastionVMCount int `envconfig:"TF_VAR_BastionVMCount" default:"1" validate:"positive"`
+	LBCount        int `envconfig:"TF_VAR_LBCount" default:"2" validate:"positive"`
+}
+
+// loadConfig reads and validates the configuration, all errors are reported together.
+func loadConfig(t *testing.T) *Config {
+	loaded := &Config{}
+	if err := envconfig.Process("", loaded); err != nil {
+		t.Fatalf("error in reading configuration: %s", err.Error())
+	}
+	if err := loaded.applyVarFiles(); err != nil {
+		t.Fatalf("error in reading configuration: %s", err.Error())
+	}
+	loadOciProfile(t)
+	loaded.applyOciConfig()
+
+	errs := loaded.Validate()
+	if securityTokenEnabled() {
+		if err := securityTokenError(); err != "" {
+			errs = append(errs, fmt.Sprintf("security token (%s): %s", securityTokenFile(), err))
+		}
+	}
+	if len(errs) > 0 {
+		t.Fatalf("wrong configuration:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return loaded
+}
+
+// Validate checks the fields by their validate tags, returns descriptions of all violations.
+func (c *Config) Validate() []string {
+	errs := []string{}
+	value := reflect.ValueOf(c).Elem()
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		env := field.Tag.Get("envconfig")
+		str := fmt.Sprint(value.Field(i).Interface())
+
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			var err string
+			switch rule {
+			case "required":
+				if str == "" {
+					err = "is required"
+				}
+			case "ocid":
+				if str != "" && !ocidPattern.MatchString(str) {
+					err = fmt.Sprintf("%q is not an OCID", str)
+				}
+			case "file":
+				err = fileError(str)
+			case "keyfile":
+				if !ephemeralKeyEnabled() {
+					if str == "" {
+						err = "is required, unless EPHEMERAL_SSH_KEY is set"
+					} else {
+						err = fileError(str)
+					}
+				}
+			case "apikey":
+				if str == "" && !securityTokenEnabled() {
+					err = "is required, unless OCI_CLI_AUTH=security_token is set"
+				}
+			case "positive":
+				if value.Field(i).Int() <= 0 {
+					err = fmt.Sprintf("%s has to be greater than zero", str)
+				}
+			}
+			if err != "" {
+				errs = append(errs, fmt.Sprintf("%s (%s): %s", field.Name, env, err))
+				// one error per field
+				break
+			}
+		}
+	}
+	return errs
+}
+
+// Vars returns the configuration as terraform variables, empty values and defaults not set by an env var
+// or a var file are left to terraform, so the stack and its var files keep their own defaults.
+func (c *Config) Vars() map[string]interface{} {
+	vars := map[string]interface{}{}
+	value := reflect.ValueOf(c).Elem()
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		env := field.Tag.Get("envconfig")
+		name := strings.TrimPrefix(env, envVarPrefix)
+		if _, ok := field.Tag.Lookup("default"); ok && !explicitlySet(env) {
+			continue
+		}
+		if str := fmt.Sprint(value.Field(i).Interface()); str != "" {
+			vars[name] = str
+		}
+	}
+	return vars
+}
+
+// ProviderEnvVars returns the credentials (fields with oci tag) as TF_VAR_* env vars, the OCI provider
+// of the stack reads them from the environment, so terraform and the SDK clients use the same credentials.
+func (c *Config) ProviderEnvVars() map[string]string {
+	env := map[string]string{}
+	value := reflect.ValueOf(c).Elem()
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.Tag.Get("oci") == "" {
+			continue
+		}
+		if str := fmt.Sprint(value.Field(i).Interface()); str != "" {
+			env[field.Tag.Get("envconfig")] = str
+		}
+	}
+	return env
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// explicitlySet tells whether the TF_VAR_* env var or a var file sets the variable.
+func explicitlySet(env string) bool {
+	if _, ok := os.LookupEnv(env); ok {
+		return true
+	}
+	_, ok := varFileValues[strings.TrimPrefix(env, envVarPrefix)]
+	return ok
+}
+
+// applyVarFiles sets fields without env var from the var files.
+func (c *Config) applyVarFiles() error {
+	value := reflect.ValueOf(c).Elem()
+
+	for i := 0; i < value.NumField(); i++ {
+		env := value.Type().Field(i).Tag.Get("envconfig")
+		if os.Getenv(env) != "" {
+			continue
+		}
+		fromFile, ok := varFileValues[strings.TrimPrefix(env, envVarPrefix)]
+		if !ok {
+			continue
+		}
+
+		switch value.Field(i).Kind() {
+		case reflect.String:
+			value.Field(i).SetString(fromFile)
+		case reflect.Int:
+			number, err := strconv.Atoi(fromFile)
+			if err != nil {
+				return fmt.Errorf("%s in var file: %s", env, err.Error())
+			}
+			value.Field(i).SetInt(int64(number))
+		}
+	}
+	return nil
+}
+
+func fileError(path string) string {
+	if path == "" {
+		return ""
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err.Error()
+	}
+	if info.IsDir() {
+		return fmt.Sprintf("%s is a directory", path)
+	}
+	return ""
+}
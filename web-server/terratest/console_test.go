@@ -0,0 +1,85 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/core"
+)
+
+const (
+	consoleBaseURL = "https://cloud.oracle.com"
+)
+
+var (
+	// console paths by OCID resource type, %[1]s is the OCID, %[2]s the VCN OCID
+	consolePaths = map[string]string{
+		"vcn":             "/networking/vcns/%[1]s",
+		"subnet":          "/networking/vcns/%[2]s/subnets/%[1]s",
+		"securitylist":    "/networking/vcns/%[2]s/security-lists/%[1]s",
+		"routetable":      "/networking/vcns/%[2]s/route-tables/%[1]s",
+		"internetgateway": "/networking/vcns/%[2]s/internet-gateways/%[1]s",
+		"natgateway":      "/networking/vcns/%[2]s/nat-gateways/%[1]s",
+		"instance":        "/compute/instances/%[1]s",
+		"loadbalancer":    "/networking/load-balancers/%[1]s",
+		"compartment":     "/identity/compartments/%[1]s",
+	}
+)
+
+func logConsoleLinks(t *testing.T) {
+	vcnID := sanitizedVcnId(t)
+	linkResource(t, "compartment", stringVar("CompartmentOCID", ""), "")
+
+	for i, id := range outputValues(t, "BastionIDs") {
+		linkResource(t, fmt.Sprintf("bastion%d", i), id, "")
+	}
+	for i, id := range outputValues(t, "WebServerIDs") {
+		linkResource(t, fmt.Sprintf("web%d", i), id, "")
+	}
+
+	getLoadBalancer(t)
+
+	compartmentID := compartmentFor(networkCompartment)
+	response, err := virtualNetworkClient(t).ListSubnets(context.Background(), core.ListSubnetsRequest{
+		CompartmentId: &compartmentID,
+		VcnId:         &vcnID,
+	})
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	for _, subnet := range response.Items {
+		linkResource(t, *subnet.DisplayName, *subnet.Id, vcnID)
+	}
+}
+
+// ConsoleURL returns the OCI Console URL of the resource in region,
+// vcnID is used only for VCN sub-resources (subnets, gateways,...).
+func ConsoleURL(region string, ocid string, vcnID string) string {
+	// ocid1.<resource type>.<realm>.[region].<unique id>
+	parts := strings.Split(ocid, ".")
+	if len(parts) < 3 {
+		return ""
+	}
+
+	path, ok := consolePaths[parts[1]]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(consoleBaseURL+path+"?region=%[3]s", ocid, vcnID, region)
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// linkResource logs the console URL of the resource and adds it to the report (once per OCID).
+func linkResource(t *testing.T, name string, ocid string, vcnID string) {
+	url := ConsoleURL(stringVar("region", ""), ocid, vcnID)
+	if url == "" {
+		return
+	}
+
+	if report.AddLink(name, ocid, url) {
+		t.Logf("console link %s: %s", name, url)
+	}
+}
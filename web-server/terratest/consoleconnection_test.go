@@ -0,0 +1,101 @@
+package terratest
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/core"
+)
+
+// checkConsoleConnection creates a console connection for each web server with the ssh key of the run
+// and deletes it, so that break-glass access of operators is known to work before an incident.
+// It is enabled by RUN_CONSOLE_CONNECTION=1, instances with a console connection already are skipped.
+func checkConsoleConnection(t *testing.T) {
+	if os.Getenv("RUN_CONSOLE_CONNECTION") == "" {
+		t.Skip("console connection check is enabled by RUN_CONSOLE_CONNECTION=1")
+	}
+	compute := computeClient(t)
+	publicKey := loadKeyPair(t).PublicKey
+
+	for _, instanceID := range outputValues(t, tierOutputs["web"]) {
+		id := instanceID
+		if existing := activeConsoleConnections(t, compute, id); len(existing) > 0 {
+			t.Logf("web server %s has console connection %s already, not checked", id, existing[0])
+			continue
+		}
+
+		response, err := compute.CreateInstanceConsoleConnection(context.Background(), core.CreateInstanceConsoleConnectionRequest{
+			CreateInstanceConsoleConnectionDetails: core.CreateInstanceConsoleConnectionDetails{
+				InstanceId: &id,
+				PublicKey:  &publicKey,
+			},
+		})
+		if err != nil {
+			t.Errorf("error in creating console connection of web server %s: %s", id, err.Error())
+			continue
+		}
+		connectionID := *response.InstanceConsoleConnection.Id
+		WaitForState(t, "console connection of "+id, consoleConnectionState(compute, connectionID),
+			string(core.InstanceConsoleConnectionLifecycleStateActive), defaultStateTimeout)
+		connection := getConsoleConnection(t, compute, connectionID)
+
+		// assertions
+		if stringValue(connection.ConnectionString) == "" {
+			t.Errorf("console connection %s of web server %s has no serial connection string", connectionID, id)
+		}
+		if stringValue(connection.VncConnectionString) == "" {
+			t.Errorf("console connection %s of web server %s has no VNC connection string", connectionID, id)
+		}
+
+		if _, err := compute.DeleteInstanceConsoleConnection(context.Background(), core.DeleteInstanceConsoleConnectionRequest{
+			InstanceConsoleConnectionId: &connectionID,
+		}); err != nil {
+			t.Errorf("error in deleting console connection %s: %s", connectionID, err.Error())
+			continue
+		}
+		WaitForState(t, "console connection of "+id, consoleConnectionState(compute, connectionID),
+			string(core.InstanceConsoleConnectionLifecycleStateDeleted), defaultStateTimeout)
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// activeConsoleConnections returns IDs of console connections of the instance, an instance has at most one.
+func activeConsoleConnections(t *testing.T, compute core.ComputeClient, instanceID string) []string {
+	compartmentID := compartmentFor(computeCompartment)
+	response, err := compute.ListInstanceConsoleConnections(context.Background(), core.ListInstanceConsoleConnectionsRequest{
+		CompartmentId: &compartmentID,
+		InstanceId:    &instanceID,
+	})
+	if err != nil {
+		t.Fatalf("error in listing console connections of %s: %s", instanceID, err.Error())
+	}
+
+	ids := []string{}
+	for _, connection := range response.Items {
+		if connection.LifecycleState == core.InstanceConsoleConnectionLifecycleStateActive {
+			ids = append(ids, stringValue(connection.Id))
+		}
+	}
+	return ids
+}
+
+func getConsoleConnection(t *testing.T, compute core.ComputeClient, connectionID string) core.InstanceConsoleConnection {
+	response, err := compute.GetInstanceConsoleConnection(context.Background(), core.GetInstanceConsoleConnectionRequest{
+		InstanceConsoleConnectionId: &connectionID,
+	})
+	if err != nil {
+		t.Fatalf("error in calling console connection %s: %s", connectionID, err.Error())
+	}
+	return response.InstanceConsoleConnection
+}
+
+func consoleConnectionState(compute core.ComputeClient, connectionID string) StateGetter {
+	return func(ctx context.Context) (string, error) {
+		response, err := compute.GetInstanceConsoleConnection(ctx, core.GetInstanceConsoleConnectionRequest{
+			InstanceConsoleConnectionId: &connectionID,
+		})
+		return string(response.InstanceConsoleConnection.LifecycleState), err
+	}
+}
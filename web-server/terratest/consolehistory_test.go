@@ -0,0 +1,92 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/ssh"
+	"github.com/oracle/oci-go-sdk/core"
+)
+
+// captureUnreachableConsole captures the serial console history of the bastion when it does not accept ssh,
+// otherwise of the host behind it. It is meant for ssh failures, so that boot failures (bad cloud-init,
+// kernel panic) are diagnosable without console access.
+func captureUnreachableConsole(t *testing.T, bastion ssh.Host, host ssh.Host) {
+	if _, err := sshCommandE(t, bastion, "exit"); err != nil {
+		captureConsoleHistory(t, bastion.Hostname)
+		return
+	}
+	captureConsoleHistory(t, host.Hostname)
+}
+
+// captureConsoleHistory writes the console history of the instance with the IP into the diagnostics dir
+// and attaches it to the report. It is best effort, errors are logged only.
+func captureConsoleHistory(t *testing.T, ip string) {
+	instanceID, err := instanceByIP(t, ip)
+	if err != nil {
+		t.Logf("error in capturing console history: %s", err.Error())
+		return
+	}
+	content, err := consoleHistory(t, instanceID)
+	if err != nil {
+		t.Logf("error in capturing console history of %s (%s): %s", ip, instanceID, err.Error())
+		return
+	}
+	report.AddAttachment(fmt.Sprintf("%s %s: console history", t.Name(), ip), content)
+
+	dir := filepath.Join(diagnosticsDir(), "console-history")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Logf("error in creating diagnostics dir: %s", err.Error())
+		return
+	}
+	file := filepath.Join(dir, fmt.Sprintf("%s-%s.txt", ip, time.Now().Format("20060102-150405")))
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Logf("error in writing console history of %s: %s", ip, err.Error())
+		return
+	}
+	t.Logf("console history of %s (%s) written to %s", ip, instanceID, file)
+}
+
+// consoleHistory captures the serial console history of the instance and returns its content,
+// the capture is deleted afterwards.
+func consoleHistory(t *testing.T, instanceID string) (string, error) {
+	client := computeClient(t)
+	capture, err := client.CaptureConsoleHistory(context.Background(), core.CaptureConsoleHistoryRequest{
+		CaptureConsoleHistoryDetails: core.CaptureConsoleHistoryDetails{InstanceId: &instanceID},
+	})
+	if err != nil {
+		return "", err
+	}
+	historyID := capture.ConsoleHistory.Id
+	defer client.DeleteConsoleHistory(context.Background(), core.DeleteConsoleHistoryRequest{InstanceConsoleHistoryId: historyID})
+
+	_, err = retry.DoWithRetryE(t, "capture console history of "+instanceID, consoleCaptureRetries, sleepBetweenRetries, func() (string, error) {
+		response, err := client.GetConsoleHistory(context.Background(), core.GetConsoleHistoryRequest{InstanceConsoleHistoryId: historyID})
+		if err != nil {
+			return "", err
+		}
+		if response.ConsoleHistory.LifecycleState != core.ConsoleHistoryLifecycleStateSucceeded {
+			return "", fmt.Errorf("console history in state %s", response.ConsoleHistory.LifecycleState)
+		}
+		return "", nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	length := consoleHistoryLength
+	content, err := client.GetConsoleHistoryContent(context.Background(), core.GetConsoleHistoryContentRequest{
+		InstanceConsoleHistoryId: historyID,
+		Length:                   &length,
+	})
+	if err != nil {
+		return "", err
+	}
+	return stringValue(content.Value), nil
+}
@@ -0,0 +1,115 @@
+package terratest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/shell"
+)
+
+var (
+	// host line of the PLAY RECAP of ansible-playbook
+	ansibleRecapPattern = regexp.MustCompile(`(?m)^(\S+)\s+:\s+ok=\d+\s+changed=(\d+)\s+unreachable=(\d+)\s+failed=(\d+)`)
+)
+
+// checkConvergence re-runs the configuration applied after terraform on the web servers in check mode
+// and fails when it would change anything, i.e. when the configuration management has not converged.
+// ANSIBLE_PLAYBOOK is run with --check through the bastion, PROVISIONER_CHECK_COMMAND is run on each
+// web server over ssh and reports changes by a non-zero exit code, e.g. a remote-exec script with --check.
+func checkConvergence(t *testing.T) {
+	playbook := os.Getenv("ANSIBLE_PLAYBOOK")
+	command := os.Getenv("PROVISIONER_CHECK_COMMAND")
+	if playbook == "" && command == "" {
+		t.Skip("no ANSIBLE_PLAYBOOK or PROVISIONER_CHECK_COMMAND to verify convergence of")
+	}
+
+	if playbook != "" {
+		t.Run("ansible", func(t *testing.T) {
+			checkAnsibleConvergence(t, playbook)
+		})
+	}
+	if command != "" {
+		t.Run("provisioner", func(t *testing.T) {
+			for _, host := range webHosts(t) {
+				result := RunRemote(t, host, command, RemoteOptions{Sudo: true, Timeout: sshCommandTimeout})
+				// assertions
+				if result.ExitCode != 0 {
+					t.Errorf("%s: provisioner would change the host, exit code %d:\n%s%s",
+						host.Hostname, result.ExitCode, result.Stdout, result.Stderr)
+				}
+			}
+		})
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func checkAnsibleConvergence(t *testing.T, playbook string) {
+	inventory := ansibleInventory(t)
+	defer os.Remove(inventory)
+
+	out, err := shell.RunCommandAndGetOutputE(t, shell.Command{
+		Command: "ansible-playbook",
+		Args:    []string{"-i", inventory, "--check", "--diff", playbook},
+		Env:     map[string]string{"ANSIBLE_HOST_KEY_CHECKING": "False"},
+	})
+	recap := ansibleRecapPattern.FindAllStringSubmatch(out, -1)
+	if err != nil && len(recap) == 0 {
+		t.Fatalf("error in running ansible playbook %s: %s", playbook, err.Error())
+	}
+
+	// assertions
+	hosts := outputValues(t, "WebServerPrivateIPs")
+	for _, match := range recap {
+		host, changed, unreachable, failed := match[1], match[2], match[3], match[4]
+		if changed != "0" || unreachable != "0" || failed != "0" {
+			t.Errorf("%s: playbook %s has not converged: changed=%s unreachable=%s failed=%s",
+				host, playbook, changed, unreachable, failed)
+		}
+		hosts = removeString(hosts, host)
+	}
+	if len(hosts) > 0 {
+		t.Errorf("playbook %s did not run on web servers %v", playbook, hosts)
+	}
+}
+
+// ansibleInventory writes an inventory of the web servers, reached through the bastion with the key of the run.
+func ansibleInventory(t *testing.T) string {
+	key := stringVar("ssh_private_key", "")
+	proxy := fmt.Sprintf("ssh -W %%h:%%p -q %s-i %s -o StrictHostKeyChecking=no %s@%s", sshJumpOption(), key, sshUserName, bastionHost(t).Hostname)
+
+	lines := []string{"[web]"}
+	for _, ip := range outputValues(t, "WebServerPrivateIPs") {
+		lines = append(lines, ip)
+	}
+	lines = append(lines,
+		"[web:vars]",
+		"ansible_user="+sshUserName,
+		"ansible_ssh_private_key_file="+key,
+		fmt.Sprintf("ansible_ssh_common_args='-o ProxyCommand=\"%s\"'", proxy),
+	)
+
+	file, err := ioutil.TempFile("", "terratest-inventory-")
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	defer file.Close()
+	if _, err := file.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
+		t.Fatalf("error in writing ansible inventory: %s", err.Error())
+	}
+	return file.Name()
+}
+
+func removeString(values []string, value string) []string {
+	result := []string{}
+	for _, v := range values {
+		if v != value {
+			result = append(result, v)
+		}
+	}
+	return result
+}
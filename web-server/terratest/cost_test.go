@@ -0,0 +1,277 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/oracle/oci-go-sdk/budget"
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/core"
+	"github.com/oracle/oci-go-sdk/identity"
+	"github.com/oracle/oci-go-sdk/loadbalancer"
+)
+
+const (
+	// the SDK has no usage package, the cost report calls the REST API of the service
+	usageEndpointTemplate = "https://usageapi.{region}.oci.{secondLevelDomain}"
+	usagePath             = "/20200107"
+)
+
+var (
+	// value of the cost-tracking tag of the resources applied by this run
+	runTagValue = "terratest-" + random.UniqueId()
+)
+
+// BudgetExpectation is the budget of the stack compartment.
+type BudgetExpectation struct {
+	// MinAlertRules is the min count of active alert rules with recipients, 1 when 0
+	MinAlertRules int `json:"minAlertRules"`
+}
+
+type usageTag struct {
+	Namespace string `json:"namespace"`
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+}
+
+type requestSummarizedUsagesDetails struct {
+	TenantId         *string         `mandatory:"true" json:"tenantId"`
+	TimeUsageStarted *common.SDKTime `mandatory:"true" json:"timeUsageStarted"`
+	TimeUsageEnded   *common.SDKTime `mandatory:"true" json:"timeUsageEnded"`
+	Granularity      string          `mandatory:"true" json:"granularity"`
+	QueryType        string          `mandatory:"false" json:"queryType"`
+	Filter           struct {
+		Operator string     `json:"operator"`
+		Tags     []usageTag `json:"tags"`
+	} `mandatory:"false" json:"filter"`
+}
+
+type requestSummarizedUsagesRequest struct {
+	RequestSummarizedUsagesDetails requestSummarizedUsagesDetails `contributesTo:"body"`
+}
+
+type requestSummarizedUsagesResponse struct {
+	RawResponse      *http.Response
+	UsageAggregation struct {
+		Items []struct {
+			ComputedAmount *float64 `json:"computedAmount"`
+			Currency       *string  `json:"currency"`
+		} `json:"items"`
+	} `presentIn:"body"`
+}
+
+func checkBudget(t *testing.T) {
+	expected := loadExpectations(t).Budget
+	if expected == nil {
+		t.Skip("no budget in expectations")
+	}
+	minAlertRules := expected.MinAlertRules
+	if minAlertRules == 0 {
+		minAlertRules = 1
+	}
+	compartmentID := stringVar("CompartmentOCID", "")
+	client := budgetClient(t)
+
+	budgets := compartmentBudgets(t, client, compartmentID)
+	if len(budgets) == 0 {
+		t.Fatalf("no active budget targets compartment %s", compartmentID)
+	}
+
+	// assertions
+	for _, summary := range budgets {
+		linkResource(t, "budget "+stringValue(summary.DisplayName), *summary.Id, "")
+		response, err := client.ListAlertRules(context.Background(), budget.ListAlertRulesRequest{
+			BudgetId:       summary.Id,
+			LifecycleState: budget.ListAlertRulesLifecycleStateActive,
+		})
+		if err != nil {
+			t.Fatalf("error in listing alert rules of budget %s: %s", stringValue(summary.DisplayName), err.Error())
+		}
+
+		rules := 0
+		for _, rule := range response.Items {
+			if strings.TrimSpace(stringValue(rule.Recipients)) != "" {
+				rules++
+			}
+		}
+		if rules < minAlertRules {
+			t.Errorf("budget %s (%.2f per %s): %d alert rules with recipients, expected at least %d",
+				stringValue(summary.DisplayName), *summary.Amount, summary.ResetPeriod, rules, minAlertRules)
+		}
+	}
+}
+
+// checkCostTrackingTag verifies the tag of COST_TRACKING_TAG is a cost-tracking tag and is set on the billable resources.
+func checkCostTrackingTag(t *testing.T) {
+	namespace, key := costTrackingTag()
+	if namespace == "" {
+		t.Skip("cost-tracking tag is enabled by COST_TRACKING_TAG=<namespace>.<key>")
+	}
+	tag := findTag(t, namespace, key)
+
+	// assertions
+	if !boolValue(tag.IsCostTracking) {
+		t.Errorf("tag %s.%s is not a cost-tracking tag", namespace, key)
+	}
+
+	compute := computeClient(t)
+	for tier, output := range tierOutputs {
+		for _, instanceID := range outputValues(t, output) {
+			id := instanceID
+			response, err := compute.GetInstance(context.Background(), core.GetInstanceRequest{InstanceId: &id})
+			if err != nil {
+				t.Fatalf("error in calling instance %s: %s", instanceID, err.Error())
+			}
+			assertRunTag(t, tier+" instance "+stringValue(response.Instance.DisplayName), response.Instance.DefinedTags, namespace, key)
+		}
+	}
+
+	lbID := terraform.Output(t, options, "lb_id")
+	response, err := loadBalancerClient(t).GetLoadBalancer(context.Background(), loadbalancer.GetLoadBalancerRequest{LoadBalancerId: &lbID})
+	if err != nil {
+		t.Fatalf("error in calling load balancer %s: %s", lbID, err.Error())
+	}
+	assertRunTag(t, "load balancer "+stringValue(response.LoadBalancer.DisplayName), response.LoadBalancer.DefinedTags, namespace, key)
+}
+
+// reportRunCost adds the cost attributed to the run's tag to the report, after destroy.
+// The Usage API has the costs of the last hours with a delay, the value is the cost known at the end of the run.
+func reportRunCost(t *testing.T) {
+	namespace, key := costTrackingTag()
+	tenancyID := config.TenancyOCID
+	// daily granularity is queried by whole days
+	start := report.Started.UTC().Truncate(24 * time.Hour)
+	end := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+
+	details := requestSummarizedUsagesDetails{
+		TenantId:         &tenancyID,
+		TimeUsageStarted: &common.SDKTime{Time: start},
+		TimeUsageEnded:   &common.SDKTime{Time: end},
+		Granularity:      "DAILY",
+		QueryType:        "COST",
+	}
+	details.Filter.Operator = "AND"
+	details.Filter.Tags = []usageTag{{Namespace: namespace, Key: key, Value: runTagValue}}
+
+	var response requestSummarizedUsagesResponse
+	err := callAPI(context.Background(), apiClient(t, usageEndpointTemplate), http.MethodPost, usagePath+"/usage",
+		requestSummarizedUsagesRequest{RequestSummarizedUsagesDetails: details}, &response)
+	if err != nil {
+		t.Errorf("error in requesting usage of %s.%s=%s: %s", namespace, key, runTagValue, err.Error())
+		return
+	}
+
+	cost, currency := 0.0, ""
+	for _, item := range response.UsageAggregation.Items {
+		if item.ComputedAmount != nil {
+			cost += *item.ComputedAmount
+		}
+		if item.Currency != nil {
+			currency = *item.Currency
+		}
+	}
+	t.Logf("cost of %s.%s=%s: %.4f %s (known so far)", namespace, key, runTagValue, cost, currency)
+	report.AddMetric("actual cost "+runTagValue, strings.TrimSpace(fmt.Sprintf("%.4f %s", cost, currency)))
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// costTrackingTag returns namespace and key of COST_TRACKING_TAG, empty when not set.
+func costTrackingTag() (string, string) {
+	tag := os.Getenv("COST_TRACKING_TAG")
+	if i := strings.Index(tag, "."); i > 0 {
+		return tag[:i], tag[i+1:]
+	}
+	return "", ""
+}
+
+// tagRun sets the cost-tracking tag on the billable resources of the run, when enabled.
+func tagRun(options *terraform.Options) bool {
+	namespace, key := costTrackingTag()
+	if namespace == "" {
+		return false
+	}
+	options.Vars["defined_tags"] = map[string]string{namespace + "." + key: runTagValue}
+	return true
+}
+
+func budgetClient(t *testing.T) budget.BudgetClient {
+	client, err := budget.NewBudgetClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	throttle(&client.BaseClient)
+	return client
+}
+
+// compartmentBudgets returns active budgets targeting the compartment, budgets are in the root compartment.
+func compartmentBudgets(t *testing.T, client budget.BudgetClient, compartmentID string) []budget.BudgetSummary {
+	tenancyID := config.TenancyOCID
+	request := budget.ListBudgetsRequest{
+		CompartmentId:  &tenancyID,
+		LifecycleState: budget.ListBudgetsLifecycleStateActive,
+		TargetType:     budget.ListBudgetsTargetTypeAll,
+	}
+
+	budgets := []budget.BudgetSummary{}
+	for {
+		response, err := client.ListBudgets(context.Background(), request)
+		if err != nil {
+			t.Fatalf("error in listing budgets: %s", err.Error())
+		}
+		for _, summary := range response.Items {
+			if stringValue(summary.TargetCompartmentId) == compartmentID || containsString(summary.Targets, compartmentID) {
+				budgets = append(budgets, summary)
+			}
+		}
+
+		if response.OpcNextPage == nil {
+			return budgets
+		}
+		request.Page = response.OpcNextPage
+	}
+}
+
+func findTag(t *testing.T, namespace string, key string) identity.Tag {
+	client := identityClient(t)
+	tenancyID := config.TenancyOCID
+	includeSubcompartments := true
+	namespaces, err := client.ListTagNamespaces(context.Background(), identity.ListTagNamespacesRequest{
+		CompartmentId:          &tenancyID,
+		IncludeSubcompartments: &includeSubcompartments,
+	})
+	if err != nil {
+		t.Fatalf("error in listing tag namespaces: %s", err.Error())
+	}
+
+	for _, summary := range namespaces.Items {
+		if stringValue(summary.Name) != namespace {
+			continue
+		}
+		response, err := client.GetTag(context.Background(), identity.GetTagRequest{TagNamespaceId: summary.Id, TagName: &key})
+		if err != nil {
+			t.Fatalf("error in calling tag %s.%s: %s", namespace, key, err.Error())
+		}
+		return response.Tag
+	}
+	t.Fatalf("tag namespace %s not found in tenancy", namespace)
+	return identity.Tag{}
+}
+
+// assertRunTag checks the tag is set, to the run's value when the stack is applied by this run.
+func assertRunTag(t *testing.T, resource string, tags map[string]map[string]interface{}, namespace string, key string) {
+	value, ok := tags[namespace][key]
+	switch {
+	case !ok:
+		t.Errorf("%s has no cost-tracking tag %s.%s", resource, namespace, key)
+	case !appliedAt.IsZero() && fmt.Sprint(value) != runTagValue:
+		t.Errorf("%s: wrong tag %s.%s: expected %s, got %v", resource, namespace, key, runTagValue, value)
+	}
+}
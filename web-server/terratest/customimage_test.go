@@ -0,0 +1,152 @@
+package terratest
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/packer"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/oracle/oci-go-sdk/core"
+)
+
+const (
+	// the builder instance runs the script from its user data and stops itself
+	customImageBuildTimeout = 45 * time.Minute
+)
+
+// customImageEnabled tells whether the run builds a custom image for the instances before apply,
+// with packer from CUSTOM_IMAGE_PACKER_TEMPLATE or from a builder instance running CUSTOM_IMAGE_SCRIPT.
+func customImageEnabled() bool {
+	return os.Getenv("CUSTOM_IMAGE_PACKER_TEMPLATE") != "" || os.Getenv("CUSTOM_IMAGE_SCRIPT") != ""
+}
+
+// buildCustomImage builds the custom image on top of the image of the stack for the region and passes it
+// to terraform as InstanceImageOCID, so that all checks run against instances of the custom image.
+// It returns the image OCID, to be deleted by deleteCustomImage after destroy.
+func buildCustomImage(t *testing.T) string {
+	baseImageID := mapVarValue("InstanceImageOCID", config.Region)
+	if baseImageID == "" {
+		t.Fatalf("no InstanceImageOCID for region %s to build the custom image on", config.Region)
+	}
+
+	start := time.Now()
+	var imageID string
+	if template := os.Getenv("CUSTOM_IMAGE_PACKER_TEMPLATE"); template != "" {
+		imageID = buildPackerImage(t, template, baseImageID)
+	} else {
+		imageID = buildSnapshotImage(t, os.Getenv("CUSTOM_IMAGE_SCRIPT"), baseImageID)
+	}
+	profiler.Record(opAPI, "build custom image", start)
+	report.AddMetric("custom image build seconds", int(time.Since(start).Seconds()))
+	t.Logf("custom image %s built from %s in %s", imageID, baseImageID, time.Since(start).Round(time.Second))
+
+	options.Vars["InstanceImageOCID"] = fmt.Sprintf("{%q = %q}", config.Region, imageID)
+	return imageID
+}
+
+// deleteCustomImage deletes the custom image, the instances launched from it have to be terminated.
+func deleteCustomImage(t *testing.T, imageID string) {
+	if _, err := computeClient(t).DeleteImage(context.Background(), core.DeleteImageRequest{ImageId: &imageID}); err != nil {
+		t.Errorf("error in deleting custom image %s: %s", imageID, err.Error())
+		return
+	}
+	t.Logf("custom image %s deleted", imageID)
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// buildPackerImage runs the oracle-oci builder of the template, it gets the variables of the stack
+// the image depends on. The artifact of the build is the image OCID.
+func buildPackerImage(t *testing.T, template string, baseImageID string) string {
+	return packer.BuildArtifact(t, &packer.Options{
+		Template: template,
+		Vars: map[string]string{
+			"region":              config.Region,
+			"compartment_ocid":    stringVar("CompartmentOCID", ""),
+			"base_image_ocid":     baseImageID,
+			"shape":               stringVar("TestServerShape", defaultShape),
+			"availability_domain": customImageAvailabilityDomain(t),
+			"subnet_ocid":         os.Getenv("CUSTOM_IMAGE_SUBNET_OCID"),
+		},
+	})
+}
+
+// buildSnapshotImage launches a builder instance into CUSTOM_IMAGE_SUBNET_OCID, which runs the script
+// and powers off, and creates the image from the stopped instance. The builder is terminated afterwards.
+func buildSnapshotImage(t *testing.T, script string, baseImageID string) string {
+	subnetID := os.Getenv("CUSTOM_IMAGE_SUBNET_OCID")
+	if subnetID == "" {
+		t.Fatal("CUSTOM_IMAGE_SUBNET_OCID is needed for the builder instance of CUSTOM_IMAGE_SCRIPT")
+	}
+	content, err := ioutil.ReadFile(script)
+	if err != nil {
+		t.Fatalf("error in reading custom image script %s: %s", script, err.Error())
+	}
+	compute := computeClient(t)
+	compartmentID := stringVar("CompartmentOCID", "")
+	ad := customImageAvailabilityDomain(t)
+	shape := stringVar("TestServerShape", defaultShape)
+	name := "terratest-image-" + random.UniqueId()
+	userData := base64.StdEncoding.EncodeToString([]byte(string(content) + "\npoweroff\n"))
+
+	launched, err := compute.LaunchInstance(context.Background(), core.LaunchInstanceRequest{
+		LaunchInstanceDetails: core.LaunchInstanceDetails{
+			AvailabilityDomain: &ad,
+			CompartmentId:      &compartmentID,
+			Shape:              &shape,
+			DisplayName:        &name,
+			SourceDetails:      core.InstanceSourceViaImageDetails{ImageId: &baseImageID},
+			CreateVnicDetails:  &core.CreateVnicDetails{SubnetId: &subnetID},
+			Metadata:           map[string]string{"user_data": userData},
+		},
+	})
+	if err != nil {
+		t.Fatalf("error in launching custom image builder %s: %s", name, err.Error())
+	}
+	builderID := *launched.Instance.Id
+	defer terminateBuilder(t, compute, builderID)
+	WaitForState(t, "custom image builder "+name, instanceState(compute, builderID), string(core.InstanceLifecycleStateStopped), customImageBuildTimeout)
+
+	created, err := compute.CreateImage(context.Background(), core.CreateImageRequest{
+		CreateImageDetails: core.CreateImageDetails{
+			CompartmentId: &compartmentID,
+			InstanceId:    &builderID,
+			DisplayName:   &name,
+		},
+	})
+	if err != nil {
+		t.Fatalf("error in creating custom image %s: %s", name, err.Error())
+	}
+	imageID := *created.Image.Id
+	WaitForState(t, "custom image "+name, imageState(compute, imageID), string(core.ImageLifecycleStateAvailable), customImageBuildTimeout)
+	return imageID
+}
+
+func terminateBuilder(t *testing.T, compute core.ComputeClient, builderID string) {
+	if _, err := compute.TerminateInstance(context.Background(), core.TerminateInstanceRequest{InstanceId: &builderID}); err != nil {
+		t.Errorf("error in terminating custom image builder %s: %s", builderID, err.Error())
+	}
+}
+
+func imageState(compute core.ComputeClient, imageID string) StateGetter {
+	return func(ctx context.Context) (string, error) {
+		response, err := compute.GetImage(ctx, core.GetImageRequest{ImageId: &imageID})
+		return string(response.Image.LifecycleState), err
+	}
+}
+
+// customImageAvailabilityDomain is the name of the availability_domain of the stack, an index.
+func customImageAvailabilityDomain(t *testing.T) string {
+	ads := regionAvailabilityDomains(t)
+	index, err := strconv.Atoi(stringVar("availability_domain", "2"))
+	if err != nil || index < 0 || index >= len(ads) {
+		t.Fatalf("wrong availability_domain %q, the region has %d", stringVar("availability_domain", "2"), len(ads))
+	}
+	return *ads[index].Name
+}
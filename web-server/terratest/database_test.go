@@ -0,0 +1,264 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/core"
+	"github.com/oracle/oci-go-sdk/database"
+	"github.com/oracle/oci-go-sdk/mysql"
+)
+
+const (
+	// DB systems and autonomous databases, COMPARTMENT_OCID_DATABASE or CompartmentOCID
+	databaseCompartment = "database"
+	databaseMysql       = "MYSQL"
+	databaseAutonomous  = "AUTONOMOUS"
+	// TLS listener of the private endpoint of autonomous databases
+	autonomousDatabasePort = 1522
+	// tier of the database in reachability expectations, e.g. "bastion->db:3306"
+	databaseTier = "db"
+	// printed by the authentication script when the query succeeds
+	databaseQueryResult = "1"
+	instanceMetadataURL = "http://169.254.169.254/opc/v2"
+)
+
+// DatabaseExpectation is the database backend of the web servers.
+type DatabaseExpectation struct {
+	// Kind is MYSQL for a MySQL DB system or AUTONOMOUS for an autonomous database
+	Kind        string `json:"kind"`
+	DisplayName string `json:"displayName"`
+	// Subnet is the display name of the private subnet of the database, not checked when empty
+	Subnet string `json:"subnet"`
+	// Credentials injected into the web servers, authentication is not checked when nil
+	Credentials *DatabaseCredentialsExpectation `json:"credentials"`
+}
+
+// DatabaseCredentialsExpectation is where the web servers read the password of the database user,
+// a secret of the vault in expectations or an instance metadata key.
+type DatabaseCredentialsExpectation struct {
+	User        string `json:"user"`
+	Secret      string `json:"secret"`
+	MetadataKey string `json:"metadataKey"`
+}
+
+// databaseEndpoint is what the checks need of a MySQL DB system or an autonomous database.
+type databaseEndpoint struct {
+	id string
+	// state is the lifecycle state of the database, which is availableState when it serves connections
+	state          StateGetter
+	availableState string
+	subnetID       string
+	// host is the private IP or hostname of the endpoint, empty when the database has only a public endpoint
+	host string
+	port int
+}
+
+// checkDatabase verifies the database is available in a private subnet and has no public endpoint.
+func checkDatabase(t *testing.T) {
+	expected := loadExpectations(t).Database
+	if expected == nil {
+		t.Skip("no database in expectations")
+	}
+	actual := findDatabase(t, expected)
+	linkResource(t, "database "+expected.DisplayName, actual.id, "")
+
+	// assertions
+	WaitForState(t, "database "+expected.DisplayName, actual.state, actual.availableState, defaultStateTimeout)
+	if actual.host == "" || actual.subnetID == "" {
+		t.Fatalf("database %s has no private endpoint", expected.DisplayName)
+	}
+
+	subnetID := actual.subnetID
+	subnet, err := virtualNetworkClient(t).GetSubnet(context.Background(), core.GetSubnetRequest{SubnetId: &subnetID})
+	if err != nil {
+		t.Fatalf("error in calling subnet %s: %s", subnetID, err.Error())
+	}
+	if !boolValue(subnet.Subnet.ProhibitPublicIpOnVnic) {
+		t.Errorf("database %s is in public subnet %s", expected.DisplayName, stringValue(subnet.Subnet.DisplayName))
+	}
+	if name := stringValue(subnet.Subnet.DisplayName); expected.Subnet != "" && !strings.HasPrefix(name, expected.Subnet) {
+		t.Errorf("database %s in wrong subnet: expected %s, got %s", expected.DisplayName, expected.Subnet, name)
+	}
+}
+
+// checkDatabaseReachability probes the database port from a web server, which has to reach it, and from the bastion,
+// which may reach it only when allowed by reachability expectations.
+func checkDatabaseReachability(t *testing.T) {
+	expectations := loadExpectations(t)
+	expected := expectations.Database
+	if expected == nil {
+		t.Skip("no database in expectations")
+	}
+	actual := findDatabase(t, expected)
+	if actual.host == "" {
+		t.Fatalf("database %s has no private endpoint", expected.DisplayName)
+	}
+	script := probeScript(t, []string{actual.host}, []int{actual.port})
+	address := actual.host + ":" + strconv.Itoa(actual.port)
+
+	sources := map[string]bool{
+		"web":     true,
+		"bastion": expectations.Reachability.Allows("bastion", databaseTier, actual.port),
+	}
+	for source, allowed := range sources {
+		host := bastionHost(t)
+		if source == "web" {
+			host = webHosts(t)[0]
+		}
+		result := RunRemote(t, host, script, RemoteOptions{Timeout: sshCommandTimeout})
+		if result.ExitCode != 0 {
+			t.Fatalf("probe script failed on %s with exit code %d: %s", host.Hostname, result.ExitCode, result.Stderr)
+		}
+
+		// assertions
+		if open := parseProbeOutput(result.Stdout)[address]; open != allowed {
+			t.Errorf("%s -> database %s tcp/%d: expected open %t, got %t", source, expected.DisplayName, actual.port, allowed, open)
+		}
+	}
+}
+
+// checkDatabaseCredentials reads the injected password on every web server the way the application does
+// and authenticates to the database with it, which proves the secret plumbing works end to end.
+func checkDatabaseCredentials(t *testing.T) {
+	expectations := loadExpectations(t)
+	expected := expectations.Database
+	if expected == nil || expected.Credentials == nil {
+		t.Skip("no database credentials in expectations")
+	}
+	if expected.Kind != databaseMysql {
+		t.Skipf("authentication is checked for %s databases only, autonomous databases need a wallet", databaseMysql)
+	}
+	actual := findDatabase(t, expected)
+
+	var password string
+	switch {
+	case expected.Credentials.Secret != "":
+		if expectations.Vault == nil {
+			t.Fatalf("database password is secret %s, but there is no vault in expectations", expected.Credentials.Secret)
+		}
+		secret, ok := findSecret(t, *findVault(t, expectations.Vault.DisplayName).Id, expected.Credentials.Secret)
+		if !ok {
+			t.Fatalf("missing secret %s in vault %s", expected.Credentials.Secret, expectations.Vault.DisplayName)
+		}
+		password = fmt.Sprintf(`oci secrets secret-bundle get --auth instance_principal --secret-id %s --query 'data."secret-bundle-content".content' --raw-output | base64 -d`, *secret.Id)
+	case expected.Credentials.MetadataKey != "":
+		password = "curl -sf -H 'Authorization: Bearer Oracle' " + shellQuote(instanceMetadataURL+"/instance/metadata/"+expected.Credentials.MetadataKey)
+	default:
+		t.Fatal("database credentials in expectations have neither secret nor metadataKey")
+	}
+	script := databaseAuthScript(password, actual.host, actual.port, expected.Credentials.User)
+
+	for _, host := range webHosts(t) {
+		out := jumpSshHost(t, host, script)
+
+		// assertions
+		if out != databaseQueryResult {
+			t.Errorf("%s: cannot authenticate to database %s as %s with the injected password: %s",
+				host.Hostname, expected.DisplayName, expected.Credentials.User, out)
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// databaseAuthScript reads the password by the command and runs a query with it, the password is passed
+// in the environment and never printed. Failures are printed, otherwise the ssh call would be retried.
+func databaseAuthScript(password string, host string, port int, user string) string {
+	return fmt.Sprintf(`pw=$(%s) || { echo "password not readable"; exit 0; }
+command -v mysql >/dev/null || sudo -n yum -y -q install mysql >/dev/null 2>&1
+MYSQL_PWD="$pw" mysql -h %s -P %d -u %s -N -B -e 'SELECT 1' 2>&1 || true`, password, shellQuote(host), port, shellQuote(user))
+}
+
+func findDatabase(t *testing.T, expected *DatabaseExpectation) databaseEndpoint {
+	compartmentID := compartmentFor(databaseCompartment)
+
+	switch expected.Kind {
+	case databaseMysql:
+		client, err := mysql.NewDbSystemClientWithConfigurationProvider(ociConfigProvider())
+		if err != nil {
+			t.Fatalf("error occured: %s", err.Error())
+		}
+		throttle(&client.BaseClient)
+
+		systems, err := client.ListDbSystems(context.Background(), mysql.ListDbSystemsRequest{
+			CompartmentId: &compartmentID,
+			DisplayName:   &expected.DisplayName,
+		})
+		if err != nil {
+			t.Fatalf("error in listing MySQL DB systems: %s", err.Error())
+		}
+		for _, summary := range systems.Items {
+			if summary.LifecycleState == mysql.DbSystemLifecycleStateDeleted {
+				continue
+			}
+			response, err := client.GetDbSystem(context.Background(), mysql.GetDbSystemRequest{DbSystemId: summary.Id})
+			if err != nil {
+				t.Fatalf("error in calling MySQL DB system %s: %s", expected.DisplayName, err.Error())
+			}
+			system := response.DbSystem
+			endpoint := databaseEndpoint{
+				id:             *system.Id,
+				state:          dbSystemState(client, *system.Id),
+				availableState: string(mysql.DbSystemLifecycleStateActive),
+				subnetID:       stringValue(system.SubnetId),
+				host:           stringValue(system.IpAddress),
+			}
+			if system.Port != nil {
+				endpoint.port = *system.Port
+			}
+			return endpoint
+		}
+
+	case databaseAutonomous:
+		client, err := database.NewDatabaseClientWithConfigurationProvider(ociConfigProvider())
+		if err != nil {
+			t.Fatalf("error occured: %s", err.Error())
+		}
+		throttle(&client.BaseClient)
+
+		databases, err := client.ListAutonomousDatabases(context.Background(), database.ListAutonomousDatabasesRequest{
+			CompartmentId: &compartmentID,
+			DisplayName:   &expected.DisplayName,
+		})
+		if err != nil {
+			t.Fatalf("error in listing autonomous databases: %s", err.Error())
+		}
+		for _, summary := range databases.Items {
+			if summary.LifecycleState == database.AutonomousDatabaseSummaryLifecycleStateTerminated {
+				continue
+			}
+			return databaseEndpoint{
+				id:             *summary.Id,
+				state:          autonomousDatabaseState(client, *summary.Id),
+				availableState: string(database.AutonomousDatabaseLifecycleStateAvailable),
+				subnetID:       stringValue(summary.SubnetId),
+				host:           stringValue(summary.PrivateEndpoint),
+				port:           autonomousDatabasePort,
+			}
+		}
+
+	default:
+		t.Fatalf("unknown database kind %q in expectations, expected %s or %s", expected.Kind, databaseMysql, databaseAutonomous)
+	}
+
+	t.Fatalf("database %s not found in %s", expected.DisplayName, compartmentID)
+	return databaseEndpoint{}
+}
+
+func dbSystemState(client mysql.DbSystemClient, dbSystemID string) StateGetter {
+	return func(ctx context.Context) (string, error) {
+		response, err := client.GetDbSystem(ctx, mysql.GetDbSystemRequest{DbSystemId: &dbSystemID})
+		return string(response.DbSystem.LifecycleState), err
+	}
+}
+
+func autonomousDatabaseState(client database.DatabaseClient, databaseID string) StateGetter {
+	return func(ctx context.Context) (string, error) {
+		response, err := client.GetAutonomousDatabase(ctx, database.GetAutonomousDatabaseRequest{AutonomousDatabaseId: &databaseID})
+		return string(response.AutonomousDatabase.LifecycleState), err
+	}
+}
@@ -0,0 +1,155 @@
+package terratest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/oracle/oci-go-sdk/core"
+	"github.com/oracle/oci-go-sdk/loadbalancer"
+)
+
+const (
+	defaultDiagnosticsDir = "diagnostics"
+	// audit events are collected from this long before the run started
+	auditLookback = 15 * time.Minute
+)
+
+// collectDiagnostics writes everything useful for debugging the failed checks into
+// a timestamped directory. It is best effort, errors are stored in errors.txt.
+func collectDiagnostics(t *testing.T, failedChecks []string) string {
+	dir := filepath.Join(diagnosticsDir(), time.Now().Format("20060102-150405"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Logf("error in creating diagnostics dir: %s", err.Error())
+		return ""
+	}
+
+	errors := []string{}
+	write := func(name string, content func() (interface{}, error)) {
+		value, err := content()
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %s", name, err.Error()))
+			return
+		}
+
+		var data []byte
+		if s, ok := value.(string); ok {
+			data = []byte(s)
+		} else if data, err = json.MarshalIndent(value, "", "  "); err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %s", name, err.Error()))
+			return
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %s", name, err.Error()))
+		}
+	}
+
+	compartmentID := stringVar("CompartmentOCID", "")
+
+	write("failed-checks.txt", func() (interface{}, error) {
+		return strings.Join(failedChecks, "\n") + "\n", nil
+	})
+	write("terraform-outputs.json", func() (interface{}, error) {
+		return terraform.RunTerraformCommandAndGetStdoutE(t, options, "output", "-json")
+	})
+	write("terraform-state-list.txt", func() (interface{}, error) {
+		return terraform.RunTerraformCommandAndGetStdoutE(t, options, "state", "list")
+	})
+	write("instances.json", func() (interface{}, error) {
+		return diagnosticInstances(compartmentFor(computeCompartment))
+	})
+	write("load-balancer.json", func() (interface{}, error) {
+		return diagnosticLoadBalancer(t)
+	})
+	write("subnets.json", func() (interface{}, error) {
+		return diagnosticSubnets(t, compartmentFor(networkCompartment))
+	})
+	write("audit-events.json", func() (interface{}, error) {
+		return listAuditEvents(compartmentID, report.Started.Add(-auditLookback), time.Now())
+	})
+
+	if len(errors) > 0 {
+		ioutil.WriteFile(filepath.Join(dir, "errors.txt"), []byte(strings.Join(errors, "\n")+"\n"), 0644)
+	}
+
+	t.Logf("diagnostics for failed checks %v written to %s", failedChecks, dir)
+	report.AddMetric("diagnostics", dir)
+	return dir
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func diagnosticsDir() string {
+	if dir := os.Getenv("DIAGNOSTICS_DIR"); dir != "" {
+		return dir
+	}
+	return defaultDiagnosticsDir
+}
+
+func diagnosticInstances(compartmentID string) ([]core.Instance, error) {
+	client, err := core.NewComputeClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		return nil, err
+	}
+	throttle(&client.BaseClient)
+
+	response, err := client.ListInstances(context.Background(), core.ListInstancesRequest{CompartmentId: &compartmentID})
+	if err != nil {
+		return nil, err
+	}
+	return response.Items, nil
+}
+
+func diagnosticLoadBalancer(t *testing.T) (loadbalancer.LoadBalancer, error) {
+	client, err := loadbalancer.NewLoadBalancerClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		return loadbalancer.LoadBalancer{}, err
+	}
+	throttle(&client.BaseClient)
+
+	lbID, err := terraform.OutputE(t, options, "lb_id")
+	if err != nil {
+		return loadbalancer.LoadBalancer{}, err
+	}
+
+	response, err := client.GetLoadBalancer(context.Background(), loadbalancer.GetLoadBalancerRequest{LoadBalancerId: &lbID})
+	if err != nil {
+		return loadbalancer.LoadBalancer{}, err
+	}
+	return response.LoadBalancer, nil
+}
+
+func diagnosticSubnets(t *testing.T, compartmentID string) ([]core.Subnet, error) {
+	client, err := core.NewVirtualNetworkClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		return nil, err
+	}
+	throttle(&client.BaseClient)
+
+	vcnIDs, err := GetAllVcnIDsE(t, compartmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	subnets := []core.Subnet{}
+	for _, vcnID := range vcnIDs {
+		id := vcnID
+		response, err := client.ListSubnets(context.Background(), core.ListSubnetsRequest{
+			CompartmentId: &compartmentID,
+			VcnId:         &id,
+		})
+		if err != nil {
+			return nil, err
+		}
+		subnets = append(subnets, response.Items...)
+	}
+	return subnets, nil
+}
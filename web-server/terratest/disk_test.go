@@ -0,0 +1,92 @@
+package terratest
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/core"
+)
+
+const (
+	gib = 1024 * 1024 * 1024
+	// the root filesystem has to span the boot volume, except boot and swap partitions
+	minRootShare = 0.85
+	// usage of / right after provisioning
+	maxRootUsagePercent = 80
+	bootDiskCommand     = "lsblk -b -d -n -o SIZE,TYPE | awk '$2 == \"disk\" {print $1; exit}'"
+	rootFsCommand       = "df -B1 --output=size,pcent / | tail -1 | tr -d '%'"
+)
+
+func checkBootVolumes(t *testing.T) {
+	compute := computeClient(t)
+	blockstorage := blockstorageClient(t)
+
+	for tier, output := range tierOutputs {
+		hosts := tierHosts(t, tier)
+		for i, instanceID := range outputValues(t, output) {
+			if i >= len(hosts) {
+				t.Fatalf("%s: more instances than hosts", tier)
+			}
+			host := hosts[i]
+
+			instance, err := compute.GetInstance(context.Background(), core.GetInstanceRequest{InstanceId: &instanceID})
+			if err != nil {
+				t.Fatalf("error in calling instance %s: %s", instanceID, err.Error())
+			}
+			attachments, err := compute.ListBootVolumeAttachments(context.Background(), core.ListBootVolumeAttachmentsRequest{
+				AvailabilityDomain: instance.AvailabilityDomain,
+				CompartmentId:      instance.CompartmentId,
+				InstanceId:         &instanceID,
+			})
+			if err != nil {
+				t.Fatalf("error in listing boot volume attachments: %s", err.Error())
+			}
+			if len(attachments.Items) != 1 {
+				t.Fatalf("%s %s: wrong number of boot volumes: expected 1, got %d", tier, instanceID, len(attachments.Items))
+			}
+			volume, err := blockstorage.GetBootVolume(context.Background(), core.GetBootVolumeRequest{
+				BootVolumeId: attachments.Items[0].BootVolumeId,
+			})
+			if err != nil {
+				t.Fatalf("error in calling boot volume: %s", err.Error())
+			}
+			volumeBytes := *volume.BootVolume.SizeInGBs * gib
+
+			disk := RunRemote(t, host, bootDiskCommand, RemoteOptions{Timeout: sshCommandTimeout})
+			root := RunRemote(t, host, rootFsCommand, RemoteOptions{Timeout: sshCommandTimeout})
+			diskBytes, diskErr := strconv.ParseInt(strings.TrimSpace(disk.Stdout), 10, 64)
+			fields := strings.Fields(root.Stdout)
+			if diskErr != nil || len(fields) != 2 {
+				t.Fatalf("%s %s: error in reading disk layout: %q %q", tier, host.Hostname, disk.Stdout, root.Stdout)
+			}
+			rootBytes, _ := strconv.ParseInt(fields[0], 10, 64)
+			usage, _ := strconv.Atoi(fields[1])
+			t.Logf("%s %s: boot volume %d GB, disk %d bytes, / %d bytes used %d%%",
+				tier, host.Hostname, *volume.BootVolume.SizeInGBs, diskBytes, rootBytes, usage)
+
+			// assertions
+			if diskBytes < volumeBytes {
+				t.Errorf("%s %s: disk %d bytes is smaller than boot volume of %d GB", tier, host.Hostname, diskBytes, *volume.BootVolume.SizeInGBs)
+			}
+			if float64(rootBytes) < float64(diskBytes)*minRootShare {
+				t.Errorf("%s %s: / is %d bytes of %d bytes disk, the filesystem was not grown", tier, host.Hostname, rootBytes, diskBytes)
+			}
+			if usage > maxRootUsagePercent {
+				t.Errorf("%s %s: / is %d%% used, expected at most %d%%", tier, host.Hostname, usage, maxRootUsagePercent)
+			}
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func blockstorageClient(t *testing.T) core.BlockstorageClient {
+	client, err := core.NewBlockstorageClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	throttle(&client.BaseClient)
+	return client
+}
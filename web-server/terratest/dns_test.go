@@ -0,0 +1,106 @@
+package terratest
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/ssh"
+	"github.com/oracle/oci-go-sdk/core"
+)
+
+const (
+	// VCN resolver, handed out by the default DHCP options of type VcnLocalPlusInternet
+	vcnResolver = "169.254.169.254"
+	// max query time of the VCN resolver, unless MAX_DNS_LATENCY is set
+	defaultMaxDnsLatency = 500 * time.Millisecond
+)
+
+// stats line of dig, e.g. ;; Query time: 3 msec
+var digQueryTimePattern = regexp.MustCompile(`Query time: (\d+) msec`)
+
+// checkDnsResolver queries the VCN resolver from the bastion and the web servers: the hostnames of the web
+// servers have to resolve to their private IPs, public names have to resolve, and each query has to be answered
+// within MAX_DNS_LATENCY (defaultMaxDnsLatency when not set). The instances have to use the VCN resolver.
+// dig is installed (bind-utils) when missing.
+func checkDnsResolver(t *testing.T) {
+	if expectedDnsServerType != core.DhcpDnsOptionServerTypeVcnlocalplusinternet {
+		t.Skipf("instances do not use the VCN resolver with DNS server type %q", expectedDnsServerType)
+	}
+	maxLatency := durationEnv(t, "MAX_DNS_LATENCY")
+	if maxLatency == 0 {
+		maxLatency = defaultMaxDnsLatency
+	}
+	hostnames := outputValues(t, "WebServerHostNames")
+	domain := outputValues(t, "WebServerDomain")[0]
+	ips := outputValues(t, "WebServerPrivateIPs")
+	publicNames := []string{
+		strings.TrimPrefix(internetProbeURL, "https://"),
+		fmt.Sprintf("objectstorage.%s.oraclecloud.com", stringVar("region", "")),
+	}
+
+	for _, host := range append([]ssh.Host{bastionHost(t)}, webHosts(t)...) {
+		install := RunRemote(t, host, "command -v dig >/dev/null || yum -y -q install bind-utils", RemoteOptions{Sudo: true, Timeout: sshCommandTimeout})
+		if install.ExitCode != 0 {
+			t.Fatalf("error in installing dig on %s, exit code %d: %s", host.Hostname, install.ExitCode, install.Stderr)
+		}
+		nameservers := RunRemote(t, host, "awk '/^nameserver/ {print $2}' /etc/resolv.conf", RemoteOptions{Timeout: sshCommandTimeout})
+		if !containsString(strings.Fields(nameservers.Stdout), vcnResolver) {
+			t.Errorf("%s does not use the VCN resolver %s, nameservers: %q", host.Hostname, vcnResolver, nameservers.Stdout)
+		}
+
+		slowest := time.Duration(0)
+		query := func(name string) []string {
+			answers, latency := dnsQuery(t, host, name)
+			if latency > slowest {
+				slowest = latency
+			}
+			if latency > maxLatency {
+				t.Errorf("%s resolved %s in %s, expected at most %s", host.Hostname, name, latency, maxLatency)
+			}
+			return answers
+		}
+
+		// assertions
+		for i, hostname := range hostnames {
+			fqdn := hostname + "." + domain
+			if answers := query(fqdn); !containsString(answers, ips[i]) {
+				t.Errorf("%s resolved %s by %s: expected %q, got %v", host.Hostname, fqdn, vcnResolver, ips[i], answers)
+			}
+		}
+		for _, name := range publicNames {
+			if answers := query(name); len(answers) == 0 {
+				t.Errorf("%s could not resolve %s by %s", host.Hostname, name, vcnResolver)
+			}
+		}
+		t.Logf("%s: slowest DNS query %s", host.Hostname, slowest)
+		report.AddMetric("dns latency "+host.Hostname, slowest)
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// dnsQuery asks the VCN resolver for the A records of name on the host and returns the addresses and the query time.
+func dnsQuery(t *testing.T, host ssh.Host, name string) ([]string, time.Duration) {
+	command := fmt.Sprintf("dig +noall +answer +stats +tries=1 +time=2 @%s %s A", vcnResolver, shellQuote(name))
+	result := RunRemote(t, host, command, RemoteOptions{Timeout: sshCommandTimeout})
+	match := digQueryTimePattern.FindStringSubmatch(result.Stdout)
+	if result.ExitCode != 0 || match == nil {
+		t.Errorf("dig %s on %s failed with exit code %d: %s%s", name, host.Hostname, result.ExitCode, result.Stdout, result.Stderr)
+		return nil, 0
+	}
+	msec, _ := strconv.Atoi(match[1])
+
+	addresses := []string{}
+	for _, line := range strings.Split(result.Stdout, "\n") {
+		// e.g. web0.web.vcn.oraclevcn.com. 300 IN A 10.0.1.2
+		fields := strings.Fields(line)
+		if len(fields) == 5 && fields[3] == "A" && !strings.HasPrefix(line, ";") {
+			addresses = append(addresses, fields[4])
+		}
+	}
+	return addresses, time.Duration(msec) * time.Millisecond
+}
@@ -0,0 +1,38 @@
+package terratest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// dryRunEnabled tells whether the run only validates its configuration, enabled by DRY_RUN=1.
+func dryRunEnabled() bool {
+	return os.Getenv("DRY_RUN") != ""
+}
+
+// dryRun validates and plans the stack, loads the expectations and lists the checks the run would do.
+// The configuration is validated by terraformEnvOptions already. Nothing is applied, no ssh session is opened
+// and no mutating API call is made, the state stays local.
+func dryRun(t *testing.T) {
+	if ephemeralKeyEnabled() {
+		generateEphemeralKey(t)
+		defer discardEphemeralKey(t)
+	}
+
+	terraform.Init(t, options)
+	terraform.RunTerraformCommand(t, options, "validate")
+
+	loadExpectations(t)
+	t.Logf("expectations loaded from %s", expectationsFile())
+
+	// terraform output is logged by terratest
+	enforcePlanPolicies(t)
+
+	suite := selectedSuite(t)
+	t.Logf("suite %s would run:", suite)
+	for _, check := range selectedChecks(suite) {
+		t.Logf("  %s %v", check.Name, check.Tags)
+	}
+}
@@ -0,0 +1,112 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/oracle/oci-go-sdk/email"
+)
+
+const (
+	// approved senders, COMPARTMENT_OCID_EMAIL or CompartmentOCID
+	emailCompartment = "email"
+	// postfix logs the delivery status of a message within seconds when the relay accepts it
+	mailStatusAttempts = 30
+)
+
+var (
+	// sends a message by the local MTA and prints the postfix log line with the delivery status of the message
+	mailTemplate = template.Must(template.New("mail").Parse(`#!/bin/bash
+printf 'From: {{.From}}\nTo: {{.To}}\nSubject: terratest relay check\nMessage-ID: <{{.Token}}>\n\nsent by terratest\n' \
+  | /usr/sbin/sendmail -f '{{.From}}' '{{.To}}' || exit 1
+for i in $(seq 1 {{.Attempts}}); do
+  queue=$(journalctl --no-pager -q --since "10 min ago" | grep -o "[0-9A-F]*: message-id=<{{.Token}}>" | cut -d: -f1 | head -1)
+  if [ -n "$queue" ]; then
+    status=$(journalctl --no-pager -q --since "10 min ago" | grep "$queue: to=" | grep "status=" | tail -1)
+    if [ -n "$status" ]; then
+      echo "$status"
+      exit 0
+    fi
+  fi
+  sleep 2
+done
+echo "no delivery status of <{{.Token}}>"
+`))
+)
+
+// EmailExpectation is the outbound mail of the web servers, relayed by OCI Email Delivery.
+type EmailExpectation struct {
+	// Sender is the approved sender the web servers send from
+	Sender    string `json:"sender"`
+	Recipient string `json:"recipient"`
+}
+
+func checkApprovedSender(t *testing.T) {
+	expected := loadExpectations(t).Email
+	if expected == nil {
+		t.Skip("no email in expectations")
+	}
+	compartmentID := compartmentFor(emailCompartment)
+	client, err := email.NewEmailClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	throttle(&client.BaseClient)
+
+	response, err := client.ListSenders(context.Background(), email.ListSendersRequest{
+		CompartmentId: &compartmentID,
+		EmailAddress:  &expected.Sender,
+	})
+	if err != nil {
+		t.Fatalf("error in listing approved senders: %s", err.Error())
+	}
+
+	// assertions
+	if len(response.Items) == 0 {
+		t.Fatalf("%s is not an approved sender in %s", expected.Sender, compartmentID)
+	}
+	if state := response.Items[0].LifecycleState; state != email.SenderSummaryLifecycleStateActive {
+		t.Errorf("approved sender %s in state %s", expected.Sender, state)
+	}
+}
+
+// checkMailRelay sends a message on a web server by its MTA and verifies by the postfix log
+// that the Email Delivery SMTP endpoint of the region accepted it.
+func checkMailRelay(t *testing.T) {
+	expected := loadExpectations(t).Email
+	if expected == nil {
+		t.Skip("no email in expectations")
+	}
+	host := webHosts(t)[0]
+	relay := fmt.Sprintf("smtp.email.%s.oci.oraclecloud.com", stringVar("region", ""))
+
+	var script strings.Builder
+	err := mailTemplate.Execute(&script, struct {
+		From     string
+		To       string
+		Token    string
+		Attempts int
+	}{expected.Sender, expected.Recipient, "terratest-" + random.UniqueId() + "@" + host.Hostname, mailStatusAttempts})
+	if err != nil {
+		t.Fatalf("error in generating mail script: %s", err.Error())
+	}
+
+	result := RunRemote(t, host, script.String(), RemoteOptions{Sudo: true, Timeout: sshCommandTimeout})
+	if result.ExitCode != 0 {
+		t.Fatalf("error in sending mail on %s: %s", host.Hostname, result.Stderr)
+	}
+	status := strings.TrimSpace(result.Stdout)
+	t.Logf("%s: %s", host.Hostname, status)
+
+	// assertions
+	if !strings.Contains(status, "relay="+relay) {
+		t.Errorf("%s: mail not relayed by %s: %s", host.Hostname, relay, status)
+	}
+	if !strings.Contains(status, "status=sent") {
+		t.Errorf("%s: mail not accepted by %s: %s", host.Hostname, relay, status)
+	}
+}
@@ -0,0 +1,139 @@
+package terratest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+)
+
+const (
+	defaultExpectationsFile = "expectations.json"
+)
+
+var (
+	expectations     *Expectations
+	expectationsErr  error
+	expectationsOnce sync.Once
+)
+
+// Expectations describe the desired state of the environment which is not derived from terraform variables.
+type Expectations struct {
+	Quotas []QuotaExpectation `json:"quotas"`
+	// SecurityPolicies by tier (web, bastion)
+	SecurityPolicies map[string][]SecurityExpectation `json:"securityPolicies"`
+	Reachability     ReachabilityExpectation          `json:"reachability"`
+	// Vnics by tier
+	Vnics map[string]VnicExpectation `json:"vnics"`
+	// Packages by tier
+	Packages map[string][]PackageExpectation `json:"packages"`
+	Os       OsExpectation                   `json:"os"`
+	// SecurityUpdatesCutoff is a date (2006-01-02), advisories issued before have to be applied
+	SecurityUpdatesCutoff string `json:"securityUpdatesCutoff"`
+	// Firewall by tier
+	Firewall map[string]FirewallExpectation `json:"firewall"`
+	Selinux  SelinuxExpectation             `json:"selinux"`
+	// InstancePool of stacks using instance pools, nil otherwise
+	InstancePool *InstancePoolExpectation `json:"instancePool"`
+	// Patching of instances registered with OS Management, not checked when nil
+	Patching *PatchingExpectation `json:"patching"`
+	// AgentPlugins are the Cloud Agent plugins required on all instances, e.g. Bastion
+	AgentPlugins []string           `json:"agentPlugins"`
+	Alarms       []AlarmExpectation `json:"alarms"`
+	// InstanceMetrics enables the check that CPU metrics of all hosts are flowing
+	InstanceMetrics bool `json:"instanceMetrics"`
+	// Notifications topic of the stack, not checked when nil
+	Notifications *NotificationsExpectation `json:"notifications"`
+	// Logging of the stack, not checked when nil
+	Logging *LoggingExpectation `json:"logging"`
+	// Vault of the stack, not checked when nil
+	Vault *VaultExpectation `json:"vault"`
+	// Certificates of OCI Certificates on TLS listeners of the load balancer
+	Certificates []CertificateExpectation `json:"certificates"`
+	// Waf of the load balancer, not checked when nil
+	Waf *WafExpectation `json:"waf"`
+	// Budget of the stack compartment, not checked when nil
+	Budget *BudgetExpectation `json:"budget"`
+	// Placement of instances on dedicated VM hosts or capacity reservations by tier
+	Placement map[string]PlacementExpectation `json:"placement"`
+	// Replication of backups and buckets to a DR region, not checked when nil
+	Replication *ReplicationExpectation `json:"replication"`
+	// BootVolumeBackupPolicy is the display name of the backup policy of all boot volumes, e.g. silver
+	BootVolumeBackupPolicy string `json:"bootVolumeBackupPolicy"`
+	// FileStorage exported to the web servers, not checked when nil
+	FileStorage *FileStorageExpectation `json:"fileStorage"`
+	// Database backend of the web servers, not checked when nil
+	Database *DatabaseExpectation `json:"database"`
+	// Steering policy of the domain of the stack, not checked when nil
+	Steering *SteeringExpectation `json:"steering"`
+	// Email relay of the web servers, not checked when nil
+	Email *EmailExpectation `json:"email"`
+	// PlanPolicies parametrize the policies the plan is evaluated against before apply, the tags and allow-lists
+	// are not checked when nil
+	PlanPolicies *PlanPoliciesExpectation `json:"planPolicies"`
+	// ImageFreshness of the images of the instances, not checked when nil
+	ImageFreshness *ImageFreshnessExpectation `json:"imageFreshness"`
+	// Bandwidth minimums of the bandwidth check, defaults of BandwidthExpectation when nil
+	Bandwidth *BandwidthExpectation `json:"bandwidth"`
+}
+
+// QuotaExpectation is a quota policy which has to contain the statements.
+type QuotaExpectation struct {
+	Name string `json:"name"`
+	// CompartmentID where the quota is defined, tenancy when empty
+	CompartmentID string   `json:"compartmentId"`
+	Statements    []string `json:"statements"`
+}
+
+// SecurityExpectation is traffic which has to be allowed or denied by effective rules of a VNIC.
+type SecurityExpectation struct {
+	Direction string `json:"direction"`
+	Protocol  string `json:"protocol"`
+	Port      int    `json:"port"`
+	// Peer is CIDR or vcn, private-subnet, bastion-subnet, lb-subnet, internet
+	Peer    string `json:"peer"`
+	Allowed bool   `json:"allowed"`
+}
+
+// ReachabilityExpectation lists permitted flows between tiers, all other flows on Ports have to be denied.
+type ReachabilityExpectation struct {
+	Ports []int `json:"ports"`
+	// Allowed flows "src->dst:port", e.g. "bastion->web:22" or "*->lb:80"
+	Allowed []string `json:"allowed"`
+}
+
+// VnicExpectation describes VNICs of every instance of a tier.
+type VnicExpectation struct {
+	Count int `json:"count"`
+	// SubnetDnsLabel is a prefix of the dns label of the primary VNIC subnet
+	SubnetDnsLabel      string `json:"subnetDnsLabel"`
+	SkipSourceDestCheck bool   `json:"skipSourceDestCheck"`
+}
+
+// loadExpectations reads the file from EXPECTATIONS_FILE (default expectations.json) once per run.
+func loadExpectations(t *testing.T) *Expectations {
+	expectationsOnce.Do(func() {
+		path := expectationsFile()
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			expectationsErr = err
+			return
+		}
+
+		expectations = &Expectations{}
+		expectationsErr = json.Unmarshal(content, expectations)
+	})
+
+	if expectationsErr != nil {
+		t.Fatalf("error in loading expectations from %s: %s", expectationsFile(), expectationsErr.Error())
+	}
+	return expectations
+}
+
+func expectationsFile() string {
+	if path := os.Getenv("EXPECTATIONS_FILE"); path != "" {
+		return path
+	}
+	return defaultExpectationsFile
+}
@@ -0,0 +1,197 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/oracle/oci-go-sdk/core"
+	"github.com/oracle/oci-go-sdk/identity"
+	"github.com/oracle/oci-go-sdk/loadbalancer"
+)
+
+const (
+	// stop or start of the instances and the LB health check following it
+	failoverTimeout      = 10 * time.Minute
+	failoverPollInterval = 10 * time.Second
+	// requests to the LB with one AD down, none of them may fail
+	failoverObservation = time.Minute
+)
+
+// checkMultiAdSpread verifies the instance and LB subnets are regional and the web servers span the ADs of the region.
+func checkMultiAdSpread(t *testing.T) {
+	ads := regionAvailabilityDomains(t)
+	if len(ads) < 2 {
+		t.Skipf("region has %d availability domain, stack cannot span ADs", len(ads))
+	}
+	network := virtualNetworkClient(t)
+
+	subnetIDs := map[string]bool{}
+	for _, subnetID := range getLoadBalancer(t).SubnetIds {
+		subnetIDs[subnetID] = true
+	}
+	for _, instanceID := range outputValues(t, tierOutputs["web"]) {
+		for _, vnic := range instanceVnics(t, instanceID) {
+			subnetIDs[*vnic.SubnetId] = true
+		}
+	}
+
+	// assertions
+	for subnetID := range subnetIDs {
+		id := subnetID
+		WaitForState(t, "subnet "+id, subnetState(network, id), string(core.SubnetLifecycleStateAvailable), defaultStateTimeout)
+		response, err := network.GetSubnet(context.Background(), core.GetSubnetRequest{SubnetId: &id})
+		if err != nil {
+			t.Fatalf("error in calling subnet %s: %s", subnetID, err.Error())
+		}
+		if ad := stringValue(response.Subnet.AvailabilityDomain); ad != "" {
+			t.Errorf("subnet %s is specific to AD %s, expected regional", stringValue(response.Subnet.DisplayName), ad)
+		}
+	}
+
+	byAd := webServersByAd(t)
+	expected := len(ads)
+	if webCount := len(outputValues(t, tierOutputs["web"])); webCount < expected {
+		expected = webCount
+	}
+	if len(byAd) < expected {
+		t.Errorf("web servers span %d of %d ADs: %v", len(byAd), len(ads), byAd)
+	}
+}
+
+// scenarioAdFailover stops all web servers in one AD and verifies the LB serves every request from the other ADs,
+// the web servers are started again at the end.
+func scenarioAdFailover(t *testing.T) {
+	requireScenarios(t)
+	byAd := webServersByAd(t)
+	if len(byAd) < 2 {
+		t.Skipf("AD failover needs web servers in at least 2 ADs, got %v", byAd)
+	}
+	ads := []string{}
+	for ad := range byAd {
+		ads = append(ads, ad)
+	}
+	sort.Strings(ads)
+	failedAd := ads[0]
+	stopped := byAd[failedAd]
+
+	compute := computeClient(t)
+	lb := loadBalancerClient(t)
+	lbID := terraform.Output(t, options, "lb_id")
+	url := "http://" + outputValues(t, "lb_ip")[0] + "/"
+
+	stoppedHosts := map[string]bool{}
+	stoppedIPs := []string{}
+	for _, instanceID := range stopped {
+		for _, vnic := range instanceVnics(t, instanceID) {
+			if boolValue(vnic.IsPrimary) {
+				stoppedHosts[stringValue(vnic.HostnameLabel)] = true
+				stoppedIPs = append(stoppedIPs, *vnic.PrivateIp)
+			}
+		}
+	}
+
+	defer func() {
+		instancesAction(t, compute, stopped, core.InstanceActionActionStart)
+		waitForBackends(t, compute, lb, lbID, stopped, stoppedIPs, core.InstanceLifecycleStateRunning, true)
+		t.Logf("web servers in %s started again", failedAd)
+	}()
+
+	start := time.Now()
+	instancesAction(t, compute, stopped, core.InstanceActionActionStop)
+	waitForBackends(t, compute, lb, lbID, stopped, stoppedIPs, core.InstanceLifecycleStateStopped, false)
+	t.Logf("web servers %v in %s down after %s", stopped, failedAd, time.Since(start))
+	report.AddMetric("time to fail over from "+failedAd, time.Since(start).Round(time.Second))
+
+	client := &http.Client{Timeout: httpTimeout}
+	served := map[string]int{}
+	failed := []string{}
+	for deadline := time.Now().Add(failoverObservation); time.Now().Before(deadline); time.Sleep(lbPollInterval) {
+		name, err := lbServerName(client, url)
+		if err != nil {
+			failed = append(failed, err.Error())
+			continue
+		}
+		served[name]++
+	}
+	t.Logf("requests with %s down served by: %v", failedAd, served)
+
+	// assertions
+	if len(failed) > 0 {
+		t.Errorf("%d requests failed with %s down, errors: %v", len(failed), failedAd, failed)
+	}
+	if len(served) == 0 {
+		t.Errorf("no requests to %s were served with %s down", url, failedAd)
+	}
+	for name := range served {
+		if stoppedHosts[name] {
+			t.Errorf("request served by %s, which is stopped in %s", name, failedAd)
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func regionAvailabilityDomains(t *testing.T) []identity.AvailabilityDomain {
+	compartmentID := stringVar("CompartmentOCID", "")
+	response, err := identityClient(t).ListAvailabilityDomains(context.Background(), identity.ListAvailabilityDomainsRequest{
+		CompartmentId: &compartmentID,
+	})
+	if err != nil {
+		t.Fatalf("error in listing availability domains: %s", err.Error())
+	}
+	return response.Items
+}
+
+// webServersByAd returns ids of web servers by their availability domain.
+func webServersByAd(t *testing.T) map[string][]string {
+	compute := computeClient(t)
+	byAd := map[string][]string{}
+	for _, instanceID := range outputValues(t, tierOutputs["web"]) {
+		id := instanceID
+		response, err := compute.GetInstance(context.Background(), core.GetInstanceRequest{InstanceId: &id})
+		if err != nil {
+			t.Fatalf("error in calling instance %s: %s", instanceID, err.Error())
+		}
+		ad := stringValue(response.Instance.AvailabilityDomain)
+		byAd[ad] = append(byAd[ad], instanceID)
+	}
+	return byAd
+}
+
+func instancesAction(t *testing.T, compute core.ComputeClient, instanceIDs []string, action core.InstanceActionActionEnum) {
+	for _, instanceID := range instanceIDs {
+		id := instanceID
+		if _, err := compute.InstanceAction(context.Background(), core.InstanceActionRequest{InstanceId: &id, Action: action}); err != nil {
+			t.Fatalf("error in %s of instance %s: %s", action, instanceID, err.Error())
+		}
+	}
+}
+
+// waitForBackends waits until the instances are in the state and the health of their backends is OK, or is not OK.
+func waitForBackends(t *testing.T, compute core.ComputeClient, lb loadbalancer.LoadBalancerClient, lbID string,
+	instanceIDs []string, ips []string, state core.InstanceLifecycleStateEnum, healthy bool) {
+	for _, instanceID := range instanceIDs {
+		WaitForState(t, "instance "+instanceID, instanceState(compute, instanceID), string(state), failoverTimeout)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), failoverTimeout)
+	defer cancel()
+	description := fmt.Sprintf("backends %v healthy %t", ips, healthy)
+	err := pollUntil(ctx, description, failoverPollInterval, func(ctx context.Context) (bool, error) {
+		for _, ip := range ips {
+			status, err := backendHealth(ctx, lb, lbID, ip)
+			if err != nil || (status == loadbalancer.BackendHealthStatusOk) != healthy {
+				return false, err
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
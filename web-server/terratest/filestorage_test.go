@@ -0,0 +1,217 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/ssh"
+	"github.com/oracle/oci-go-sdk/core"
+	"github.com/oracle/oci-go-sdk/filestorage"
+)
+
+const (
+	// file systems and mount targets, COMPARTMENT_OCID_FILESTORAGE or CompartmentOCID
+	fileStorageCompartment = "filestorage"
+	fileStorageMountPoint  = "/mnt/terratest-fss"
+)
+
+// FileStorageExpectation is the file system exported by a mount target to the web servers.
+type FileStorageExpectation struct {
+	FileSystem  string `json:"fileSystem"`
+	MountTarget string `json:"mountTarget"`
+	ExportPath  string `json:"exportPath"`
+	// ExportOptions in the order of the export
+	ExportOptions []ExportOptionExpectation `json:"exportOptions"`
+}
+
+// ExportOptionExpectation is a client option of the export, e.g. {"source": "10.0.1.0/24", "access": "READ_WRITE",
+// "identitySquash": "NONE"}.
+type ExportOptionExpectation struct {
+	Source                      string `json:"source"`
+	Access                      string `json:"access"`
+	IdentitySquash              string `json:"identitySquash"`
+	RequirePrivilegedSourcePort bool   `json:"requirePrivilegedSourcePort"`
+}
+
+// fileStorageExport is the file system, its mount target and export of the expectation.
+type fileStorageExport struct {
+	fileSystem  filestorage.FileSystemSummary
+	mountTarget filestorage.MountTargetSummary
+	export      filestorage.Export
+	// mountIP is the private IP of the mount target
+	mountIP string
+}
+
+func checkFileStorage(t *testing.T) {
+	expected := loadExpectations(t).FileStorage
+	if expected == nil {
+		t.Skip("no fileStorage in expectations")
+	}
+	actual := findFileStorageExport(t, expected)
+	linkResource(t, "file system "+expected.FileSystem, *actual.fileSystem.Id, "")
+	linkResource(t, "mount target "+expected.MountTarget, *actual.mountTarget.Id, "")
+
+	// assertions
+	if actual.fileSystem.LifecycleState != filestorage.FileSystemSummaryLifecycleStateActive {
+		t.Errorf("file system %s in state %s", expected.FileSystem, actual.fileSystem.LifecycleState)
+	}
+	if actual.mountTarget.LifecycleState != filestorage.MountTargetSummaryLifecycleStateActive {
+		t.Errorf("mount target %s in state %s", expected.MountTarget, actual.mountTarget.LifecycleState)
+	}
+	if actual.export.LifecycleState != filestorage.ExportLifecycleStateActive {
+		t.Errorf("export %s in state %s", expected.ExportPath, actual.export.LifecycleState)
+	}
+
+	options := actual.export.ExportOptions
+	if len(options) != len(expected.ExportOptions) {
+		t.Fatalf("export %s: wrong number of export options: expected %d, got %d", expected.ExportPath, len(expected.ExportOptions), len(options))
+	}
+	for i, option := range expected.ExportOptions {
+		got := ExportOptionExpectation{
+			Source:                      stringValue(options[i].Source),
+			Access:                      string(options[i].Access),
+			IdentitySquash:              string(options[i].IdentitySquash),
+			RequirePrivilegedSourcePort: boolValue(options[i].RequirePrivilegedSourcePort),
+		}
+		if got != option {
+			t.Errorf("export %s: wrong export option %d: expected %+v, got %+v", expected.ExportPath, i, option, got)
+		}
+	}
+}
+
+// checkFileStorageMount mounts the export on two web servers, writes a file on the first and reads it on the second.
+func checkFileStorageMount(t *testing.T) {
+	expected := loadExpectations(t).FileStorage
+	if expected == nil {
+		t.Skip("no fileStorage in expectations")
+	}
+	hosts := webHosts(t)
+	if len(hosts) < 2 {
+		t.Skipf("shared file storage needs at least 2 web servers, got %d", len(hosts))
+	}
+	actual := findFileStorageExport(t, expected)
+	writer, reader := hosts[0], hosts[1]
+	file := fileStorageMountPoint + "/terratest-" + random.UniqueId()
+	content := runTagValue
+
+	for _, host := range []ssh.Host{writer, reader} {
+		defer RunRemote(t, host, "umount "+fileStorageMountPoint, RemoteOptions{Sudo: true, Timeout: sshCommandTimeout})
+		result := RunRemote(t, host, mountScript(actual.mountIP, expected.ExportPath), RemoteOptions{Sudo: true, Timeout: sshCommandTimeout})
+		if result.ExitCode != 0 {
+			t.Fatalf("error in mounting %s:%s on %s: %s", actual.mountIP, expected.ExportPath, host.Hostname, result.Stderr)
+		}
+	}
+	defer RunRemote(t, writer, "rm -f "+shellQuote(file), RemoteOptions{Sudo: true, Timeout: sshCommandTimeout})
+
+	write := RunRemote(t, writer, fmt.Sprintf("echo %s > %s", shellQuote(content), shellQuote(file)), RemoteOptions{Sudo: true, Timeout: sshCommandTimeout})
+	if write.ExitCode != 0 {
+		t.Fatalf("error in writing %s on %s: %s", file, writer.Hostname, write.Stderr)
+	}
+	read := RunRemote(t, reader, "cat "+shellQuote(file), RemoteOptions{Sudo: true, Timeout: sshCommandTimeout})
+
+	// assertions
+	if read.ExitCode != 0 {
+		t.Fatalf("error in reading %s written by %s on %s: %s", file, writer.Hostname, reader.Hostname, read.Stderr)
+	}
+	if got := strings.TrimSpace(read.Stdout); got != content {
+		t.Errorf("%s read on %s: expected %q written by %s, got %q", file, reader.Hostname, content, writer.Hostname, got)
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func fileStorageClient(t *testing.T) filestorage.FileStorageClient {
+	client, err := filestorage.NewFileStorageClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	throttle(&client.BaseClient)
+	return client
+}
+
+// findFileStorageExport looks up the file system and mount target by display name in all ADs, and their export by path.
+func findFileStorageExport(t *testing.T, expected *FileStorageExpectation) fileStorageExport {
+	client := fileStorageClient(t)
+	compartmentID := compartmentFor(fileStorageCompartment)
+	var found fileStorageExport
+	fileSystemFound, mountTargetFound := false, false
+
+	for _, ad := range regionAvailabilityDomains(t) {
+		fileSystems, err := client.ListFileSystems(context.Background(), filestorage.ListFileSystemsRequest{
+			CompartmentId:      &compartmentID,
+			AvailabilityDomain: ad.Name,
+			DisplayName:        &expected.FileSystem,
+		})
+		if err != nil {
+			t.Fatalf("error in listing file systems in %s: %s", stringValue(ad.Name), err.Error())
+		}
+		for _, fileSystem := range fileSystems.Items {
+			if fileSystem.LifecycleState != filestorage.FileSystemSummaryLifecycleStateDeleted {
+				found.fileSystem, fileSystemFound = fileSystem, true
+			}
+		}
+
+		mountTargets, err := client.ListMountTargets(context.Background(), filestorage.ListMountTargetsRequest{
+			CompartmentId:      &compartmentID,
+			AvailabilityDomain: ad.Name,
+			DisplayName:        &expected.MountTarget,
+		})
+		if err != nil {
+			t.Fatalf("error in listing mount targets in %s: %s", stringValue(ad.Name), err.Error())
+		}
+		for _, mountTarget := range mountTargets.Items {
+			if mountTarget.LifecycleState != filestorage.MountTargetSummaryLifecycleStateDeleted {
+				found.mountTarget, mountTargetFound = mountTarget, true
+			}
+		}
+	}
+	if !fileSystemFound {
+		t.Fatalf("file system %s not found in %s", expected.FileSystem, compartmentID)
+	}
+	if !mountTargetFound {
+		t.Fatalf("mount target %s not found in %s", expected.MountTarget, compartmentID)
+	}
+
+	exports, err := client.ListExports(context.Background(), filestorage.ListExportsRequest{
+		CompartmentId: &compartmentID,
+		FileSystemId:  found.fileSystem.Id,
+		ExportSetId:   found.mountTarget.ExportSetId,
+	})
+	if err != nil {
+		t.Fatalf("error in listing exports of file system %s: %s", expected.FileSystem, err.Error())
+	}
+	for _, export := range exports.Items {
+		if stringValue(export.Path) != expected.ExportPath {
+			continue
+		}
+		response, err := client.GetExport(context.Background(), filestorage.GetExportRequest{ExportId: export.Id})
+		if err != nil {
+			t.Fatalf("error in calling export %s: %s", expected.ExportPath, err.Error())
+		}
+		found.export = response.Export
+	}
+	if found.export.Id == nil {
+		t.Fatalf("file system %s is not exported as %s by mount target %s", expected.FileSystem, expected.ExportPath, expected.MountTarget)
+	}
+
+	if len(found.mountTarget.PrivateIpIds) == 0 {
+		t.Fatalf("mount target %s has no private IP", expected.MountTarget)
+	}
+	privateIP, err := virtualNetworkClient(t).GetPrivateIp(context.Background(), core.GetPrivateIpRequest{
+		PrivateIpId: &found.mountTarget.PrivateIpIds[0],
+	})
+	if err != nil {
+		t.Fatalf("error in calling private IP of mount target %s: %s", expected.MountTarget, err.Error())
+	}
+	found.mountIP = *privateIP.PrivateIp.IpAddress
+	return found
+}
+
+// mountScript installs the NFS client when missing and mounts the export, unless already mounted.
+func mountScript(ip string, path string) string {
+	return fmt.Sprintf("(rpm -q nfs-utils >/dev/null || yum -y -q install nfs-utils) && mkdir -p %s && (mountpoint -q %s || mount -t nfs -o nfsvers=3 %s)",
+		fileStorageMountPoint, fileStorageMountPoint, shellQuote(ip+":"+path)+" "+fileStorageMountPoint)
+}
@@ -0,0 +1,62 @@
+package terratest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// FirewallExpectation is the firewalld configuration of a tier.
+type FirewallExpectation struct {
+	Zone string `json:"zone"`
+	// Ports and Services are exactly the open ones in Zone, e.g. 80/tcp and ssh
+	Ports    []string `json:"ports"`
+	Services []string `json:"services"`
+}
+
+func checkHostFirewalls(t *testing.T) {
+	expected := loadExpectations(t).Firewall
+	if len(expected) == 0 {
+		t.Skip("no firewall in expectations")
+	}
+
+	for tier, expectation := range expected {
+		for _, host := range tierHosts(t, tier) {
+			firewall := func(args string) string {
+				result := RunRemote(t, host, "firewall-cmd "+args, RemoteOptions{Sudo: true, Timeout: sshCommandTimeout})
+				if result.ExitCode != 0 {
+					t.Fatalf("%s %s: firewall-cmd %s failed: %s%s", tier, host.Hostname, args, result.Stdout, result.Stderr)
+				}
+				return strings.TrimSpace(result.Stdout)
+			}
+
+			// assertions, reported as host firewall to tell them from OCI security rules
+			if state := firewall("--state"); state != "running" {
+				t.Errorf("%s %s: host firewall is not running: %q", tier, host.Hostname, state)
+				continue
+			}
+			if zone := firewall("--get-default-zone"); zone != expectation.Zone {
+				t.Errorf("%s %s: wrong host firewall default zone: expected %q, got %q", tier, host.Hostname, expectation.Zone, zone)
+			}
+
+			ports := firewall(fmt.Sprintf("--zone=%s --list-ports", shellQuote(expectation.Zone)))
+			assertFirewallList(t, tier, host.Hostname, "ports", expectation.Ports, ports)
+			services := firewall(fmt.Sprintf("--zone=%s --list-services", shellQuote(expectation.Zone)))
+			assertFirewallList(t, tier, host.Hostname, "services", expectation.Services, services)
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func assertFirewallList(t *testing.T, tier string, hostname string, kind string, expected []string, out string) {
+	actual := strings.Fields(out)
+	sort.Strings(actual)
+	wanted := append([]string{}, expected...)
+	sort.Strings(wanted)
+
+	if strings.Join(actual, " ") != strings.Join(wanted, " ") {
+		t.Errorf("%s %s: wrong host firewall %s: expected %v, got %v", tier, hostname, kind, wanted, actual)
+	}
+}
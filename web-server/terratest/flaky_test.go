@@ -0,0 +1,170 @@
+package terratest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const (
+	defaultCheckHistoryFile = "check-history.json"
+	// results of a check kept in the history
+	checkHistoryRuns = 20
+	// a check flaky in so many runs of its history is reported as quarantined
+	quarantineFlakyRuns = 3
+	// results of checks
+	resultPass  = "PASS"
+	resultFail  = "FAIL"
+	resultFlaky = "FLAKY"
+	resultSkip  = "SKIP"
+)
+
+// CheckHistory are the results of the checks in previous runs, persisted between runs.
+type CheckHistory struct {
+	// Checks are results by check name, the latest last
+	Checks map[string][]CheckResult `json:"checks"`
+}
+
+// CheckResult is the result of a check in one run.
+type CheckResult struct {
+	Time   time.Time `json:"time"`
+	Result string    `json:"result"`
+	// Attempts is 1 plus the number of reruns
+	Attempts int `json:"attempts"`
+}
+
+// runCheck runs the check, and reruns it up to retries times while it fails. A check which passes on a rerun is flaky.
+// Destructive scenarios and load tests are not rerun.
+// The failed attempts of a flaky check still fail TestTerraform in the go test output, pipelines gate on the exit code
+// or exit-summary.json instead, which classify a run with flaky and no failed checks as passed.
+func runCheck(t *testing.T, check Check, run func(t *testing.T), retries int) CheckResult {
+	if check.Matches([]string{tagChaos, tagLoad}) {
+		retries = 0
+	}
+
+	result := CheckResult{Time: time.Now()}
+	for result.Attempts <= retries {
+		name := check.Name
+		if result.Attempts > 0 {
+			name = fmt.Sprintf("%s_rerun_%d", check.Name, result.Attempts)
+		}
+		result.Attempts++
+
+		skipped := false
+		passed := t.Run(name, func(t *testing.T) {
+			defer func() { skipped = t.Skipped() }()
+			run(t)
+		})
+		switch {
+		case skipped:
+			result.Result = resultSkip
+			return result
+		case passed && result.Attempts == 1:
+			result.Result = resultPass
+			return result
+		case passed:
+			result.Result = resultFlaky
+			return result
+		}
+	}
+	result.Result = resultFail
+	return result
+}
+
+// Record appends the result of the check, skipped checks are not recorded.
+func (h *CheckHistory) Record(name string, result CheckResult) {
+	if result.Result == resultSkip {
+		return
+	}
+	results := append(h.Checks[name], result)
+	if len(results) > checkHistoryRuns {
+		results = results[len(results)-checkHistoryRuns:]
+	}
+	h.Checks[name] = results
+}
+
+// Quarantined tells whether the check was flaky in quarantineFlakyRuns runs of its history,
+// its failures are then likely infra noise rather than regressions.
+func (h *CheckHistory) Quarantined(name string) bool {
+	flaky := 0
+	for _, result := range h.Checks[name] {
+		if result.Result == resultFlaky {
+			flaky++
+		}
+	}
+	return flaky >= quarantineFlakyRuns
+}
+
+// FailureRate is the share of the recorded runs of the check which did not pass at the first attempt.
+func (h *CheckHistory) FailureRate(name string) float64 {
+	results := h.Checks[name]
+	if len(results) == 0 {
+		return 0
+	}
+	failed := 0
+	for _, result := range results {
+		if result.Result != resultPass {
+			failed++
+		}
+	}
+	return float64(failed) / float64(len(results))
+}
+
+// Write stores the history into file.
+func (h *CheckHistory) Write(file string) error {
+	content, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, content, 0644)
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// checkRetries is the number of reruns of a failed check, RERUN_FAILED_CHECKS or 0.
+func checkRetries(t *testing.T) int {
+	value := os.Getenv("RERUN_FAILED_CHECKS")
+	if value == "" {
+		return 0
+	}
+	retries, err := strconv.Atoi(value)
+	if err != nil || retries < 0 {
+		t.Fatalf("wrong RERUN_FAILED_CHECKS %q, expected number of reruns", value)
+	}
+	return retries
+}
+
+// checkHistoryFile is CHECK_HISTORY_FILE or check-history.json of the report dir.
+func checkHistoryFile() string {
+	if file := os.Getenv("CHECK_HISTORY_FILE"); file != "" {
+		return file
+	}
+	return filepath.Join(reportDir(), defaultCheckHistoryFile)
+}
+
+// loadCheckHistory reads the history of previous runs, it is empty on the first run.
+func loadCheckHistory(t *testing.T) *CheckHistory {
+	history := &CheckHistory{Checks: map[string][]CheckResult{}}
+	content, err := ioutil.ReadFile(checkHistoryFile())
+	if os.IsNotExist(err) {
+		return history
+	}
+	if err != nil {
+		t.Fatalf("error in reading check history: %s", err.Error())
+	}
+	if err := json.Unmarshal(content, history); err != nil {
+		t.Fatalf("error in parsing check history %s: %s", checkHistoryFile(), err.Error())
+	}
+	if history.Checks == nil {
+		history.Checks = map[string][]CheckResult{}
+	}
+	return history
+}
@@ -0,0 +1,139 @@
+package terratest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const (
+	flowAccept = "ACCEPT"
+	flowReject = "REJECT"
+)
+
+// flowRecord is the data of a VCN flow log entry.
+type flowRecord struct {
+	Action             string `json:"action"`
+	SourceAddress      string `json:"sourceAddress"`
+	DestinationAddress string `json:"destinationAddress"`
+	DestinationPort    int    `json:"destinationPort"`
+	Protocol           int    `json:"protocol"`
+}
+
+// checkFlowLogVerdicts probes reachability ports of web servers from the bastion and asserts the flow logs
+// of the web subnets record ACCEPT for the allowed and REJECT for the denied flows. It validates the security
+// rules by OCI's own telemetry, enabled by RUN_LOG_SEARCH=1 as the ingestion takes minutes.
+func checkFlowLogVerdicts(t *testing.T) {
+	if os.Getenv("RUN_LOG_SEARCH") == "" {
+		t.Skip("log search is enabled by RUN_LOG_SEARCH=1")
+	}
+	expected := loadExpectations(t)
+	if expected.Logging == nil || !expected.Logging.FlowLogs {
+		t.Skip("no logging flowLogs in expectations")
+	}
+	if len(expected.Reachability.Ports) == 0 {
+		t.Skip("no reachability in expectations")
+	}
+	client := newLoggingClient(t)
+	group := findLogGroup(t, client, expected.Logging.LogGroup)
+
+	bastionIP := *instanceVnics(t, outputValues(t, tierOutputs["bastion"])[0])[0].PrivateIp
+	hosts := []string{}
+	queries := map[string]bool{}
+	for _, instanceID := range outputValues(t, tierOutputs["web"]) {
+		for _, vnic := range instanceVnics(t, instanceID) {
+			hosts = append(hosts, *vnic.PrivateIp)
+
+			log, err := client.serviceLog(context.Background(), *group.Id, flowLogsService, *vnic.SubnetId, flowLogsCategory)
+			if err != nil {
+				t.Fatalf("error in listing logs of %s: %s", expected.Logging.LogGroup, err.Error())
+			}
+			if log == nil {
+				t.Fatalf("subnet %s of web server %s has no flow log", *vnic.SubnetId, *vnic.PrivateIp)
+			}
+			queries[fmt.Sprintf(`search "%s/%s/%s" | where data.sourceAddress = '%s'`, *group.CompartmentId, *group.Id, *log.Id, bastionIP)] = true
+		}
+	}
+
+	// known traffic, the verdicts are compared with the reachability expectations
+	start := time.Now()
+	result := RunRemote(t, bastionHost(t), probeScript(t, hosts, expected.Reachability.Ports), RemoteOptions{Timeout: sshCommandTimeout})
+	if result.ExitCode != 0 {
+		t.Fatalf("probe script failed with exit code %d: %s", result.ExitCode, result.Stderr)
+	}
+
+	verdicts := map[string]map[string]bool{}
+	ctx, cancel := context.WithTimeout(context.Background(), logIngestionTimeout)
+	defer cancel()
+
+	err := pollUntil(ctx, "flow log records of the probes", sleepBetweenRetries*3, func(ctx context.Context) (bool, error) {
+		for query := range queries {
+			entries, err := client.searchLogs(ctx, query, start.Add(-time.Minute), time.Now())
+			if err != nil {
+				return false, err
+			}
+			for _, entry := range entries {
+				var record flowRecord
+				if err := json.Unmarshal([]byte(entry), &record); err != nil {
+					return false, err
+				}
+				if strconv.Itoa(record.Protocol) != tcpProtocol {
+					continue
+				}
+				flow := record.DestinationAddress + ":" + strconv.Itoa(record.DestinationPort)
+				if verdicts[flow] == nil {
+					verdicts[flow] = map[string]bool{}
+				}
+				verdicts[flow][record.Action] = true
+			}
+		}
+
+		for _, host := range hosts {
+			for _, port := range expected.Reachability.Ports {
+				if len(verdicts[host+":"+strconv.Itoa(port)]) == 0 {
+					return false, nil
+				}
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		t.Log(err.Error())
+	}
+
+	// assertions
+	for _, host := range hosts {
+		for _, port := range expected.Reachability.Ports {
+			actual := verdicts[host+":"+strconv.Itoa(port)]
+			verdict := flowReject
+			if expected.Reachability.Allows("bastion", "web", port) {
+				verdict = flowAccept
+			}
+
+			switch {
+			case len(actual) == 0:
+				t.Errorf("bastion %s -> %s tcp/%d: no flow log record", bastionIP, host, port)
+			case !actual[verdict] || len(actual) > 1:
+				t.Errorf("bastion %s -> %s tcp/%d: expected %s, flow log records %v", bastionIP, host, port, verdict, flowActions(actual))
+			default:
+				t.Logf("bastion %s -> %s tcp/%d: %s", bastionIP, host, port, verdict)
+			}
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func flowActions(actions map[string]bool) []string {
+	result := []string{}
+	for _, action := range []string{flowAccept, flowReject} {
+		if actions[action] {
+			result = append(result, action)
+		}
+	}
+	return result
+}
@@ -2,7 +2,9 @@ module orahub.oraclecorp.com/cloud-bigdata-dev/terratest
 
 require (
 	github.com/gruntwork-io/terratest v0.27.2
+	github.com/kelseyhightower/envconfig v1.4.0
 	github.com/oracle/oci-go-sdk v19.2.0+incompatible
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
 )
 
 go 1.14
@@ -0,0 +1,87 @@
+package terratest
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/retry"
+)
+
+var (
+	update = flag.Bool("update", false, "update golden files in testdata")
+
+	// dynamic parts of the nginx demo page (userdata/hello-plain-text.conf)
+	goldenNormalizers = []struct {
+		re          *regexp.Regexp
+		replacement string
+	}{
+		{regexp.MustCompile(`(?m)^(Server address: )[0-9.]+`), "${1}<ip>"},
+		{regexp.MustCompile(`(?m)^(Server name: ).*$`), "${1}<hostname>"},
+		{regexp.MustCompile(`(?m)^(Date: ).*$`), "${1}<date>"},
+		{regexp.MustCompile(`(?m)^(Request ID: ).*$`), "${1}<request-id>"},
+	}
+)
+
+func checkIndexGolden(t *testing.T) {
+	bastionHost := bastionHost(t)
+	for _, ip := range outputValues(t, "WebServerPrivateIPs") {
+		command := fmt.Sprintf("curl -s http://%s:%s/", ip, nginxPort)
+		description := fmt.Sprintf("fetch index page from %s", ip)
+
+		body := retry.DoWithRetry(t, description, maxRetries, sleepBetweenRetries, func() (string, error) {
+			return sshCommandE(t, bastionHost, command)
+		})
+		assertGolden(t, "index-backend", body)
+	}
+
+	client := &http.Client{Timeout: httpTimeout}
+	response, err := client.Get("http://" + outputValues(t, "lb_ip")[0] + "/")
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	assertGolden(t, "index-lb", string(body))
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// assertGolden compares normalized content with testdata/<name>.golden,
+// with -update flag the golden file is rewritten instead.
+func assertGolden(t *testing.T, name string, content string) {
+	path := filepath.Join("testdata", name+".golden")
+	actual := normalizeGolden(content)
+
+	if *update {
+		if err := ioutil.WriteFile(path, []byte(actual), 0644); err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("golden file %s updated", path)
+		return
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(expected) != actual {
+		t.Errorf("content differs from golden file %s:\nexpected:\n%s\ngot:\n%s", path, expected, actual)
+	}
+}
+
+func normalizeGolden(content string) string {
+	for _, n := range goldenNormalizers {
+		content = n.re.ReplaceAllString(content, n.replacement)
+	}
+	return content
+}
@@ -0,0 +1,105 @@
+package terratest
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/retry"
+)
+
+var (
+	// headers configured in userdata/hello-plain-text.conf
+	expectedSecurityHeaders = map[string]string{
+		"X-Frame-Options":        "DENY",
+		"X-Content-Type-Options": "nosniff",
+	}
+	// headers which should never reach the client
+	forbiddenHeaders = []string{"X-Powered-By", "X-AspNet-Version", "X-Backend-Server"}
+	expectedServer   = "nginx"
+
+	privateIPPattern = regexp.MustCompile(`\b(10\.\d+|172\.(1[6-9]|2\d|3[01])|192\.168)\.\d+\.\d+\b`)
+	versionPattern   = regexp.MustCompile(`/\d+(\.\d+)*`)
+)
+
+func checkResponseHeaders(t *testing.T) {
+	bastionHost := bastionHost(t)
+	for _, ip := range outputValues(t, "WebServerPrivateIPs") {
+		command := fmt.Sprintf("curl -s -D - -o /dev/null http://%s:%s/", ip, nginxPort)
+		description := fmt.Sprintf("fetch headers from %s", ip)
+
+		out := retry.DoWithRetry(t, description, maxRetries, sleepBetweenRetries, func() (string, error) {
+			return sshCommandE(t, bastionHost, command)
+		})
+
+		header, err := parseHeaders(out)
+		if err != nil {
+			t.Fatalf("error in parsing headers from %s: %s", ip, err.Error())
+		}
+		assertHeaders(t, ip, header)
+	}
+
+	lbAddress := outputValues(t, "lb_ip")[0]
+	client := &http.Client{Timeout: httpTimeout}
+	response, err := client.Get("http://" + lbAddress + "/")
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	response.Body.Close()
+
+	assertHeaders(t, "lb "+lbAddress, response.Header)
+}
+
+// assertHeaders checks Server header, configured security headers and headers leaking internals.
+func assertHeaders(t *testing.T, source string, header http.Header) {
+	server := header.Get("Server")
+	if server != expectedServer {
+		t.Errorf("%s: wrong Server header: expected %q, got %q", source, expectedServer, server)
+	}
+
+	for name, expected := range expectedSecurityHeaders {
+		actual := header.Get(name)
+		if actual != expected {
+			t.Errorf("%s: wrong %s header: expected %q, got %q", source, name, expected, actual)
+		}
+	}
+
+	for _, name := range forbiddenHeaders {
+		if value := header.Get(name); value != "" {
+			t.Errorf("%s: forbidden header %s present: %q", source, name, value)
+		}
+	}
+
+	for name, values := range header {
+		for _, value := range values {
+			if privateIPPattern.MatchString(value) {
+				t.Errorf("%s: header %s leaks internal IP: %q", source, name, value)
+			}
+			if name != "Set-Cookie" && versionPattern.MatchString(value) {
+				t.Errorf("%s: header %s leaks software version: %q", source, name, value)
+			}
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// parseHeaders parses the raw output of curl -D.
+func parseHeaders(raw string) (http.Header, error) {
+	reader := textproto.NewReader(bufio.NewReader(strings.NewReader(raw)))
+
+	// status line
+	if _, err := reader.ReadLine(); err != nil {
+		return nil, err
+	}
+
+	header, err := reader.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	return http.Header(header), nil
+}
@@ -0,0 +1,119 @@
+package terratest
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/oracle/oci-go-sdk/loadbalancer"
+)
+
+const (
+	// time from apply to healthy LB
+	defaultHealthySLO = 5 * time.Minute
+)
+
+var (
+	// set when the stack is applied by the test itself
+	applyStartedAt time.Time
+	appliedAt      time.Time
+	// time from apply to the first successful response of the LB, set by checkLoadBalancerHealthy
+	lbHealthyAfter time.Duration
+)
+
+func checkLoadBalancerHealthy(t *testing.T) {
+	WaitForState(t, "load balancer", loadBalancerState(loadBalancerClient(t), terraform.Output(t, options, "lb_id")),
+		string(loadbalancer.LoadBalancerLifecycleStateActive), defaultStateTimeout)
+	url := "http://" + outputValues(t, "lb_ip")[0] + "/"
+	lbHealthyAfter = WaitForHealthy(t, url, http.StatusOK, healthySLO(t))
+}
+
+// checkProvisioningSLO fails when terraform apply takes longer than APPLY_SLO, or the LB responds first later
+// than LB_RESPONSE_SLO after the start of apply, e.g. APPLY_SLO=15m LB_RESPONSE_SLO=20m. Both are optional.
+func checkProvisioningSLO(t *testing.T) {
+	applySLO := durationEnv(t, "APPLY_SLO")
+	responseSLO := durationEnv(t, "LB_RESPONSE_SLO")
+	if applySLO == 0 && responseSLO == 0 {
+		t.Skip("provisioning SLO is enabled by APPLY_SLO or LB_RESPONSE_SLO")
+	}
+	if appliedAt.IsZero() {
+		t.Skip("stack was not applied by this run")
+	}
+	applyDuration := appliedAt.Sub(applyStartedAt)
+	report.AddMetric("terraform apply", applyDuration.Round(time.Second))
+
+	// assertions
+	if applySLO > 0 && applyDuration > applySLO {
+		t.Errorf("terraform apply took %s, SLO is %s", applyDuration.Round(time.Second), applySLO)
+	}
+
+	if responseSLO > 0 {
+		if lbHealthyAfter == 0 {
+			url := "http://" + outputValues(t, "lb_ip")[0] + "/"
+			lbHealthyAfter = WaitForHealthy(t, url, http.StatusOK, responseSLO)
+		}
+		firstResponse := applyDuration + lbHealthyAfter
+		report.AddMetric("time to first LB response", firstResponse.Round(time.Second))
+		if firstResponse > responseSLO {
+			t.Errorf("first successful LB response %s after start of apply, SLO is %s", firstResponse.Round(time.Second), responseSLO)
+		}
+	}
+}
+
+// WaitForHealthy polls url until it returns expectedStatus and fails when it does not converge within the duration.
+// The convergence time is measured from apply (when done by this run) and is added to the report.
+func WaitForHealthy(t *testing.T, url string, expectedStatus int, within time.Duration) time.Duration {
+	start := appliedAt
+	if start.IsZero() {
+		start = time.Now()
+	}
+	deadline := start.Add(within)
+	client := &http.Client{Timeout: httpTimeout}
+
+	for {
+		status := 0
+		response, err := client.Get(url)
+		if err == nil {
+			status = response.StatusCode
+			response.Body.Close()
+		}
+
+		if status == expectedStatus {
+			converged := time.Since(start)
+			t.Logf("%s healthy after %s", url, converged)
+			report.AddMetric("time to healthy "+url, converged.Round(time.Second))
+			return converged
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("%s not healthy within %s: expected status %d, got %d (err: %v)", url, within, expectedStatus, status, err)
+		}
+		time.Sleep(sleepBetweenRetries)
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// healthySLO can be overridden by HEALTHY_SLO env var, e.g. HEALTHY_SLO=10m.
+func healthySLO(t *testing.T) time.Duration {
+	if slo := durationEnv(t, "HEALTHY_SLO"); slo > 0 {
+		return slo
+	}
+	return defaultHealthySLO
+}
+
+// durationEnv parses the env var as a duration, e.g. 10m, it is 0 when not set.
+func durationEnv(t *testing.T, name string) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		t.Fatalf("wrong %s %q: %s", name, value, err.Error())
+	}
+	return duration
+}
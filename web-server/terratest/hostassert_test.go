@@ -0,0 +1,93 @@
+package terratest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/ssh"
+)
+
+// Host assertions run on the host through the bastion and are retried until they hold, as services
+// and cloud-init may still be settling after apply. A failed assertion is reported with t.Errorf and
+// the assertion returns false, so that a check can make several of them.
+
+// ProcessListening asserts that a process of the name listens on the TCP port.
+func ProcessListening(t *testing.T, host ssh.Host, process string, port string) bool {
+	command := fmt.Sprintf("netstat -tnlp | grep ':%s ' | grep -c %s", port, shellQuote("/"+process))
+	return assertHost(t, host, fmt.Sprintf("%s listening on %s", process, port), command, true, func(result *RemoteResult) error {
+		if result.ExitCode != 0 {
+			return fmt.Errorf("no %s listening on port %s", process, port)
+		}
+		return nil
+	})
+}
+
+// FileContains asserts that the file contains the text.
+func FileContains(t *testing.T, host ssh.Host, path string, text string) bool {
+	command := fmt.Sprintf("grep -qF -- %s %s", shellQuote(text), shellQuote(path))
+	return assertHost(t, host, fmt.Sprintf("%s contains %q", path, text), command, true, func(result *RemoteResult) error {
+		if result.ExitCode != 0 {
+			return fmt.Errorf("%s does not contain %q: %s", path, text, strings.TrimSpace(result.Stderr))
+		}
+		return nil
+	})
+}
+
+// UserExists asserts that the user exists.
+func UserExists(t *testing.T, host ssh.Host, user string) bool {
+	command := "id -u " + shellQuote(user)
+	return assertHost(t, host, fmt.Sprintf("user %s exists", user), command, false, func(result *RemoteResult) error {
+		if result.ExitCode != 0 {
+			return fmt.Errorf("no user %s", user)
+		}
+		return nil
+	})
+}
+
+// CronEntryExists asserts that the crontab of the user has a line containing the entry.
+func CronEntryExists(t *testing.T, host ssh.Host, user string, entry string) bool {
+	command := fmt.Sprintf("crontab -l -u %s | grep -qF -- %s", shellQuote(user), shellQuote(entry))
+	return assertHost(t, host, fmt.Sprintf("cron entry %q of %s", entry, user), command, true, func(result *RemoteResult) error {
+		if result.ExitCode != 0 {
+			return fmt.Errorf("no cron entry %q in crontab of %s", entry, user)
+		}
+		return nil
+	})
+}
+
+// SysctlEquals asserts the value of the kernel parameter, values of several fields are compared
+// regardless of the whitespace between them.
+func SysctlEquals(t *testing.T, host ssh.Host, key string, value string) bool {
+	command := "sysctl -n " + shellQuote(key)
+	return assertHost(t, host, fmt.Sprintf("sysctl %s = %s", key, value), command, false, func(result *RemoteResult) error {
+		if result.ExitCode != 0 {
+			return fmt.Errorf("no sysctl %s: %s", key, strings.TrimSpace(result.Stderr))
+		}
+		if actual := strings.Join(strings.Fields(result.Stdout), " "); actual != strings.Join(strings.Fields(value), " ") {
+			return fmt.Errorf("wrong sysctl %s: expected %q, got %q", key, value, actual)
+		}
+		return nil
+	})
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// assertHost runs command on host until holds accepts its result, and reports the last error when it does not.
+func assertHost(t *testing.T, host ssh.Host, description string, command string, sudo bool, holds func(result *RemoteResult) error) bool {
+	var lastErr error
+	retry.DoWithRetryE(t, description+" on "+host.Hostname, maxRetries, sleepBetweenRetries, func() (string, error) {
+		result, err := RunRemoteE(t, host, command, RemoteOptions{Sudo: sudo, Timeout: sshCommandTimeout})
+		if err == nil {
+			err = holds(result)
+		}
+		lastErr = err
+		return "", err
+	})
+	if lastErr != nil {
+		t.Errorf("%s: %s", host.Hostname, lastErr.Error())
+		return false
+	}
+	return true
+}
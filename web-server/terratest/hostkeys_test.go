@@ -0,0 +1,199 @@
+package terratest
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/ssh"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	// SSH_HOST_KEYS modes
+	hostKeysInsecure = ""
+	hostKeysPin      = "pin"
+	hostKeysConsole  = "console"
+	// cloud-init prints the host keys to the serial console
+	consoleKeysBegin      = "-----BEGIN SSH HOST KEY KEYS-----"
+	consoleKeysEnd        = "-----END SSH HOST KEY KEYS-----"
+	consoleHistoryLength  = 10 * 1024 * 1024
+	consoleCaptureRetries = 12
+)
+
+var (
+	// host keys verified during the run, by host IP
+	knownHosts struct {
+		sync.Mutex
+		keys map[string][]gossh.PublicKey
+	}
+)
+
+// hostKeyMode is how host keys are verified, SSH_HOST_KEYS=pin trusts the first seen key of every host
+// for the rest of the run, SSH_HOST_KEYS=console expects keys printed by cloud-init to the instance console.
+// Without SSH_HOST_KEYS any host key is accepted.
+func hostKeyMode() string {
+	return os.Getenv("SSH_HOST_KEYS")
+}
+
+// hostKeyCallback verifies host keys by hostKeyMode, the terratest ssh module does not verify them,
+// so in the verifying modes all commands go through the own client (RunRemote).
+func hostKeyCallback(t *testing.T) gossh.HostKeyCallback {
+	mode := hostKeyMode()
+	if mode == hostKeysInsecure {
+		return gossh.InsecureIgnoreHostKey()
+	}
+
+	return func(address string, remote net.Addr, key gossh.PublicKey) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			host = address
+		}
+
+		knownHosts.Lock()
+		known, ok := knownHosts.keys[host]
+		knownHosts.Unlock()
+
+		if !ok {
+			// resolved without the lock, the console capture of a slow instance must not block other handshakes
+			switch mode {
+			case hostKeysPin:
+				known = []gossh.PublicKey{key}
+			case hostKeysConsole:
+				known, err = consoleHostKeys(t, host)
+				if err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("unknown SSH_HOST_KEYS mode %q", mode)
+			}
+
+			// a concurrent handshake may have stored the keys of the host meanwhile, the first one wins
+			knownHosts.Lock()
+			if stored, ok := knownHosts.keys[host]; ok {
+				known = stored
+			} else {
+				if knownHosts.keys == nil {
+					knownHosts.keys = map[string][]gossh.PublicKey{}
+				}
+				knownHosts.keys[host] = known
+				if mode == hostKeysPin {
+					t.Logf("pinned %s host key of %s: %s", key.Type(), host, gossh.FingerprintSHA256(key))
+				}
+				saveKnownHosts(t, address, known)
+			}
+			knownHosts.Unlock()
+		}
+
+		for _, expected := range known {
+			if bytes.Equal(expected.Marshal(), key.Marshal()) {
+				return nil
+			}
+		}
+		return fmt.Errorf("host key mismatch for %s: got %s %s, possible man in the middle",
+			host, key.Type(), gossh.FingerprintSHA256(key))
+	}
+}
+
+// consoleHostKeys reads host keys of the instance with the given IP from its console history.
+func consoleHostKeys(t *testing.T, ip string) ([]gossh.PublicKey, error) {
+	instanceID, err := instanceByIP(t, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := consoleHistory(t, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := parseConsoleHostKeys(content)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no ssh host keys in console history of %s (%s)", ip, instanceID)
+	}
+	return keys, nil
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// verifiedCommandE runs command with the own client, which verifies host keys, and returns
+// output and errors as the terratest ssh module does.
+func verifiedCommandE(t *testing.T, host ssh.Host, command string) (string, error) {
+	result, err := RunRemoteE(t, host, command, RemoteOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	out := result.Stdout + result.Stderr
+	if result.ExitCode != 0 {
+		return out, fmt.Errorf("command %q on %s exited with %d: %s", command, host.Hostname, result.ExitCode, out)
+	}
+	return out, nil
+}
+
+func parseConsoleHostKeys(console string) []gossh.PublicKey {
+	keys := []gossh.PublicKey{}
+	inKeys := false
+	for _, line := range strings.Split(console, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.Contains(line, consoleKeysBegin):
+			inKeys = true
+		case strings.Contains(line, consoleKeysEnd):
+			inKeys = false
+		case inKeys:
+			if key, _, _, _, err := gossh.ParseAuthorizedKey([]byte(line)); err == nil {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// instanceByIP finds the OCID of a bastion or web server by its public or private IP.
+func instanceByIP(t *testing.T, ip string) (string, error) {
+	tiers := []struct {
+		ips string
+		ids string
+	}{
+		{"BastionPublicIP", "BastionIDs"},
+		{"WebServerPrivateIPs", "WebServerIDs"},
+	}
+
+	for _, tier := range tiers {
+		ids := outputValues(t, tier.ids)
+		for i, candidate := range outputValues(t, tier.ips) {
+			if candidate == ip && i < len(ids) {
+				return ids[i], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no instance of the stack has IP %s", ip)
+}
+
+// saveKnownHosts records verified keys of the run in known_hosts of the report dir.
+func saveKnownHosts(t *testing.T, address string, keys []gossh.PublicKey) {
+	path := filepath.Join(reportDir(), "known_hosts")
+	if err := os.MkdirAll(reportDir(), 0755); err != nil {
+		t.Logf("error in saving known hosts: %s", err.Error())
+		return
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Logf("error in saving known hosts: %s", err.Error())
+		return
+	}
+	defer file.Close()
+
+	for _, key := range keys {
+		fmt.Fprintf(file, "# %s\n%s\n", time.Now().UTC().Format(time.RFC3339), knownhosts.Line([]string{address}, key))
+	}
+}
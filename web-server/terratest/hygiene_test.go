@@ -0,0 +1,40 @@
+package terratest
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// TestTerraformHygiene checks formatting and validity of the HCL of the stack, and lints it with tflint when
+// installed. It needs neither OCI credentials nor an applied stack.
+func TestTerraformHygiene(t *testing.T) {
+	// no vars and no backend, the stack is checked as written
+	hygieneOptions := &terraform.Options{TerraformDir: terraformDir()}
+
+	t.Run("fmt", func(t *testing.T) {
+		out, err := terraform.RunTerraformCommandE(t, hygieneOptions, "fmt", "-check", "-recursive", "-diff")
+		if err != nil {
+			t.Errorf("terraform files of %s are not formatted, run terraform fmt:\n%s", terraformDir(), out)
+		}
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		terraform.RunTerraformCommand(t, hygieneOptions, "init", "-backend=false", "-input=false")
+		if out, err := terraform.RunTerraformCommandE(t, hygieneOptions, "validate"); err != nil {
+			t.Errorf("terraform validate of %s failed:\n%s", terraformDir(), out)
+		}
+	})
+
+	t.Run("tflint", func(t *testing.T) {
+		if _, err := exec.LookPath("tflint"); err != nil {
+			t.Skip("tflint is not installed")
+		}
+		cmd := exec.Command("tflint")
+		cmd.Dir = terraformDir()
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("tflint of %s failed: %s\n%s", terraformDir(), err.Error(), out)
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package terratest
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/core"
+)
+
+const (
+	oracleLinux = "Oracle Linux"
+)
+
+var (
+	// build suffix of platform image names, e.g. -2021.04.09-0 of Oracle-Linux-7.9-2021.04.09-0
+	imageBuildPattern = regexp.MustCompile(`-\d{4}\.\d{2}\.\d{2}-\d+$`)
+)
+
+// ImageFreshnessExpectation bounds how far the image of the instances may lag the latest platform image
+// of the same OS version, family (e.g. not GPU) and shape.
+type ImageFreshnessExpectation struct {
+	// MaxReleasesBehind is the number of newer images allowed, 0 requires the latest one
+	MaxReleasesBehind int `json:"maxReleasesBehind"`
+	// WarnOnly logs stale and deprecated images instead of failing
+	WarnOnly bool `json:"warnOnly"`
+}
+
+func checkImageFreshness(t *testing.T) {
+	expected := loadExpectations(t).ImageFreshness
+	if expected == nil {
+		t.Skip("no imageFreshness in expectations")
+	}
+	compute := computeClient(t)
+	stale := t.Errorf
+	if expected.WarnOnly {
+		stale = t.Logf
+	}
+
+	checked := map[string]bool{}
+	for tier := range tierOutputs {
+		for _, instanceID := range outputValues(t, tierOutputs[tier]) {
+			instance := getInstance(t, compute, instanceID)
+			imageID := stringValue(instance.ImageId)
+			if checked[imageID] {
+				continue
+			}
+			checked[imageID] = true
+
+			response, err := compute.GetImage(context.Background(), core.GetImageRequest{ImageId: &imageID})
+			// assertions
+			if err != nil {
+				stale("%s image %s is deprecated: %s", tier, imageID, err.Error())
+				continue
+			}
+			if response.Image.LifecycleState != core.ImageLifecycleStateAvailable {
+				stale("%s image %s is deprecated, state %s", tier, imageID, response.Image.LifecycleState)
+				continue
+			}
+			image := response.Image
+
+			latest := latestImages(t, compute, stringValue(image.OperatingSystem), stringValue(image.OperatingSystemVersion),
+				imageFamily(stringValue(image.DisplayName)), stringValue(instance.Shape))
+			behind := len(latest)
+			for i, newer := range latest {
+				if *newer.Id == imageID {
+					behind = i
+				}
+			}
+			t.Logf("%s image %s is %d releases behind %s", tier, stringValue(image.DisplayName), behind, latestImageName(latest))
+			report.AddMetric("image releases behind "+stringValue(image.DisplayName), behind)
+			if behind > expected.MaxReleasesBehind {
+				stale("%s image %s is %d releases behind %s, at most %d allowed",
+					tier, stringValue(image.DisplayName), behind, latestImageName(latest), expected.MaxReleasesBehind)
+			}
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// LatestOracleLinuxImageID resolves the latest Oracle Linux image of the version, e.g. 7.9, for the shape
+// in the region of the run.
+func LatestOracleLinuxImageID(t *testing.T, compute core.ComputeClient, version string, shape string) string {
+	images := latestImages(t, compute, oracleLinux, version, "Oracle-Linux-"+version, shape)
+	if len(images) == 0 {
+		t.Fatalf("no %s %s image for shape %s", oracleLinux, version, shape)
+	}
+	return *images[0].Id
+}
+
+// latestImages returns available platform images of the OS version and family compatible with shape,
+// the latest first.
+func latestImages(t *testing.T, compute core.ComputeClient, operatingSystem string, version string, family string, shape string) []core.Image {
+	response, err := compute.ListImages(context.Background(), core.ListImagesRequest{
+		CompartmentId:          &config.TenancyOCID,
+		OperatingSystem:        &operatingSystem,
+		OperatingSystemVersion: &version,
+		Shape:                  &shape,
+		LifecycleState:         core.ImageLifecycleStateAvailable,
+		SortBy:                 core.ListImagesSortByTimecreated,
+		SortOrder:              core.ListImagesSortOrderDesc,
+	})
+	if err != nil {
+		t.Fatalf("error in listing images of %s %s: %s", operatingSystem, version, err.Error())
+	}
+
+	images := []core.Image{}
+	for _, candidate := range response.Items {
+		if imageFamily(stringValue(candidate.DisplayName)) == family {
+			images = append(images, candidate)
+		}
+	}
+	return images
+}
+
+// latestImageName is the name of the first image, the latest of latestImages.
+func latestImageName(images []core.Image) string {
+	if len(images) == 0 {
+		return "no available image"
+	}
+	return stringValue(images[0].DisplayName)
+}
+
+// imageFamily strips the build of a platform image name, e.g. Oracle-Linux-7.9-Gen2-GPU.
+func imageFamily(name string) string {
+	return imageBuildPattern.ReplaceAllString(name, "")
+}
@@ -0,0 +1,288 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/ssh"
+	"github.com/oracle/oci-go-sdk/autoscaling"
+	"github.com/oracle/oci-go-sdk/core"
+)
+
+const (
+	defaultShape = "VM.Standard2.1"
+	// CPU load of the scale event, longer than the evaluation of threshold policies and their cool down
+	scaleLoadSeconds = 1200
+	scaleOutTimeout  = 25 * time.Minute
+	poolPollInterval = 30 * time.Second
+)
+
+// InstancePoolExpectation describes the instance pool of a stack using pools and autoscaling.
+type InstancePoolExpectation struct {
+	DisplayName string `json:"displayName"`
+	// Size of the pool, WebVMCount when zero
+	Size int `json:"size"`
+	// Autoscaling capacity of the pool, not checked when nil
+	Autoscaling *AutoscalingExpectation `json:"autoscaling"`
+	// BackendSet of the stack LB the pool is attached to, BackendPort is not checked when zero
+	BackendSet  string `json:"backendSet"`
+	BackendPort int    `json:"backendPort"`
+}
+
+// AutoscalingExpectation is the capacity of the enabled autoscaling configuration of the pool.
+type AutoscalingExpectation struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+func checkInstancePool(t *testing.T) {
+	expected := loadExpectations(t).InstancePool
+	if expected == nil {
+		t.Skip("no instancePool in expectations")
+	}
+	client := computeManagementClient(t)
+	pool := findInstancePool(t, client, expected.DisplayName)
+
+	// pool size
+	size := expected.Size
+	if size == 0 {
+		size = config.WebVMCount
+	}
+	if pool.LifecycleState != core.InstancePoolLifecycleStateRunning {
+		t.Errorf("instance pool %s in state %s", expected.DisplayName, pool.LifecycleState)
+	}
+	if *pool.Size != size {
+		t.Errorf("wrong size of instance pool %s: expected %d, got %d", expected.DisplayName, size, *pool.Size)
+	}
+	if running := len(runningPoolInstances(t, client, pool)); running != size {
+		t.Errorf("wrong number of running instances in pool %s: expected %d, got %d", expected.DisplayName, size, running)
+	}
+
+	// instance configuration matches variables
+	launch := instanceConfigurationLaunchDetails(t, client, pool)
+	shape := stringVar("TestServerShape", defaultShape)
+	if actual := stringValue(launch.Shape); actual != shape {
+		t.Errorf("wrong shape of instance configuration: expected %q, got %q", shape, actual)
+	}
+	if image := mapVarValue("InstanceImageOCID", config.Region); image != "" {
+		source, ok := launch.SourceDetails.(core.InstanceConfigurationInstanceSourceViaImageDetails)
+		if !ok {
+			t.Errorf("instance configuration does not launch from an image: %T", launch.SourceDetails)
+		} else if actual := stringValue(source.ImageId); actual != image {
+			t.Errorf("wrong image of instance configuration: expected %q, got %q", image, actual)
+		}
+	}
+
+	// autoscaling
+	if expected.Autoscaling != nil {
+		autoscalingConfig := poolAutoscalingConfiguration(t, *pool.Id)
+		if !boolValue(autoscalingConfig.IsEnabled) {
+			t.Errorf("autoscaling configuration %s is disabled", stringValue(autoscalingConfig.DisplayName))
+		}
+		for _, policy := range autoscalingConfig.Policies {
+			capacity := policy.GetCapacity()
+			if capacity == nil {
+				continue
+			}
+			if *capacity.Min != expected.Autoscaling.Min || *capacity.Max != expected.Autoscaling.Max {
+				t.Errorf("autoscaling policy %s: wrong capacity: expected %d-%d, got %d-%d", stringValue(policy.GetDisplayName()),
+					expected.Autoscaling.Min, expected.Autoscaling.Max, *capacity.Min, *capacity.Max)
+			}
+		}
+	}
+}
+
+// scenarioScaleOut raises CPU load on all pool instances and waits for the autoscaling to grow the pool,
+// enabled by RUN_SCALE_EVENT=1 as it runs for tens of minutes and leaves the pool scaled out until scale in.
+func scenarioScaleOut(t *testing.T) {
+	if os.Getenv("RUN_SCALE_EVENT") == "" {
+		t.Skip("scale event is enabled by RUN_SCALE_EVENT=1")
+	}
+	expected := loadExpectations(t).InstancePool
+	if expected == nil || expected.Autoscaling == nil {
+		t.Skip("no instancePool autoscaling in expectations")
+	}
+	client := computeManagementClient(t)
+	pool := findInstancePool(t, client, expected.DisplayName)
+	before := *pool.Size
+	if before >= expected.Autoscaling.Max {
+		t.Skipf("instance pool %s already has max size %d", expected.DisplayName, before)
+	}
+
+	hosts := poolHosts(t, client, pool)
+	for _, host := range hosts {
+		result := RunRemote(t, host, cpuLoad(scaleLoadSeconds), RemoteOptions{Timeout: sshCommandTimeout})
+		if result.ExitCode != 0 {
+			t.Fatalf("error in starting CPU load on %s: %s", host.Hostname, result.Stderr)
+		}
+	}
+	defer func() {
+		for _, host := range hosts {
+			RunRemote(t, host, "pkill -f 'cpu-load' || true", RemoteOptions{Timeout: sshCommandTimeout})
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), scaleOutTimeout)
+	defer cancel()
+	start := time.Now()
+
+	description := fmt.Sprintf("scale out of instance pool %s", expected.DisplayName)
+	err := pollUntil(ctx, description, poolPollInterval, func(ctx context.Context) (bool, error) {
+		response, err := client.GetInstancePool(ctx, core.GetInstancePoolRequest{InstancePoolId: pool.Id})
+		if err != nil {
+			return false, err
+		}
+		return *response.InstancePool.Size > before, nil
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	t.Logf("instance pool %s scaled out after %s", expected.DisplayName, time.Since(start))
+	report.AddMetric("time to scale out", time.Since(start).Round(time.Second))
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func computeManagementClient(t *testing.T) core.ComputeManagementClient {
+	client, err := core.NewComputeManagementClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	throttle(&client.BaseClient)
+	return client
+}
+
+func autoscalingClient(t *testing.T) autoscaling.AutoScalingClient {
+	client, err := autoscaling.NewAutoScalingClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	throttle(&client.BaseClient)
+	return client
+}
+
+// findInstancePool returns the pool with the display name in the compute compartment.
+func findInstancePool(t *testing.T, client core.ComputeManagementClient, displayName string) core.InstancePool {
+	compartmentID := compartmentFor(computeCompartment)
+	response, err := client.ListInstancePools(context.Background(), core.ListInstancePoolsRequest{
+		CompartmentId: &compartmentID,
+		DisplayName:   &displayName,
+	})
+	if err != nil {
+		t.Fatalf("error in listing instance pools: %s", err.Error())
+	}
+
+	for _, summary := range response.Items {
+		if summary.LifecycleState == core.InstancePoolSummaryLifecycleStateTerminated {
+			continue
+		}
+		pool, err := client.GetInstancePool(context.Background(), core.GetInstancePoolRequest{InstancePoolId: summary.Id})
+		if err != nil {
+			t.Fatalf("error in calling instance pool %s: %s", displayName, err.Error())
+		}
+		linkResource(t, "instance pool "+displayName, *summary.Id, "")
+		return pool.InstancePool
+	}
+
+	t.Fatalf("instance pool %s not found in %s", displayName, compartmentID)
+	return core.InstancePool{}
+}
+
+func runningPoolInstances(t *testing.T, client core.ComputeManagementClient, pool core.InstancePool) []core.InstanceSummary {
+	response, err := client.ListInstancePoolInstances(context.Background(), core.ListInstancePoolInstancesRequest{
+		CompartmentId:  pool.CompartmentId,
+		InstancePoolId: pool.Id,
+	})
+	if err != nil {
+		t.Fatalf("error in listing instances of pool %s: %s", *pool.Id, err.Error())
+	}
+
+	running := []core.InstanceSummary{}
+	for _, instance := range response.Items {
+		if isRunningPoolInstance(instance) {
+			running = append(running, instance)
+		}
+	}
+	return running
+}
+
+// isRunningPoolInstance checks the state of the pool instance, it is capitalized, e.g. Running.
+func isRunningPoolInstance(instance core.InstanceSummary) bool {
+	return strings.EqualFold(stringValue(instance.State), string(core.InstanceLifecycleStateRunning))
+}
+
+// poolHosts are the running pool instances by their primary private IP.
+func poolHosts(t *testing.T, client core.ComputeManagementClient, pool core.InstancePool) []ssh.Host {
+	hosts := []ssh.Host{}
+	for _, instance := range runningPoolInstances(t, client, pool) {
+		for _, vnic := range instanceVnics(t, *instance.Id) {
+			if boolValue(vnic.IsPrimary) {
+				hosts = append(hosts, sshHost(t, *vnic.PrivateIp))
+			}
+		}
+	}
+	return hosts
+}
+
+func instanceConfigurationLaunchDetails(t *testing.T, client core.ComputeManagementClient, pool core.InstancePool) core.InstanceConfigurationLaunchInstanceDetails {
+	response, err := client.GetInstanceConfiguration(context.Background(), core.GetInstanceConfigurationRequest{
+		InstanceConfigurationId: pool.InstanceConfigurationId,
+	})
+	if err != nil {
+		t.Fatalf("error in calling instance configuration %s: %s", *pool.InstanceConfigurationId, err.Error())
+	}
+
+	details, ok := response.InstanceConfiguration.InstanceDetails.(core.ComputeInstanceDetails)
+	if !ok || details.LaunchDetails == nil {
+		t.Fatalf("instance configuration %s has no compute launch details", *pool.InstanceConfigurationId)
+	}
+	return *details.LaunchDetails
+}
+
+// poolAutoscalingConfiguration returns the autoscaling configuration managing the pool.
+func poolAutoscalingConfiguration(t *testing.T, poolID string) autoscaling.AutoScalingConfiguration {
+	client := autoscalingClient(t)
+	compartmentID := compartmentFor(computeCompartment)
+	response, err := client.ListAutoScalingConfigurations(context.Background(), autoscaling.ListAutoScalingConfigurationsRequest{
+		CompartmentId: &compartmentID,
+	})
+	if err != nil {
+		t.Fatalf("error in listing autoscaling configurations: %s", err.Error())
+	}
+
+	for _, summary := range response.Items {
+		if summary.Resource == nil || stringValue(summary.Resource.GetId()) != poolID {
+			continue
+		}
+		configuration, err := client.GetAutoScalingConfiguration(context.Background(), autoscaling.GetAutoScalingConfigurationRequest{
+			AutoScalingConfigurationId: summary.Id,
+		})
+		if err != nil {
+			t.Fatalf("error in calling autoscaling configuration %s: %s", *summary.Id, err.Error())
+		}
+		return configuration.AutoScalingConfiguration
+	}
+
+	t.Fatalf("no autoscaling configuration of instance pool %s", poolID)
+	return autoscaling.AutoScalingConfiguration{}
+}
+
+// cpuLoad keeps all CPUs of the host busy in the background for the seconds, its processes are named cpu-load.
+func cpuLoad(seconds int) string {
+	return fmt.Sprintf(`for i in $(seq $(nproc)); do nohup timeout %d bash -c 'exec -a cpu-load bash -c "while :; do :; done"' >/dev/null 2>&1 & done`, seconds)
+}
+
+// mapVarValue returns the value of the key of a map variable given as HCL, e.g. {eu-frankfurt-1 = "ocid..."}.
+func mapVarValue(name string, key string) string {
+	pattern := regexp.MustCompile(`"?` + regexp.QuoteMeta(key) + `"?\s*=\s*"([^"]*)"`)
+	if match := pattern.FindStringSubmatch(stringVar(name, "")); match != nil {
+		return match[1]
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+package terratest
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/core"
+)
+
+const (
+	ipv6DefaultRoute = "::/0"
+	// OCI subnets of dual-stack VCNs are always /64
+	ipv6SubnetPrefixLength = 64
+)
+
+// checkIpv6 verifies the IPv6 side of a dual-stack VCN: every VNIC of the instances has an IPv6 address
+// of its /64 subnet, subnets routed to the internet route ::/0 too, and the web servers answer HTTP and ssh
+// on their IPv6 addresses from the bastion. It is skipped when the VCN is not IPv6 enabled.
+func checkIpv6(t *testing.T) {
+	network := virtualNetworkClient(t)
+	vcnID := sanitizedVcnId(t)
+	vcn, err := network.GetVcn(context.Background(), core.GetVcnRequest{VcnId: &vcnID})
+	if err != nil {
+		t.Fatalf("error in calling vcn: %s", err.Error())
+	}
+	if vcn.Vcn.Ipv6CidrBlock == nil {
+		t.Skip("VCN is not IPv6 enabled")
+	}
+
+	for tier := range tierOutputs {
+		for _, instanceID := range outputValues(t, tierOutputs[tier]) {
+			for _, vnic := range instanceVnics(t, instanceID) {
+				subnet, err := network.GetSubnet(context.Background(), core.GetSubnetRequest{SubnetId: vnic.SubnetId})
+				if err != nil {
+					t.Fatalf("error in calling subnet %s: %s", *vnic.SubnetId, err.Error())
+				}
+				subnetIpv6 := stringValue(subnet.Subnet.Ipv6CidrBlock)
+				addresses := vnicIpv6s(t, network, *vnic.Id)
+
+				// assertions
+				if _, cidr, err := net.ParseCIDR(subnetIpv6); err != nil {
+					t.Errorf("%s %s: subnet %q has no IPv6 CIDR", tier, *vnic.PrivateIp, stringValue(subnet.Subnet.DisplayName))
+				} else if ones, _ := cidr.Mask.Size(); ones != ipv6SubnetPrefixLength {
+					t.Errorf("%s %s: wrong IPv6 prefix of subnet %q: expected /%d, got %s",
+						tier, *vnic.PrivateIp, stringValue(subnet.Subnet.DisplayName), ipv6SubnetPrefixLength, subnetIpv6)
+				}
+				if len(addresses) == 0 {
+					t.Errorf("%s %s: VNIC has no IPv6 address", tier, *vnic.PrivateIp)
+				}
+				for _, address := range addresses {
+					if !cidrContains(subnetIpv6, *address.IpAddress) {
+						t.Errorf("%s %s: IPv6 %s is not inside subnet %s", tier, *vnic.PrivateIp, *address.IpAddress, subnetIpv6)
+					}
+				}
+				if subnetInternetRoute(t, network, *vnic.SubnetId) && !subnetDefaultRoute(t, network, *vnic.SubnetId, ipv6DefaultRoute) {
+					t.Errorf("%s %s: subnet %q routes 0.0.0.0/0 to the internet, but not %s",
+						tier, *vnic.PrivateIp, stringValue(subnet.Subnet.DisplayName), ipv6DefaultRoute)
+				}
+			}
+		}
+	}
+
+	// probes over IPv6 literals, the bastion is reached over IPv4
+	bastion := bastionHost(t)
+	for _, address := range webIpv6Addresses(t) {
+		result := RunRemote(t, bastion, curl(address, nginxPort, "/"), RemoteOptions{Timeout: sshCommandTimeout})
+		if out := result.Stdout; out != "200" {
+			t.Errorf("curl to web server %s over IPv6: expected status 200, got %q %s", address, out, result.Stderr)
+		}
+		if _, err := RunRemoteE(t, sshHost(t, address), "exit", RemoteOptions{Timeout: sshCommandTimeout}); err != nil {
+			t.Errorf("ssh to web server %s over IPv6: %s", address, err.Error())
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// vnicIpv6s returns the IPv6 addresses of the VNIC, none outside of dual-stack subnets.
+func vnicIpv6s(t *testing.T, network core.VirtualNetworkClient, vnicID string) []core.Ipv6 {
+	response, err := network.ListIpv6s(context.Background(), core.ListIpv6sRequest{VnicId: &vnicID})
+	if err != nil {
+		t.Fatalf("error in listing IPv6 addresses of vnic %s: %s", vnicID, err.Error())
+	}
+	return response.Items
+}
+
+// webIpv6Addresses returns the IPv6 addresses of the web servers, none in an IPv4 only VCN.
+func webIpv6Addresses(t *testing.T) []string {
+	network := virtualNetworkClient(t)
+	addresses := []string{}
+	for _, instanceID := range outputValues(t, tierOutputs["web"]) {
+		for _, vnic := range instanceVnics(t, instanceID) {
+			for _, address := range vnicIpv6s(t, network, *vnic.Id) {
+				addresses = append(addresses, *address.IpAddress)
+			}
+		}
+	}
+	return addresses
+}
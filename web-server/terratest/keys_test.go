@@ -0,0 +1,165 @@
+package terratest
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/ssh"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+const (
+	ephemeralKeyBits = 4096
+	privateKeyMode   = 0600
+)
+
+var (
+	// directory of the generated keypair, empty when personal keys are used
+	ephemeralKeyDir string
+
+	// connection to SSH_AUTH_SOCK shared by all ssh clients of the run
+	sshAgent struct {
+		once   sync.Once
+		client agent.ExtendedAgent
+		err    error
+	}
+)
+
+// useSshAgent is true when keys are taken from the running ssh-agent, forced by SSH_AGENT=1.
+// Without a passphrase in SSH_KEY_PASSPHRASE, encrypted key files fall back to the agent too.
+func useSshAgent(t *testing.T) bool {
+	if ephemeralKeyDir != "" {
+		return false
+	}
+	if os.Getenv("SSH_AGENT") != "" {
+		return true
+	}
+	if os.Getenv("SSH_AUTH_SOCK") == "" || os.Getenv("SSH_KEY_PASSPHRASE") != "" {
+		return false
+	}
+	return privateKeyEncrypted(t, readPrivateKey(t))
+}
+
+// ephemeralKeyEnabled is true when the run generates its own keypair, enabled by EPHEMERAL_SSH_KEY=1.
+func ephemeralKeyEnabled() bool {
+	return os.Getenv("EPHEMERAL_SSH_KEY") != ""
+}
+
+// generateEphemeralKey creates a fresh keypair and points the stack ssh key variables to it.
+// The stack reads both keys from files (authorized keys and the provisioner connection),
+// so the keys are written to a private temp dir, removed by discardEphemeralKey after destroy.
+func generateEphemeralKey(t *testing.T) {
+	keyPair := ssh.GenerateRSAKeyPair(t, ephemeralKeyBits)
+
+	dir, err := ioutil.TempDir("", "terratest-ssh-")
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	ephemeralKeyDir = dir
+
+	privateKeyPath := filepath.Join(dir, "id_rsa")
+	if err := ioutil.WriteFile(privateKeyPath, []byte(keyPair.PrivateKey), privateKeyMode); err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	publicKeyPath := privateKeyPath + ".pub"
+	if err := ioutil.WriteFile(publicKeyPath, []byte(keyPair.PublicKey), privateKeyMode); err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+
+	options.Vars["ssh_private_key"] = privateKeyPath
+	options.Vars["ssh_public_key"] = publicKeyPath
+	t.Logf("using ephemeral ssh key %s", publicKeyPath)
+}
+
+// discardEphemeralKey removes the generated keypair, nobody can log in to leftovers of the run.
+func discardEphemeralKey(t *testing.T) {
+	if err := os.RemoveAll(ephemeralKeyDir); err != nil {
+		t.Errorf("error in removing ephemeral ssh key %s: %s", ephemeralKeyDir, err.Error())
+	}
+	ephemeralKeyDir = ""
+}
+
+// decryptPrivateKey returns the key unencrypted in PEM, as the terratest ssh module parses only those.
+func decryptPrivateKey(key []byte, passphrase string) ([]byte, error) {
+	raw, err := gossh.ParseRawPrivateKeyWithPassphrase(key, []byte(passphrase))
+	if err != nil {
+		return nil, err
+	}
+
+	// OpenSSH format keys are parsed to a pointer
+	if ed, ok := raw.(*ed25519.PrivateKey); ok {
+		raw = *ed
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(raw)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func readPrivateKey(t *testing.T) []byte {
+	path := stringVar("ssh_private_key", "")
+	key, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func privateKeyEncrypted(t *testing.T, key []byte) bool {
+	_, err := gossh.ParseRawPrivateKey(key)
+	if _, ok := err.(*gossh.PassphraseMissingError); ok {
+		return true
+	}
+	if err != nil {
+		t.Fatalf("error in parsing ssh private key: %s", err.Error())
+	}
+	return false
+}
+
+// agentAuth authenticates with keys of the running ssh-agent, e.g. hardware backed keys.
+func agentAuth() (gossh.AuthMethod, error) {
+	sshAgent.once.Do(func() {
+		socket := os.Getenv("SSH_AUTH_SOCK")
+		if socket == "" {
+			sshAgent.err = fmt.Errorf("SSH_AGENT is set, but SSH_AUTH_SOCK is not")
+			return
+		}
+
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			sshAgent.err = err
+			return
+		}
+		sshAgent.client = agent.NewClient(conn)
+	})
+
+	if sshAgent.err != nil {
+		return nil, sshAgent.err
+	}
+	return gossh.PublicKeysCallback(sshAgent.client.Signers), nil
+}
+
+// hostAuth returns the auth method of host for the own ssh clients (RunRemote).
+func hostAuth(host ssh.Host) (gossh.AuthMethod, error) {
+	if host.SshAgent {
+		return agentAuth()
+	}
+
+	signer, err := gossh.ParsePrivateKey([]byte(host.SshKeyPair.PrivateKey))
+	if err != nil {
+		return nil, err
+	}
+	return gossh.PublicKeys(signer), nil
+}
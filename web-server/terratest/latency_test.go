@@ -0,0 +1,93 @@
+package terratest
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/ssh"
+)
+
+const (
+	latencyPings = 10
+	// MTU of VNICs inside a VCN, the path supports jumbo frames when the interface has it
+	jumboMtu = 9000
+)
+
+var (
+	// summary line of ping, e.g. rtt min/avg/max/mdev = 0.201/0.245/0.301/0.030 ms
+	pingRttPattern = regexp.MustCompile(`= [0-9.]+/([0-9.]+)/[0-9.]+/[0-9.]+ ms`)
+	// summary line of tracepath, e.g. Resume: pmtu 9000 hops 1 back 1
+	tracepathMtuPattern = regexp.MustCompile(`pmtu (\d+)`)
+)
+
+// checkTierLatency measures the round trip time and the path MTU from the bastion to each web server
+// and between the first web server and the others, and records them in the report for trend tracking.
+// The path MTU has to be the jumbo MTU when the interface towards the peer has it, and the RTT has to be
+// below MAX_TIER_RTT (e.g. 2ms) when set.
+func checkTierLatency(t *testing.T) {
+	maxRtt := durationEnv(t, "MAX_TIER_RTT")
+	webIPs := outputValues(t, "WebServerPrivateIPs")
+
+	measure := func(name string, from ssh.Host, to string) {
+		rtt, pathMtu, interfaceMtu := tierLatency(t, from, to)
+		t.Logf("%s: rtt %s, path mtu %d, interface mtu %d", name, rtt, pathMtu, interfaceMtu)
+		report.AddMetric(name+" rtt", rtt)
+		report.AddMetric(name+" path mtu", pathMtu)
+
+		// assertions
+		if interfaceMtu >= jumboMtu && pathMtu < jumboMtu {
+			t.Errorf("%s: path mtu %d, expected jumbo frames of %d as the interface has mtu %d", name, pathMtu, jumboMtu, interfaceMtu)
+		}
+		if maxRtt > 0 && rtt > maxRtt {
+			t.Errorf("%s: rtt %s exceeds MAX_TIER_RTT %s", name, rtt, maxRtt)
+		}
+	}
+
+	bastion := bastionHost(t)
+	for _, ip := range webIPs {
+		measure("bastion -> web "+ip, bastion, ip)
+	}
+	if len(webIPs) < 2 {
+		t.Logf("web -> web not measured with %d web server", len(webIPs))
+		return
+	}
+	from := sshHost(t, webIPs[0])
+	for _, ip := range webIPs[1:] {
+		measure(fmt.Sprintf("web %s -> web %s", webIPs[0], ip), from, ip)
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// tierLatency returns the average ping RTT, the path MTU and the MTU of the interface routing to the ip.
+func tierLatency(t *testing.T, from ssh.Host, ip string) (time.Duration, int, int) {
+	ping := RunRemote(t, from, fmt.Sprintf("ping -c %d -i 0.2 -q %s", latencyPings, shellQuote(ip)), RemoteOptions{Timeout: sshCommandTimeout})
+	match := pingRttPattern.FindStringSubmatch(ping.Stdout)
+	if ping.ExitCode != 0 || match == nil {
+		t.Fatalf("ping from %s to %s failed with exit code %d: %s%s", from.Hostname, ip, ping.ExitCode, ping.Stdout, ping.Stderr)
+	}
+	rtt, err := time.ParseDuration(match[1] + "ms")
+	if err != nil {
+		t.Fatalf("error in parsing rtt %q: %s", match[1], err.Error())
+	}
+
+	tracepath := RunRemote(t, from, "tracepath -n "+shellQuote(ip), RemoteOptions{Timeout: sshCommandTimeout})
+	pathMtu := 0
+	if match := tracepathMtuPattern.FindStringSubmatch(tracepath.Stdout); match != nil {
+		pathMtu, _ = strconv.Atoi(match[1])
+	} else {
+		t.Errorf("no path mtu from %s to %s, exit code %d: %s%s", from.Hostname, ip, tracepath.ExitCode, tracepath.Stdout, tracepath.Stderr)
+	}
+
+	command := fmt.Sprintf("cat /sys/class/net/$(ip route get %s | grep -o 'dev [^ ]*' | cut -d' ' -f2)/mtu", shellQuote(ip))
+	mtu := RunRemote(t, from, command, RemoteOptions{Timeout: sshCommandTimeout})
+	interfaceMtu, err := strconv.Atoi(strings.TrimSpace(mtu.Stdout))
+	if err != nil {
+		t.Errorf("no interface mtu on %s towards %s: %s", from.Hostname, ip, mtu.Stderr)
+	}
+	return rtt, pathMtu, interfaceMtu
+}
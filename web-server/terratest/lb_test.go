@@ -0,0 +1,234 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	neturl "net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/oracle/oci-go-sdk/loadbalancer"
+)
+
+const (
+	// defaults of lb.tf
+	lbShape              = "100Mbps"
+	lbListenerName       = "http"
+	lbListenerPort       = 80
+	lbListenerProtocol   = "HTTP"
+	lbBackendSetName     = "lb-bes-web"
+	lbBackendPort        = 80
+	lbPolicy             = "ROUND_ROBIN"
+	lbIdleTimeoutSeconds = 300
+	// session persistence
+	persistenceRequests = 10
+	// cookie the LB issues to route the session, unless the LB cookie persistence names another one
+	lbRouteCookie       = "X-Oracle-BMC-LBS-Route"
+	httpTimeout         = 10 * time.Second
+	serverNamePrefix    = "Server name: "
+	serverAddressPrefix = "Server address: "
+)
+
+func checkLoadBalancerConfiguration(t *testing.T) {
+	lbID := terraform.Output(t, options, "lb_id")
+	client := loadBalancerClient(t)
+	backends := []string{}
+	for _, ip := range outputValues(t, "WebServerPrivateIPs") {
+		backends = append(backends, fmt.Sprintf("%s:%d", ip, lbBackendPort))
+	}
+	WaitForIDs(t, "backend sets", listBackendSets(client, lbID), []string{lbBackendSetName}, listConsistencyTimeout)
+	WaitForIDs(t, "backends of "+lbBackendSetName, listBackends(client, lbID, lbBackendSetName), backends, listConsistencyTimeout)
+	lb := getLoadBalancer(t)
+
+	// shape
+	expectedShape := stringVar("load_balancer_shape", lbShape)
+	if *lb.ShapeName != expectedShape {
+		t.Errorf("wrong lb shape: expected %q, got %q", expectedShape, *lb.ShapeName)
+	}
+
+	// listeners
+	if len(lb.Listeners) != 1 {
+		t.Errorf("wrong number of listeners: expected 1, got %d", len(lb.Listeners))
+	}
+
+	listener, ok := lb.Listeners[lbListenerName]
+	if !ok {
+		t.Fatalf("missing listener %q", lbListenerName)
+	}
+
+	if *listener.Port != lbListenerPort {
+		t.Errorf("wrong listener port: expected %d, got %d", lbListenerPort, *listener.Port)
+	}
+
+	if *listener.Protocol != lbListenerProtocol {
+		t.Errorf("wrong listener protocol: expected %q, got %q", lbListenerProtocol, *listener.Protocol)
+	}
+
+	if *listener.DefaultBackendSetName != lbBackendSetName {
+		t.Errorf("wrong listener backend set: expected %q, got %q", lbBackendSetName, *listener.DefaultBackendSetName)
+	}
+
+	if listener.ConnectionConfiguration == nil {
+		t.Errorf("missing connection configuration on listener %q", lbListenerName)
+	} else if *listener.ConnectionConfiguration.IdleTimeout != lbIdleTimeoutSeconds {
+		t.Errorf("wrong idle timeout: expected %d, got %d", lbIdleTimeoutSeconds, *listener.ConnectionConfiguration.IdleTimeout)
+	}
+
+	// backend set
+	backendSet, ok := lb.BackendSets[lbBackendSetName]
+	if !ok {
+		t.Fatalf("missing backend set %q", lbBackendSetName)
+	}
+
+	if *backendSet.Policy != lbPolicy {
+		t.Errorf("wrong lb policy: expected %q, got %q", lbPolicy, *backendSet.Policy)
+	}
+}
+
+// checkSessionPersistence verifies that requests with the session cookie stick to one backend and requests
+// without it are balanced. The cookie is issued by the LB (LB cookie persistence) or set by the backends
+// (application cookie persistence), the LB sticks to a backend only after it set the application cookie.
+func checkSessionPersistence(t *testing.T) {
+	lb := getLoadBalancer(t)
+	backendSet := lb.BackendSets[lbBackendSetName]
+
+	sessionCookies := []string{lbRouteCookie}
+	applicationCookie := ""
+	switch {
+	case backendSet.LbCookieSessionPersistenceConfiguration != nil:
+		if name := stringValue(backendSet.LbCookieSessionPersistenceConfiguration.CookieName); name != "" {
+			sessionCookies = []string{name}
+		}
+	case backendSet.SessionPersistenceConfiguration != nil:
+		applicationCookie = stringValue(backendSet.SessionPersistenceConfiguration.CookieName)
+		sessionCookies = append(sessionCookies, applicationCookie)
+	default:
+		t.Skipf("session persistence is not enabled on backend set %q", lbBackendSetName)
+	}
+	if len(backendSet.Backends) < 2 {
+		t.Skipf("session persistence needs at least 2 backends, got %d", len(backendSet.Backends))
+	}
+
+	url := "http://" + outputValues(t, "lb_ip")[0]
+
+	// with cookies: all requests land on the first backend
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	client := &http.Client{Jar: jar, Timeout: httpTimeout}
+
+	first, err := lbServerName(client, url)
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+
+	lbURL, err := neturl.Parse(url)
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+
+	names := []string{}
+	session := false
+	for _, cookie := range jar.Cookies(lbURL) {
+		names = append(names, cookie.Name)
+		session = session || containsString(sessionCookies, cookie.Name)
+	}
+	t.Logf("first request served by %q, cookies: %v", first, names)
+
+	if !session && applicationCookie != "" {
+		t.Skipf("backends do not set the application cookie %q, the LB does not persist sessions without it", applicationCookie)
+	}
+	if !session {
+		t.Fatalf("LB cookie persistence is enabled, but %s set none of the cookies %v", url, sessionCookies)
+	}
+
+	for i := 1; i < persistenceRequests; i++ {
+		actual, err := lbServerName(client, url)
+		if err != nil {
+			t.Fatalf("error occured: %s", err.Error())
+		}
+
+		if actual != first {
+			t.Fatalf("request #%d with session cookie: expected backend %q, got %q", i, first, actual)
+		}
+	}
+
+	// without cookies: requests are balanced again
+	client = &http.Client{Timeout: httpTimeout}
+	served := map[string]int{}
+	for i := 0; i < persistenceRequests; i++ {
+		actual, err := lbServerName(client, url)
+		if err != nil {
+			t.Fatalf("error occured: %s", err.Error())
+		}
+		served[actual]++
+	}
+
+	t.Logf("requests without cookie served by: %v", served)
+	if len(served) < 2 {
+		t.Fatalf("requests without session cookie were not rebalanced: %v", served)
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// lbServerName returns the backend hostname from the nginx demo page.
+func lbServerName(client *http.Client, url string) (string, error) {
+	return lbResponseLine(client, url, serverNamePrefix)
+}
+
+// lbServerAddress returns the backend ip:port from the nginx demo page, the name of the backend in the LB.
+func lbServerAddress(client *http.Client, url string) (string, error) {
+	return lbResponseLine(client, url, serverAddressPrefix)
+}
+
+// lbResponseLine returns the value of the line of the nginx demo page starting with prefix.
+func lbResponseLine(client *http.Client, url string, prefix string) (string, error) {
+	response, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(body), "\n") {
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimPrefix(line, prefix), nil
+		}
+	}
+	return "", fmt.Errorf("no %q in response from %s: %q", prefix, url, string(body))
+}
+
+func loadBalancerClient(t *testing.T) loadbalancer.LoadBalancerClient {
+	client, err := loadbalancer.NewLoadBalancerClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	throttle(&client.BaseClient)
+	return client
+}
+
+func getLoadBalancer(t *testing.T) loadbalancer.LoadBalancer {
+	client := loadBalancerClient(t)
+	lbID := terraform.Output(t, options, "lb_id")
+
+	response, err := client.GetLoadBalancer(context.Background(), loadbalancer.GetLoadBalancerRequest{
+		LoadBalancerId: &lbID,
+	})
+	if err != nil {
+		t.Fatalf("error in calling load balancer: %s", err.Error())
+	}
+
+	linkResource(t, "load balancer", lbID, "")
+	return response.LoadBalancer
+}
@@ -0,0 +1,111 @@
+package terratest
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	lockInitialBackoff = 10 * time.Second
+	lockMaxBackoff     = 2 * time.Minute
+)
+
+var (
+	stateLockedPattern = regexp.MustCompile(`Error (acquiring|locking) the state lock|Error locking state`)
+	lockInfoPattern    = regexp.MustCompile(`(?m)^\s*(ID|Path|Operation|Who|Version|Created):\s*(.*)$`)
+)
+
+// StateLock is the lock holder as reported by terraform.
+type StateLock struct {
+	ID        string
+	Path      string
+	Operation string
+	Who       string
+	Version   string
+	Created   string
+}
+
+func (l StateLock) String() string {
+	return fmt.Sprintf("%s by %s (terraform %s, created %s, lock ID %s, path %s)",
+		l.Operation, l.Who, l.Version, l.Created, l.ID, l.Path)
+}
+
+// withStateLock runs terraform command, on "state locked" errors it reports the lock holder and
+// waits with exponential backoff up to TF_LOCK_WAIT (e.g. 15m), without it the test fails immediately.
+func withStateLock(t *testing.T, description string, command func() (string, error)) string {
+	wait := lockWait(t)
+	deadline := time.Now().Add(wait)
+	backoff := lockInitialBackoff
+
+	for {
+		start := time.Now()
+		out, err := command()
+		profiler.Record(opTerraform, description, start)
+		if err == nil {
+			return out
+		}
+
+		lock, locked := parseStateLock(out + "\n" + err.Error())
+		if !locked {
+			t.Fatalf("%s failed: %s", description, err.Error())
+		}
+
+		if time.Now().Add(backoff).After(deadline) {
+			t.Fatalf("%s: state is locked by %s, gave up waiting after %s (TF_LOCK_WAIT)", description, lock, wait)
+		}
+
+		t.Logf("%s: state is locked by %s, retrying in %s", description, lock, backoff)
+		time.Sleep(backoff)
+
+		backoff *= 2
+		if backoff > lockMaxBackoff {
+			backoff = lockMaxBackoff
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// parseStateLock returns lock info from terraform output, false when the output is not a lock error.
+func parseStateLock(out string) (StateLock, bool) {
+	if !stateLockedPattern.MatchString(out) {
+		return StateLock{}, false
+	}
+
+	lock := StateLock{}
+	for _, match := range lockInfoPattern.FindAllStringSubmatch(out, -1) {
+		value := strings.TrimSpace(match[2])
+		switch match[1] {
+		case "ID":
+			lock.ID = value
+		case "Path":
+			lock.Path = value
+		case "Operation":
+			lock.Operation = value
+		case "Who":
+			lock.Who = value
+		case "Version":
+			lock.Version = value
+		case "Created":
+			lock.Created = value
+		}
+	}
+	return lock, true
+}
+
+func lockWait(t *testing.T) time.Duration {
+	value := os.Getenv("TF_LOCK_WAIT")
+	if value == "" {
+		return 0
+	}
+
+	wait, err := time.ParseDuration(value)
+	if err != nil {
+		t.Fatalf("wrong TF_LOCK_WAIT %q: %s", value, err.Error())
+	}
+	return wait
+}
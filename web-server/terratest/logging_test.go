@@ -0,0 +1,345 @@
+package terratest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/core"
+)
+
+const (
+	// log groups, COMPARTMENT_OCID_LOGGING or CompartmentOCID
+	loggingCompartment = "logging"
+	// the SDK has no logging package, the client calls the REST API of the service
+	loggingEndpointTemplate = "https://logging.{region}.oci.{secondLevelDomain}"
+	loggingManagementPath   = "/20200531"
+	loggingSearchPath       = "/20190909"
+	flowLogsService         = "flowlogs"
+	lbLogsService           = "loadbalancer"
+	flowLogsCategory        = "all"
+	lbAccessLogCategory     = "access"
+	// log entries are searchable a few minutes after the traffic
+	logIngestionTimeout = 10 * time.Minute
+)
+
+// LoggingExpectation is the log group of the stack and the service logs in it.
+type LoggingExpectation struct {
+	LogGroup string `json:"logGroup"`
+	// FlowLogs are required for all subnets of the VCN
+	FlowLogs bool `json:"flowLogs"`
+	// AccessLogs are required for the load balancer
+	AccessLogs bool `json:"accessLogs"`
+}
+
+// loggingClient is a minimal client of the Logging management and search API.
+type loggingClient struct {
+	common.BaseClient
+}
+
+type logGroupSummary struct {
+	Id             *string `json:"id"`
+	CompartmentId  *string `json:"compartmentId"`
+	DisplayName    *string `json:"displayName"`
+	LifecycleState string  `json:"lifecycleState"`
+}
+
+type logSummary struct {
+	Id             *string `json:"id"`
+	DisplayName    *string `json:"displayName"`
+	IsEnabled      *bool   `json:"isEnabled"`
+	LifecycleState string  `json:"lifecycleState"`
+	Configuration  *struct {
+		Source struct {
+			Service  *string `json:"service"`
+			Resource *string `json:"resource"`
+			Category *string `json:"category"`
+		} `json:"source"`
+	} `json:"configuration"`
+}
+
+type listLogGroupsRequest struct {
+	CompartmentId *string `mandatory:"true" contributesTo:"query" name:"compartmentId"`
+	DisplayName   *string `mandatory:"false" contributesTo:"query" name:"displayName"`
+}
+
+type listLogGroupsResponse struct {
+	RawResponse *http.Response
+	Items       []logGroupSummary `presentIn:"body"`
+}
+
+type listLogsRequest struct {
+	LogGroupId     *string `mandatory:"true" contributesTo:"path" name:"logGroupId"`
+	LogType        *string `mandatory:"false" contributesTo:"query" name:"logType"`
+	SourceService  *string `mandatory:"false" contributesTo:"query" name:"sourceService"`
+	SourceResource *string `mandatory:"false" contributesTo:"query" name:"sourceResource"`
+	Page           *string `mandatory:"false" contributesTo:"query" name:"page"`
+}
+
+type listLogsResponse struct {
+	RawResponse *http.Response
+	Items       []logSummary `presentIn:"body"`
+	OpcNextPage *string      `presentIn:"header" name:"opc-next-page"`
+}
+
+type searchLogsDetails struct {
+	TimeStart   *common.SDKTime `mandatory:"true" json:"timeStart"`
+	TimeEnd     *common.SDKTime `mandatory:"true" json:"timeEnd"`
+	SearchQuery *string         `mandatory:"true" json:"searchQuery"`
+}
+
+type searchLogsRequest struct {
+	SearchLogsDetails searchLogsDetails `contributesTo:"body"`
+	Page              *string           `mandatory:"false" contributesTo:"query" name:"page"`
+}
+
+type searchLogsResponse struct {
+	RawResponse    *http.Response
+	SearchResponse struct {
+		Results []struct {
+			Data json.RawMessage `json:"data"`
+		} `json:"results"`
+	} `presentIn:"body"`
+	OpcNextPage *string `presentIn:"header" name:"opc-next-page"`
+}
+
+func checkFlowLogs(t *testing.T) {
+	expected := loadExpectations(t).Logging
+	if expected == nil || !expected.FlowLogs {
+		t.Skip("no logging flowLogs in expectations")
+	}
+	client := newLoggingClient(t)
+	group := findLogGroup(t, client, expected.LogGroup)
+
+	vcnID := sanitizedVcnId(t)
+	compartmentID := compartmentFor(networkCompartment)
+	response, err := virtualNetworkClient(t).ListSubnets(context.Background(), core.ListSubnetsRequest{
+		CompartmentId: &compartmentID,
+		VcnId:         &vcnID,
+	})
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+
+	// assertions
+	for _, subnet := range response.Items {
+		log, err := client.serviceLog(context.Background(), *group.Id, flowLogsService, *subnet.Id, flowLogsCategory)
+		if err != nil {
+			t.Fatalf("error in listing logs of %s: %s", expected.LogGroup, err.Error())
+		}
+		if log == nil {
+			t.Errorf("subnet %s has no flow log in log group %s", stringValue(subnet.DisplayName), expected.LogGroup)
+			continue
+		}
+		assertLogEnabled(t, "flow log of subnet "+stringValue(subnet.DisplayName), *log)
+	}
+}
+
+func checkAccessLogs(t *testing.T) {
+	expected := loadExpectations(t).Logging
+	if expected == nil || !expected.AccessLogs {
+		t.Skip("no logging accessLogs in expectations")
+	}
+	client := newLoggingClient(t)
+	group := findLogGroup(t, client, expected.LogGroup)
+
+	log := lbAccessLog(t, client, group)
+	assertLogEnabled(t, "load balancer access log", log)
+}
+
+// checkAccessLogSearch sends a request with a unique query to the load balancer and searches the access log
+// until the request appears, enabled by RUN_LOG_SEARCH=1 as the ingestion takes minutes.
+func checkAccessLogSearch(t *testing.T) {
+	if os.Getenv("RUN_LOG_SEARCH") == "" {
+		t.Skip("log search is enabled by RUN_LOG_SEARCH=1")
+	}
+	expected := loadExpectations(t).Logging
+	if expected == nil || !expected.AccessLogs {
+		t.Skip("no logging accessLogs in expectations")
+	}
+	client := newLoggingClient(t)
+	group := findLogGroup(t, client, expected.LogGroup)
+	log := lbAccessLog(t, client, group)
+
+	token := "terratest-" + random.UniqueId()
+	start := time.Now()
+	url := fmt.Sprintf("http://%s/?%s", outputValues(t, "lb_ip")[0], token)
+	response, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("error in calling %s: %s", url, err.Error())
+	}
+	response.Body.Close()
+
+	query := fmt.Sprintf(`search "%s/%s/%s"`, *group.CompartmentId, *group.Id, *log.Id)
+	found := waitForLogEntry(t, client, query, start, func(entry string) bool {
+		return strings.Contains(entry, token)
+	})
+	t.Logf("request %s in access log after %s: %s", token, time.Since(start), found)
+	report.AddMetric("access log ingestion", time.Since(start).Round(time.Second))
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func newLoggingClient(t *testing.T) loggingClient {
+	return loggingClient{BaseClient: apiClient(t, loggingEndpointTemplate)}
+}
+
+// apiClient is a client of the service endpoint template in the region of the config, see callAPI.
+func apiClient(t *testing.T, endpointTemplate string) common.BaseClient {
+	provider := ociConfigProvider()
+	client, err := common.NewClientWithConfig(provider)
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	region, err := provider.Region()
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	client.Host = common.StringToRegion(region).EndpointForTemplate("", endpointTemplate)
+	throttle(&client)
+	return client
+}
+
+func (client loggingClient) call(ctx context.Context, method string, path string, request interface{}, response interface{}) error {
+	return callAPI(ctx, client.BaseClient, method, path, request, response)
+}
+
+// callAPI sends the tagged request struct and reads the tagged response struct like the SDK clients,
+// for API operations or fields the SDK does not have yet.
+func callAPI(ctx context.Context, client common.BaseClient, method string, path string, request interface{}, response interface{}) error {
+	httpRequest, err := common.MakeDefaultHTTPRequestWithTaggedStruct(method, path, request)
+	if err != nil {
+		return err
+	}
+	httpResponse, err := client.Call(ctx, &httpRequest)
+	defer common.CloseBodyIfValid(httpResponse)
+	if err != nil {
+		return err
+	}
+	return common.UnmarshalResponse(httpResponse, response)
+}
+
+// serviceLog returns the log of the service, resource and category in the log group, nil when there is none.
+func (client loggingClient) serviceLog(ctx context.Context, logGroupID string, service string, resource string, category string) (*logSummary, error) {
+	logType := "SERVICE"
+	request := listLogsRequest{LogGroupId: &logGroupID, LogType: &logType, SourceService: &service, SourceResource: &resource}
+	for {
+		var response listLogsResponse
+		if err := client.call(ctx, http.MethodGet, loggingManagementPath+"/logGroups/{logGroupId}/logs", request, &response); err != nil {
+			return nil, err
+		}
+		for _, log := range response.Items {
+			if log.Configuration == nil {
+				continue
+			}
+			source := log.Configuration.Source
+			if stringValue(source.Resource) == resource && stringValue(source.Category) == category {
+				return &log, nil
+			}
+		}
+
+		if response.OpcNextPage == nil {
+			return nil, nil
+		}
+		request.Page = response.OpcNextPage
+	}
+}
+
+// searchLogs returns the data of all log entries matching the query in the time window.
+func (client loggingClient) searchLogs(ctx context.Context, query string, start time.Time, end time.Time) ([]string, error) {
+	request := searchLogsRequest{SearchLogsDetails: searchLogsDetails{
+		TimeStart:   &common.SDKTime{Time: start},
+		TimeEnd:     &common.SDKTime{Time: end},
+		SearchQuery: &query,
+	}}
+
+	entries := []string{}
+	for {
+		var response searchLogsResponse
+		if err := client.call(ctx, http.MethodPost, loggingSearchPath+"/search", request, &response); err != nil {
+			return nil, err
+		}
+		for _, result := range response.SearchResponse.Results {
+			entries = append(entries, string(result.Data))
+		}
+
+		if response.OpcNextPage == nil {
+			return entries, nil
+		}
+		request.Page = response.OpcNextPage
+	}
+}
+
+// waitForLogEntry searches logs since start until an entry matches and returns it.
+func waitForLogEntry(t *testing.T, client loggingClient, query string, start time.Time, match func(entry string) bool) string {
+	ctx, cancel := context.WithTimeout(context.Background(), logIngestionTimeout)
+	defer cancel()
+
+	found := ""
+	err := pollUntil(ctx, "log entry of "+query, sleepBetweenRetries*3, func(ctx context.Context) (bool, error) {
+		// entries are timestamped by the service, allow for clock skew of the test host
+		entries, err := client.searchLogs(ctx, query, start.Add(-time.Minute), time.Now())
+		if err != nil {
+			return false, err
+		}
+		for _, entry := range entries {
+			if match(entry) {
+				found = entry
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return found
+}
+
+func findLogGroup(t *testing.T, client loggingClient, name string) logGroupSummary {
+	compartmentID := compartmentFor(loggingCompartment)
+	var response listLogGroupsResponse
+	err := client.call(context.Background(), http.MethodGet, loggingManagementPath+"/logGroups",
+		listLogGroupsRequest{CompartmentId: &compartmentID, DisplayName: &name}, &response)
+	if err != nil {
+		t.Fatalf("error in listing log groups: %s", err.Error())
+	}
+	if len(response.Items) == 0 {
+		t.Fatalf("log group %s not found in %s", name, compartmentID)
+	}
+	group := response.Items[0]
+	linkResource(t, "log group "+name, *group.Id, "")
+	if group.LifecycleState != "ACTIVE" {
+		t.Errorf("log group %s in state %s", name, group.LifecycleState)
+	}
+	return group
+}
+
+func lbAccessLog(t *testing.T, client loggingClient, group logGroupSummary) logSummary {
+	lbID := terraform.Output(t, options, "lb_id")
+	log, err := client.serviceLog(context.Background(), *group.Id, lbLogsService, lbID, lbAccessLogCategory)
+	if err != nil {
+		t.Fatalf("error in listing logs of %s: %s", stringValue(group.DisplayName), err.Error())
+	}
+	if log == nil {
+		t.Fatalf("load balancer %s has no access log in log group %s", lbID, stringValue(group.DisplayName))
+	}
+	return *log
+}
+
+func assertLogEnabled(t *testing.T, description string, log logSummary) {
+	if !boolValue(log.IsEnabled) {
+		t.Errorf("%s %s is disabled", description, stringValue(log.DisplayName))
+	}
+	if log.LifecycleState != "ACTIVE" {
+		t.Errorf("%s %s in state %s", description, stringValue(log.DisplayName), log.LifecycleState)
+	}
+}
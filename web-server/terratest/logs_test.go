@@ -0,0 +1,50 @@
+package terratest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/ssh"
+)
+
+var (
+	// logs collected from a host when a check against it fails
+	hostLogCommands = []struct {
+		name    string
+		command string
+	}{
+		{"journal nginx", "journalctl -u nginx --no-pager -n 200"},
+		{"nginx error.log", "tail -n 200 /var/log/nginx/error.log"},
+		{"cloud-init output", "tail -n 200 /var/log/cloud-init-output.log"},
+		{"cloud-init log", "tail -n 200 /var/log/cloud-init.log"},
+	}
+)
+
+// collectLogsOnFailure is meant to be deferred, it runs also after t.Fatal.
+// Host is resolved lazily, so that loops can point it to the host being checked.
+func collectLogsOnFailure(t *testing.T, host func() *ssh.Host) {
+	if !t.Failed() {
+		return
+	}
+	if h := host(); h != nil {
+		collectHostLogs(t, *h)
+	}
+}
+
+// collectHostLogs logs nginx and cloud-init logs of host and attaches them to the report.
+func collectHostLogs(t *testing.T, host ssh.Host) {
+	for _, log := range hostLogCommands {
+		result, err := RunRemoteE(t, host, log.command, RemoteOptions{Sudo: true, Timeout: sshCommandTimeout})
+
+		var content string
+		if err != nil {
+			content = fmt.Sprintf("could not collect %q: %s", log.command, err.Error())
+		} else {
+			content = result.Stdout + result.Stderr
+		}
+
+		title := fmt.Sprintf("%s %s: %s", t.Name(), host.Hostname, log.name)
+		t.Logf("~~~~~~~~ %s ~~~~~~~~\n%s", title, content)
+		report.AddAttachment(title, content)
+	}
+}
@@ -0,0 +1,225 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/core"
+)
+
+const (
+	// Gateways the stack creates in its VCN
+	expectedInternetGateways = 1
+	expectedNatGateways      = 1
+	expectedServiceGateways  = 0
+	expectedDrgAttachments   = 0
+	gatewayAvailableState    = "AVAILABLE"
+	// DHCP options of the VCN
+	expectedDnsServerType = core.DhcpDnsOptionServerTypeVcnlocalplusinternet
+	// egress
+	// private subnet routes 0.0.0.0/0 via NAT, so general internet is reachable
+	expectedInternetEgress = true
+	internetProbeURL       = "https://www.oracle.com"
+	egressTimeoutSeconds   = 10
+	unreachableHttpCode    = "000"
+)
+
+var (
+	// used only when expectedDnsServerType is CustomDnsServer
+	expectedCustomDnsServers = []string{}
+)
+
+func checkVcnGateways(t *testing.T) {
+	client := virtualNetworkClient(t)
+	compartmentID := compartmentFor(networkCompartment)
+	vcnID := sanitizedVcnId(t)
+
+	igws, err := client.ListInternetGateways(context.Background(), core.ListInternetGatewaysRequest{
+		CompartmentId: &compartmentID,
+		VcnId:         &vcnID,
+	})
+	if err != nil {
+		t.Fatalf("error in listing internet gateways: %s", err.Error())
+	}
+	states := []string{}
+	for _, gw := range igws.Items {
+		states = append(states, string(gw.LifecycleState))
+	}
+	assertGateways(t, "internet gateway", states, expectedInternetGateways)
+
+	nats, err := client.ListNatGateways(context.Background(), core.ListNatGatewaysRequest{
+		CompartmentId: &compartmentID,
+		VcnId:         &vcnID,
+	})
+	if err != nil {
+		t.Fatalf("error in listing NAT gateways: %s", err.Error())
+	}
+	states = []string{}
+	for _, gw := range nats.Items {
+		states = append(states, string(gw.LifecycleState))
+	}
+	assertGateways(t, "NAT gateway", states, expectedNatGateways)
+
+	sgws, err := client.ListServiceGateways(context.Background(), core.ListServiceGatewaysRequest{
+		CompartmentId: &compartmentID,
+		VcnId:         &vcnID,
+	})
+	if err != nil {
+		t.Fatalf("error in listing service gateways: %s", err.Error())
+	}
+	states = []string{}
+	for _, gw := range sgws.Items {
+		states = append(states, string(gw.LifecycleState))
+	}
+	assertGateways(t, "service gateway", states, expectedServiceGateways)
+
+	// DRGs are not VCN resources, they are bound to the VCN by attachments
+	drgs, err := client.ListDrgAttachments(context.Background(), core.ListDrgAttachmentsRequest{
+		CompartmentId: &compartmentID,
+		VcnId:         &vcnID,
+	})
+	if err != nil {
+		t.Fatalf("error in listing DRG attachments: %s", err.Error())
+	}
+	states = []string{}
+	for _, attachment := range drgs.Items {
+		states = append(states, string(attachment.LifecycleState))
+	}
+	assertGateways(t, "DRG attachment", states, expectedDrgAttachments)
+}
+
+// assertGateways fails when the count of gateways differs from expected
+// or when any of them is not AVAILABLE (e.g. still provisioning or terminated).
+func assertGateways(t *testing.T, kind string, states []string, expected int) {
+	t.Logf("%s count: %d, states: %v", kind, len(states), states)
+
+	if len(states) != expected {
+		t.Errorf("wrong number of %ss: expected %d, got %d", kind, expected, len(states))
+	}
+
+	for i, state := range states {
+		if state != gatewayAvailableState {
+			t.Errorf("%s #%d in wrong state: expected %q, got %q", kind, i, gatewayAvailableState, state)
+		}
+	}
+}
+
+func checkDhcpOptions(t *testing.T) {
+	client := virtualNetworkClient(t)
+	vcnID := sanitizedVcnId(t)
+
+	vcn, err := client.GetVcn(context.Background(), core.GetVcnRequest{VcnId: &vcnID})
+	if err != nil {
+		t.Fatalf("error in calling vcn: %s", err.Error())
+	}
+
+	response, err := client.GetDhcpOptions(context.Background(), core.GetDhcpOptionsRequest{
+		DhcpId: vcn.Vcn.DefaultDhcpOptionsId,
+	})
+	if err != nil {
+		t.Fatalf("error in calling dhcp options: %s", err.Error())
+	}
+
+	dnsOptions := []core.DhcpDnsOption{}
+	for _, option := range response.DhcpOptions.Options {
+		if dns, ok := option.(core.DhcpDnsOption); ok {
+			dnsOptions = append(dnsOptions, dns)
+		}
+	}
+
+	// assertions
+	if len(dnsOptions) != 1 {
+		t.Fatalf("wrong number of DNS options: expected 1, got %d", len(dnsOptions))
+	}
+
+	actual := dnsOptions[0].ServerType
+	if actual != expectedDnsServerType {
+		t.Fatalf("wrong DNS server type: expected %q, got %q", expectedDnsServerType, actual)
+	}
+
+	if expectedDnsServerType == core.DhcpDnsOptionServerTypeCustomdnsserver {
+		servers := fmt.Sprint(dnsOptions[0].CustomDnsServers)
+		if servers != fmt.Sprint(expectedCustomDnsServers) {
+			t.Fatalf("wrong custom DNS servers: expected %v, got %s", expectedCustomDnsServers, servers)
+		}
+	}
+}
+
+func checkWebDnsResolution(t *testing.T) {
+	hostnames := outputValues(t, "WebServerHostNames")
+	domain := outputValues(t, "WebServerDomain")[0]
+	ips := outputValues(t, "WebServerPrivateIPs")
+	objectStorage := fmt.Sprintf("objectstorage.%s.oraclecloud.com", stringVar("region", ""))
+
+	for _, host := range webHosts(t) {
+		for i, hostname := range hostnames {
+			fqdn := hostname + "." + domain
+			out := jumpSshHost(t, host, resolve(fqdn))
+
+			if out != ips[i] {
+				t.Errorf("%s resolved %s: expected %q, got %q", host.Hostname, fqdn, ips[i], out)
+			}
+		}
+
+		out := jumpSshHost(t, host, resolve(objectStorage))
+		if out == "" {
+			t.Errorf("%s could not resolve %s", host.Hostname, objectStorage)
+		}
+	}
+}
+
+func checkWebEgress(t *testing.T) {
+	region := stringVar("region", "")
+	// Oracle services have to be reachable via service gateway (or NAT)
+	serviceURLs := []string{
+		fmt.Sprintf("https://objectstorage.%s.oraclecloud.com", region),
+		fmt.Sprintf("https://yum.%s.oci.oraclecloud.com", region),
+	}
+
+	for _, host := range webHosts(t) {
+		for _, url := range serviceURLs {
+			code := jumpSshHost(t, host, egressCurl(url))
+			if code == unreachableHttpCode {
+				t.Errorf("%s cannot reach %s", host.Hostname, url)
+			}
+		}
+
+		code := jumpSshHost(t, host, egressCurl(internetProbeURL))
+		reachable := code != unreachableHttpCode
+		if reachable != expectedInternetEgress {
+			t.Errorf("%s internet egress to %s: expected reachable %t, got %t (http code %s)",
+				host.Hostname, internetProbeURL, expectedInternetEgress, reachable, code)
+		}
+	}
+}
+
+// egressCurl prints the http code, "000" means the url could not be reached at all.
+// Curl failure is not a command failure here, otherwise the ssh call would be retried.
+func egressCurl(url string) string {
+	return fmt.Sprintf("curl -s -o /dev/null -m %d -w '%%{http_code}' %s || true", egressTimeoutSeconds, url)
+}
+
+func resolve(name string) string {
+	return fmt.Sprintf("getent hosts %s | awk '{print $1}' | head -1", name)
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func computeClient(t *testing.T) core.ComputeClient {
+	client, err := core.NewComputeClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	throttle(&client.BaseClient)
+	return client
+}
+
+func virtualNetworkClient(t *testing.T) core.VirtualNetworkClient {
+	client, err := core.NewVirtualNetworkClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	throttle(&client.BaseClient)
+	return client
+}
@@ -0,0 +1,218 @@
+package terratest
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/oracle/oci-go-sdk/ons"
+)
+
+const (
+	defaultWebhookListen = ":8089"
+	// header of the subscription confirmation request of ONS
+	confirmationURLHeader = "X-OCI-NS-ConfirmationURL"
+	deliveryTimeout       = 5 * time.Minute
+)
+
+// NotificationsExpectation is the notification topic of the stack and its subscriptions.
+type NotificationsExpectation struct {
+	Topic         string                    `json:"topic"`
+	Subscriptions []SubscriptionExpectation `json:"subscriptions"`
+}
+
+// SubscriptionExpectation is an ACTIVE subscription of the topic.
+type SubscriptionExpectation struct {
+	// Protocol as in the API, e.g. EMAIL, SLACK or CUSTOM_HTTPS
+	Protocol string `json:"protocol"`
+	Endpoint string `json:"endpoint"`
+}
+
+// webhookCatcher receives ONS deliveries, it confirms the subscription and passes message bodies on.
+type webhookCatcher struct {
+	server   *http.Server
+	messages chan string
+}
+
+func checkNotifications(t *testing.T) {
+	expected := loadExpectations(t).Notifications
+	if expected == nil {
+		t.Skip("no notifications in expectations")
+	}
+	topic := findTopic(t, expected.Topic)
+	linkResource(t, "topic "+expected.Topic, *topic.TopicId, "")
+
+	// assertions
+	if topic.LifecycleState != ons.NotificationTopicSummaryLifecycleStateActive {
+		t.Errorf("topic %s in state %s", expected.Topic, topic.LifecycleState)
+	}
+
+	subscriptions := topicSubscriptions(t, topic)
+	for _, subscription := range expected.Subscriptions {
+		found := false
+		for _, actual := range subscriptions {
+			if !strings.EqualFold(stringValue(actual.Protocol), subscription.Protocol) || stringValue(actual.Endpoint) != subscription.Endpoint {
+				continue
+			}
+			found = true
+			if actual.LifecycleState != ons.SubscriptionSummaryLifecycleStateActive {
+				t.Errorf("subscription %s %s of topic %s in state %s", subscription.Protocol, subscription.Endpoint, expected.Topic, actual.LifecycleState)
+			}
+		}
+		if !found {
+			t.Errorf("missing subscription %s %s of topic %s", subscription.Protocol, subscription.Endpoint, expected.Topic)
+		}
+	}
+}
+
+// checkNotificationDelivery subscribes a webhook catcher started by the test to the topic, publishes a message
+// and waits for its delivery. NOTIFICATION_WEBHOOK_URL is the public HTTPS URL forwarded to NOTIFICATION_WEBHOOK_LISTEN.
+func checkNotificationDelivery(t *testing.T) {
+	expected := loadExpectations(t).Notifications
+	if expected == nil {
+		t.Skip("no notifications in expectations")
+	}
+	publicURL := os.Getenv("NOTIFICATION_WEBHOOK_URL")
+	if publicURL == "" {
+		t.Skip("delivery test is enabled by NOTIFICATION_WEBHOOK_URL=<public https url of the catcher>")
+	}
+	topic := findTopic(t, expected.Topic)
+
+	catcher := startWebhookCatcher(t, webhookListen())
+	defer catcher.Close()
+
+	client := notificationDataPlaneClient(t)
+	protocol := "CUSTOM_HTTPS"
+	subscription, err := client.CreateSubscription(context.Background(), ons.CreateSubscriptionRequest{
+		CreateSubscriptionDetails: ons.CreateSubscriptionDetails{
+			TopicId:       topic.TopicId,
+			CompartmentId: topic.CompartmentId,
+			Protocol:      &protocol,
+			Endpoint:      &publicURL,
+		},
+	})
+	if err != nil {
+		t.Fatalf("error in subscribing %s to topic %s: %s", publicURL, expected.Topic, err.Error())
+	}
+	defer func() {
+		if _, err := client.DeleteSubscription(context.Background(), ons.DeleteSubscriptionRequest{SubscriptionId: subscription.Id}); err != nil {
+			t.Errorf("error in deleting subscription %s: %s", *subscription.Id, err.Error())
+		}
+	}()
+
+	// the subscription is confirmed by the catcher, messages are delivered once it is ACTIVE
+	waitForSubscriptionActive(t, client, *subscription.Id)
+
+	token := "terratest-" + random.UniqueId()
+	title := "terratest delivery check"
+	if _, err := client.PublishMessage(context.Background(), ons.PublishMessageRequest{
+		TopicId:        topic.TopicId,
+		MessageDetails: ons.MessageDetails{Title: &title, Body: &token},
+	}); err != nil {
+		t.Fatalf("error in publishing to topic %s: %s", expected.Topic, err.Error())
+	}
+
+	start := time.Now()
+	deadline := time.After(deliveryTimeout)
+	for {
+		select {
+		case body := <-catcher.messages:
+			if strings.Contains(body, token) {
+				t.Logf("message delivered to %s after %s", publicURL, time.Since(start))
+				report.AddMetric("notification delivery", time.Since(start).Round(time.Second))
+				return
+			}
+		case <-deadline:
+			t.Fatalf("message %s published to topic %s not delivered to %s within %s", token, expected.Topic, publicURL, deliveryTimeout)
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func notificationDataPlaneClient(t *testing.T) ons.NotificationDataPlaneClient {
+	client, err := ons.NewNotificationDataPlaneClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	throttle(&client.BaseClient)
+	return client
+}
+
+func topicSubscriptions(t *testing.T, topic ons.NotificationTopicSummary) []ons.SubscriptionSummary {
+	client := notificationDataPlaneClient(t)
+	request := ons.ListSubscriptionsRequest{CompartmentId: topic.CompartmentId, TopicId: topic.TopicId}
+
+	subscriptions := []ons.SubscriptionSummary{}
+	for {
+		response, err := client.ListSubscriptions(context.Background(), request)
+		if err != nil {
+			t.Fatalf("error in listing subscriptions: %s", err.Error())
+		}
+		subscriptions = append(subscriptions, response.Items...)
+
+		if response.OpcNextPage == nil {
+			return subscriptions
+		}
+		request.Page = response.OpcNextPage
+	}
+}
+
+func waitForSubscriptionActive(t *testing.T, client ons.NotificationDataPlaneClient, id string) {
+	WaitForState(t, "subscription "+id, func(ctx context.Context) (string, error) {
+		response, err := client.GetSubscription(ctx, ons.GetSubscriptionRequest{SubscriptionId: &id})
+		return string(response.Subscription.LifecycleState), err
+	}, string(ons.SubscriptionLifecycleStateActive), deliveryTimeout)
+}
+
+func webhookListen() string {
+	if listen := os.Getenv("NOTIFICATION_WEBHOOK_LISTEN"); listen != "" {
+		return listen
+	}
+	return defaultWebhookListen
+}
+
+func startWebhookCatcher(t *testing.T, listen string) *webhookCatcher {
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		t.Fatalf("error in starting webhook catcher on %s: %s", listen, err.Error())
+	}
+
+	catcher := &webhookCatcher{messages: make(chan string, 16)}
+	catcher.server = &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if confirmationURL := r.Header.Get(confirmationURLHeader); confirmationURL != "" {
+			response, err := http.Get(confirmationURL)
+			if err != nil {
+				t.Logf("error in confirming subscription: %s", err.Error())
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			response.Body.Close()
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		select {
+		case catcher.messages <- string(body):
+		default:
+		}
+	})}
+
+	go catcher.server.Serve(listener)
+	return catcher
+}
+
+// Close stops the catcher.
+func (c *webhookCatcher) Close() {
+	c.server.Close()
+}
@@ -0,0 +1,201 @@
+package terratest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rsa"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/common"
+)
+
+const (
+	defaultOciProfile = "DEFAULT"
+	ociCliEnvPrefix   = "OCI_CLI_"
+)
+
+var (
+	// values of the selected profile of the OCI config file by key, DEFAULT values included
+	ociProfileValues = map[string]string{}
+)
+
+// ociConfigFile is the OCI CLI config file, OCI_CLI_CONFIG_FILE or ~/.oci/config.
+func ociConfigFile() string {
+	if file := os.Getenv("OCI_CLI_CONFIG_FILE"); file != "" {
+		return expandHome(file)
+	}
+	return expandHome("~/.oci/config")
+}
+
+// ociProfile is the profile of the OCI config file, OCI_PROFILE or OCI_CLI_PROFILE, DEFAULT otherwise.
+func ociProfile() string {
+	for _, env := range []string{"OCI_PROFILE", "OCI_CLI_PROFILE"} {
+		if profile := os.Getenv(env); profile != "" {
+			return profile
+		}
+	}
+	return defaultOciProfile
+}
+
+// loadOciProfile reads the selected profile, a missing config file is fine unless a profile is chosen.
+func loadOciProfile(t *testing.T) {
+	ociProfileValues = map[string]string{}
+	content, err := ioutil.ReadFile(ociConfigFile())
+	if os.IsNotExist(err) && ociProfile() == defaultOciProfile {
+		return
+	}
+	if err != nil {
+		t.Fatalf("error in reading OCI config: %s", err.Error())
+	}
+
+	values, ok := parseOciConfig(content, ociProfile())
+	if !ok {
+		t.Fatalf("profile %s not found in %s", ociProfile(), ociConfigFile())
+	}
+	ociProfileValues = values
+}
+
+// parseOciConfig returns values of the profile in the ini-like OCI config file,
+// keys missing in the profile are inherited from DEFAULT like the OCI CLI does.
+func parseOciConfig(content []byte, profile string) (map[string]string, bool) {
+	sections := map[string]map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+
+	section := ""
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if sections[section] == nil {
+				sections[section] = map[string]string{}
+			}
+			continue
+		}
+		pair := strings.SplitN(line, "=", 2)
+		if len(pair) == 2 && section != "" {
+			sections[section][strings.TrimSpace(pair[0])] = strings.TrimSpace(pair[1])
+		}
+	}
+
+	selected, ok := sections[profile]
+	if !ok {
+		return nil, false
+	}
+	values := map[string]string{}
+	for key, value := range sections[defaultOciProfile] {
+		values[key] = value
+	}
+	for key, value := range selected {
+		values[key] = value
+	}
+	return values, true
+}
+
+// applyOciConfig sets empty fields with an oci tag from OCI_CLI_<KEY> env vars, then from the OCI config profile.
+func (c *Config) applyOciConfig() {
+	value := reflect.ValueOf(c).Elem()
+
+	for i := 0; i < value.NumField(); i++ {
+		key := value.Type().Field(i).Tag.Get("oci")
+		if key == "" || value.Field(i).String() != "" {
+			continue
+		}
+		if fromEnv := os.Getenv(ociCliEnvPrefix + strings.ToUpper(key)); fromEnv != "" {
+			value.Field(i).SetString(expandHome(fromEnv))
+		} else if fromFile, ok := ociProfileValues[key]; ok {
+			value.Field(i).SetString(expandHome(fromFile))
+		}
+	}
+}
+
+// ociConfigProvider returns the SDK configuration of the run, the same credentials as passed to terraform.
+// Before the configuration is loaded, the SDK default (~/.oci/config DEFAULT and TF_VAR_*) is used.
+func ociConfigProvider() common.ConfigurationProvider {
+	if config == nil {
+		return common.DefaultConfigProvider()
+	}
+	if auditing && auditorConfig != nil {
+		return configProvider{config: auditorConfig, profile: auditorProfileValues}
+	}
+	return configProvider{config: config, profile: ociProfileValues, securityToken: securityTokenEnabled()}
+}
+
+// configProvider is the common.ConfigurationProvider of the Config.
+type configProvider struct {
+	config *Config
+	// values of the OCI config profile of the credentials, e.g. the key pass_phrase
+	profile       map[string]string
+	securityToken bool
+}
+
+func (p configProvider) TenancyOCID() (string, error) {
+	return p.config.TenancyOCID, nil
+}
+
+func (p configProvider) UserOCID() (string, error) {
+	return p.config.UserOCID, nil
+}
+
+func (p configProvider) KeyFingerprint() (string, error) {
+	return p.config.Fingerprint, nil
+}
+
+func (p configProvider) Region() (string, error) {
+	return p.config.Region, nil
+}
+
+// KeyID is the session token for security token auth, "ST$" is the SDK prefix of token key IDs.
+func (p configProvider) KeyID() (string, error) {
+	if p.securityToken {
+		token, err := readSecurityToken()
+		if err != nil {
+			return "", err
+		}
+		return "ST$" + token, nil
+	}
+	return fmt.Sprintf("%s/%s/%s", p.config.TenancyOCID, p.config.UserOCID, p.config.Fingerprint), nil
+}
+
+func (p configProvider) PrivateRSAKey() (*rsa.PrivateKey, error) {
+	pem, err := ioutil.ReadFile(p.config.PrivateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var passphrase *string
+	if value, ok := keyPassphrase(p.profile); ok {
+		passphrase = &value
+	}
+	return common.PrivateKeyFromBytes(pem, passphrase)
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// keyPassphrase returns the passphrase of the API key from OCI_CLI_PASSPHRASE or the profile.
+func keyPassphrase(profile map[string]string) (string, bool) {
+	if value := os.Getenv("OCI_CLI_PASSPHRASE"); value != "" {
+		return value, true
+	}
+	value, ok := profile["pass_phrase"]
+	return value, ok
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
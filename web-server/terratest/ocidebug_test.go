@@ -0,0 +1,78 @@
+package terratest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/common"
+)
+
+var (
+	// debug lines of parallel requests are not interleaved
+	ociDebugMu sync.Mutex
+)
+
+// OciRequestEntry is one SDK request logged with OCI_DEBUG=1, the opc-request-id identifies it for Oracle support.
+type OciRequestEntry struct {
+	Time       time.Time `json:"time"`
+	Service    string    `json:"service"`
+	Operation  string    `json:"operation"`
+	RequestID  string    `json:"opcRequestId,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+	Status     int       `json:"status,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// ociDebugEnabled is true with OCI_DEBUG=1, every SDK request is logged as a JSON line to stderr.
+func ociDebugEnabled() bool {
+	return os.Getenv("OCI_DEBUG") != ""
+}
+
+// debugDispatcher logs the requests of a client, every retry of a throttled request too.
+type debugDispatcher struct {
+	next common.HTTPRequestDispatcher
+}
+
+func (d debugDispatcher) Do(request *http.Request) (*http.Response, error) {
+	start := time.Now()
+	response, err := d.next.Do(request)
+
+	entry := OciRequestEntry{
+		Time:       start.UTC(),
+		Service:    strings.Split(request.URL.Hostname(), ".")[0],
+		Operation:  fmt.Sprintf("%s %s", request.Method, request.URL.Path),
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if response != nil {
+		entry.Status = response.StatusCode
+		entry.RequestID = response.Header.Get("opc-request-id")
+	}
+	if entry.RequestID == "" {
+		entry.RequestID = request.Header.Get("opc-request-id")
+	}
+	if err != nil {
+		entry.Error = redact(err.Error())
+	}
+	logOciRequest(entry)
+
+	return response, err
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func logOciRequest(entry OciRequestEntry) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error in logging OCI request: %s\n", err.Error())
+		return
+	}
+
+	ociDebugMu.Lock()
+	defer ociDebugMu.Unlock()
+	fmt.Fprintln(os.Stderr, string(line))
+}
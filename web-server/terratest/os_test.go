@@ -0,0 +1,55 @@
+package terratest
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	osReleaseCommand = ". /etc/os-release && echo \"$ID $VERSION_ID\""
+	kernelCommand    = "uname -r"
+)
+
+// OsExpectation is the OS of all instances, it changes only with InstanceImageOCID.
+type OsExpectation struct {
+	// ID of /etc/os-release, e.g. ol
+	ID           string `json:"id"`
+	MajorVersion string `json:"majorVersion"`
+	// MinKernel is not checked when empty
+	MinKernel string `json:"minKernel,omitempty"`
+}
+
+func checkOsVersion(t *testing.T) {
+	expected := loadExpectations(t).Os
+	if expected.ID == "" {
+		t.Skip("no os in expectations")
+	}
+
+	for tier := range tierOutputs {
+		for _, host := range tierHosts(t, tier) {
+			release := RunRemote(t, host, osReleaseCommand, RemoteOptions{Timeout: sshCommandTimeout})
+			kernel := RunRemote(t, host, kernelCommand, RemoteOptions{Timeout: sshCommandTimeout})
+			if release.ExitCode != 0 || kernel.ExitCode != 0 {
+				t.Fatalf("%s %s: error in reading os version: %s %s", tier, host.Hostname, release.Stderr, kernel.Stderr)
+			}
+
+			fields := strings.Fields(release.Stdout)
+			if len(fields) != 2 {
+				t.Fatalf("%s %s: unexpected os release %q", tier, host.Hostname, release.Stdout)
+			}
+			id, version := fields[0], fields[1]
+			major := strings.SplitN(version, ".", 2)[0]
+			kernelVersion := strings.TrimSpace(kernel.Stdout)
+			t.Logf("%s %s: %s %s, kernel %s", tier, host.Hostname, id, version, kernelVersion)
+
+			// assertions
+			if id != expected.ID || major != expected.MajorVersion {
+				t.Errorf("%s %s: wrong os: expected %s %s, got %s %s (check InstanceImageOCID)",
+					tier, host.Hostname, expected.ID, expected.MajorVersion, id, version)
+			}
+			if expected.MinKernel != "" && compareVersions(kernelVersion, expected.MinKernel) < 0 {
+				t.Errorf("%s %s: kernel %s is older than %s", tier, host.Hostname, kernelVersion, expected.MinKernel)
+			}
+		}
+	}
+}
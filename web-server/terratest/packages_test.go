@@ -0,0 +1,159 @@
+package terratest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/ssh"
+)
+
+const (
+	// updateinfo downloads repository metadata first
+	updateinfoTimeout  = 5 * time.Minute
+	advisoryDateLayout = "2006-01-02"
+	cutoffDateLayout   = "2006-01-02"
+)
+
+// PackageExpectation is an installed package of a tier.
+type PackageExpectation struct {
+	Name string `json:"name"`
+	// version range of the package, inclusive, empty bound is not checked
+	MinVersion string `json:"minVersion,omitempty"`
+	MaxVersion string `json:"maxVersion,omitempty"`
+	// Service is the expected `systemctl is-active` state of the package service, e.g. active or inactive
+	Service string `json:"service,omitempty"`
+}
+
+func checkPackages(t *testing.T) {
+	expected := loadExpectations(t)
+	if len(expected.Packages) == 0 && expected.SecurityUpdatesCutoff == "" {
+		t.Skip("no packages in expectations")
+	}
+
+	for tier, packages := range expected.Packages {
+		for _, host := range tierHosts(t, tier) {
+			for _, pkg := range packages {
+				assertPackage(t, tier, host, pkg)
+			}
+		}
+	}
+
+	if expected.SecurityUpdatesCutoff == "" {
+		return
+	}
+	cutoff, err := time.Parse(cutoffDateLayout, expected.SecurityUpdatesCutoff)
+	if err != nil {
+		t.Fatalf("wrong securityUpdatesCutoff %q: %s", expected.SecurityUpdatesCutoff, err.Error())
+	}
+	for tier := range tierOutputs {
+		for _, host := range tierHosts(t, tier) {
+			assertSecurityUpdates(t, tier, host, cutoff)
+		}
+	}
+}
+
+// assertPackage checks version and service state of the package on host.
+func assertPackage(t *testing.T, tier string, host ssh.Host, pkg PackageExpectation) {
+	command := fmt.Sprintf("rpm -q --qf '%%{VERSION}' %s", shellQuote(pkg.Name))
+	result := RunRemote(t, host, command, RemoteOptions{Timeout: sshCommandTimeout})
+	if result.ExitCode != 0 {
+		t.Errorf("%s %s: package %s is not installed: %s", tier, host.Hostname, pkg.Name, strings.TrimSpace(result.Stdout))
+		return
+	}
+
+	version := strings.TrimSpace(result.Stdout)
+	t.Logf("%s %s: %s %s", tier, host.Hostname, pkg.Name, version)
+	if pkg.MinVersion != "" && compareVersions(version, pkg.MinVersion) < 0 {
+		t.Errorf("%s %s: %s %s is older than %s", tier, host.Hostname, pkg.Name, version, pkg.MinVersion)
+	}
+	if pkg.MaxVersion != "" && compareVersions(version, pkg.MaxVersion) > 0 {
+		t.Errorf("%s %s: %s %s is newer than %s", tier, host.Hostname, pkg.Name, version, pkg.MaxVersion)
+	}
+
+	if pkg.Service == "" {
+		return
+	}
+	// is-active exits non-zero for any state but active
+	result = RunRemote(t, host, "systemctl is-active "+shellQuote(pkg.Name), RemoteOptions{Timeout: sshCommandTimeout})
+	state := strings.TrimSpace(result.Stdout)
+	if state != pkg.Service {
+		t.Errorf("%s %s: wrong %s service state: expected %q, got %q", tier, host.Hostname, pkg.Name, pkg.Service, state)
+	}
+}
+
+// assertSecurityUpdates fails when a security advisory issued before cutoff is still not applied.
+func assertSecurityUpdates(t *testing.T, tier string, host ssh.Host, cutoff time.Time) {
+	command := "yum -q updateinfo info security | awk '/Update ID/ {id=$4} /Issued/ {print id, $3}'"
+	result := RunRemote(t, host, command, RemoteOptions{Sudo: true, Timeout: updateinfoTimeout})
+	if result.ExitCode != 0 {
+		t.Fatalf("%s %s: error in listing security updates: %s", tier, host.Hostname, result.Stderr)
+	}
+
+	pending := 0
+	for _, line := range strings.Split(strings.TrimSpace(result.Stdout), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		issued, err := time.Parse(advisoryDateLayout, fields[1])
+		if err != nil {
+			continue
+		}
+		pending++
+		if issued.Before(cutoff) {
+			t.Errorf("%s %s: security advisory %s issued %s is not applied", tier, host.Hostname, fields[0], fields[1])
+		}
+	}
+	t.Logf("%s %s: %d pending security advisories", tier, host.Hostname, pending)
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// tierHosts returns ssh hosts of all instances of the tier.
+func tierHosts(t *testing.T, tier string) []ssh.Host {
+	switch tier {
+	case "web":
+		return webHosts(t)
+	case "bastion":
+		hosts := []ssh.Host{}
+		for _, ip := range outputValues(t, "BastionPublicIP") {
+			hosts = append(hosts, sshHost(t, ip))
+		}
+		return hosts
+	}
+	t.Fatalf("unknown tier %q in expectations", tier)
+	return nil
+}
+
+// compareVersions compares dot separated versions segment by segment, numerically when both are numbers.
+func compareVersions(a string, b string) int {
+	split := func(version string) []string {
+		return strings.FieldsFunc(version, func(r rune) bool { return r == '.' || r == '-' || r == '_' })
+	}
+	as, bs := split(a), split(b)
+
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		if i >= len(as) {
+			return -1
+		}
+		if i >= len(bs) {
+			return 1
+		}
+
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil && an != bn:
+			if an < bn {
+				return -1
+			}
+			return 1
+		case (aErr != nil || bErr != nil) && as[i] != bs[i]:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	return 0
+}
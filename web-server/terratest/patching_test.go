@@ -0,0 +1,86 @@
+package terratest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/osmanagement"
+)
+
+// PatchingExpectation is the patching posture of the instances managed by OS Management.
+type PatchingExpectation struct {
+	// MaxSecurityUpdates is the max count of pending security updates per instance
+	MaxSecurityUpdates int `json:"maxSecurityUpdates"`
+}
+
+func checkPatchingPosture(t *testing.T) {
+	expected := loadExpectations(t).Patching
+	if expected == nil {
+		t.Skip("no patching in expectations")
+	}
+	client := osManagementClient(t)
+
+	for tier, output := range tierOutputs {
+		for _, instanceID := range outputValues(t, output) {
+			// the managed instance has the OCID of the instance
+			id := instanceID
+			response, err := client.GetManagedInstance(context.Background(), osmanagement.GetManagedInstanceRequest{ManagedInstanceId: &id})
+			if err != nil {
+				t.Errorf("%s instance %s is not managed by OS Management: %s", tier, instanceID, err.Error())
+				continue
+			}
+			instance := response.ManagedInstance
+			if instance.Status != osmanagement.ManagedInstanceStatusNormal {
+				t.Errorf("%s managed instance %s in status %s, last checkin %s",
+					tier, stringValue(instance.DisplayName), instance.Status, stringValue(instance.LastCheckin))
+			}
+
+			security, err := pendingSecurityUpdates(client, id)
+			if err != nil {
+				t.Fatalf("error in listing updates of %s: %s", instanceID, err.Error())
+			}
+			t.Logf("%s %s: %d pending security updates of %d updates", tier, stringValue(instance.DisplayName), len(security), intValue(instance.UpdatesAvailable))
+			report.AddMetric("pending security updates "+stringValue(instance.DisplayName), len(security))
+
+			// assertions
+			if len(security) > expected.MaxSecurityUpdates {
+				t.Errorf("%s %s: %d pending security updates exceed %d: %v",
+					tier, stringValue(instance.DisplayName), len(security), expected.MaxSecurityUpdates, security)
+			}
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func osManagementClient(t *testing.T) osmanagement.OsManagementClient {
+	client, err := osmanagement.NewOsManagementClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	throttle(&client.BaseClient)
+	return client
+}
+
+// pendingSecurityUpdates returns names of the packages with a pending security update.
+func pendingSecurityUpdates(client osmanagement.OsManagementClient, managedInstanceID string) ([]string, error) {
+	request := osmanagement.ListAvailableUpdatesForManagedInstanceRequest{ManagedInstanceId: &managedInstanceID}
+
+	names := []string{}
+	for {
+		response, err := client.ListAvailableUpdatesForManagedInstance(context.Background(), request)
+		if err != nil {
+			return nil, err
+		}
+		for _, update := range response.Items {
+			if update.UpdateType == osmanagement.UpdateTypesSecurity {
+				names = append(names, stringValue(update.Name))
+			}
+		}
+
+		if response.OpcNextPage == nil {
+			return names, nil
+		}
+		request.Page = response.OpcNextPage
+	}
+}
@@ -0,0 +1,125 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/core"
+)
+
+const (
+	computePath = "/20160918"
+)
+
+// PlacementExpectation is the capacity the instances of a tier are launched against,
+// so they do not silently fall back to on-demand capacity.
+type PlacementExpectation struct {
+	DedicatedVmHostID     string `json:"dedicatedVmHostId"`
+	CapacityReservationID string `json:"capacityReservationId"`
+}
+
+type getInstanceRequest struct {
+	InstanceId *string `mandatory:"true" contributesTo:"path" name:"instanceId"`
+}
+
+// instancePlacement has the capacity reservation of the instance the SDK does not have yet.
+type instancePlacement struct {
+	RawResponse *http.Response
+	Instance    struct {
+		DisplayName           *string `json:"displayName"`
+		DedicatedVmHostId     *string `json:"dedicatedVmHostId"`
+		CapacityReservationId *string `json:"capacityReservationId"`
+	} `presentIn:"body"`
+}
+
+type getCapacityReservationRequest struct {
+	CapacityReservationId *string `mandatory:"true" contributesTo:"path" name:"capacityReservationId"`
+}
+
+type getCapacityReservationResponse struct {
+	RawResponse         *http.Response
+	CapacityReservation struct {
+		DisplayName           *string `json:"displayName"`
+		LifecycleState        string  `json:"lifecycleState"`
+		ReservedInstanceCount *int64  `json:"reservedInstanceCount"`
+		UsedInstanceCount     *int64  `json:"usedInstanceCount"`
+	} `presentIn:"body"`
+}
+
+func checkInstancePlacement(t *testing.T) {
+	expected := loadExpectations(t).Placement
+	if len(expected) == 0 {
+		t.Skip("no placement in expectations")
+	}
+	compute := computeClient(t)
+
+	for tier, placement := range expected {
+		for _, instanceID := range outputValues(t, tierOutputs[tier]) {
+			id := instanceID
+			var actual instancePlacement
+			if err := callAPI(context.Background(), compute.BaseClient, http.MethodGet, computePath+"/instances/{instanceId}",
+				getInstanceRequest{InstanceId: &id}, &actual); err != nil {
+				t.Fatalf("error in calling instance %s: %s", instanceID, err.Error())
+			}
+			name := fmt.Sprintf("%s instance %s", tier, stringValue(actual.Instance.DisplayName))
+
+			// assertions
+			if host := stringValue(actual.Instance.DedicatedVmHostId); placement.DedicatedVmHostID != "" && host != placement.DedicatedVmHostID {
+				t.Errorf("%s: wrong dedicated VM host: expected %s, got %q", name, placement.DedicatedVmHostID, host)
+			}
+			if reservation := stringValue(actual.Instance.CapacityReservationId); placement.CapacityReservationID != "" && reservation != placement.CapacityReservationID {
+				t.Errorf("%s: wrong capacity reservation: expected %s, got %q", name, placement.CapacityReservationID, reservation)
+			}
+		}
+
+		if placement.DedicatedVmHostID != "" {
+			reportDedicatedHost(t, compute, placement.DedicatedVmHostID)
+		}
+		if placement.CapacityReservationID != "" {
+			reportCapacityReservation(t, compute, placement.CapacityReservationID)
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func reportDedicatedHost(t *testing.T, compute core.ComputeClient, hostID string) {
+	response, err := compute.GetDedicatedVmHost(context.Background(), core.GetDedicatedVmHostRequest{DedicatedVmHostId: &hostID})
+	if err != nil {
+		t.Fatalf("error in calling dedicated VM host %s: %s", hostID, err.Error())
+	}
+	host := response.DedicatedVmHost
+	linkResource(t, "dedicated VM host "+stringValue(host.DisplayName), hostID, "")
+
+	if host.LifecycleState != core.DedicatedVmHostLifecycleStateActive {
+		t.Errorf("dedicated VM host %s in state %s", stringValue(host.DisplayName), host.LifecycleState)
+	}
+	used := *host.TotalOcpus - *host.RemainingOcpus
+	t.Logf("dedicated VM host %s: %.1f of %.1f OCPUs used", stringValue(host.DisplayName), used, *host.TotalOcpus)
+	report.AddMetric("dedicated VM host "+stringValue(host.DisplayName)+" OCPUs used", fmt.Sprintf("%.1f/%.1f", used, *host.TotalOcpus))
+}
+
+func reportCapacityReservation(t *testing.T, compute core.ComputeClient, reservationID string) {
+	var response getCapacityReservationResponse
+	if err := callAPI(context.Background(), compute.BaseClient, http.MethodGet, computePath+"/computeCapacityReservations/{capacityReservationId}",
+		getCapacityReservationRequest{CapacityReservationId: &reservationID}, &response); err != nil {
+		t.Fatalf("error in calling capacity reservation %s: %s", reservationID, err.Error())
+	}
+	reservation := response.CapacityReservation
+	linkResource(t, "capacity reservation "+stringValue(reservation.DisplayName), reservationID, "")
+
+	if reservation.LifecycleState != "ACTIVE" {
+		t.Errorf("capacity reservation %s in state %s", stringValue(reservation.DisplayName), reservation.LifecycleState)
+	}
+	used, reserved := int64(0), int64(0)
+	if reservation.UsedInstanceCount != nil {
+		used = *reservation.UsedInstanceCount
+	}
+	if reservation.ReservedInstanceCount != nil {
+		reserved = *reservation.ReservedInstanceCount
+	}
+	t.Logf("capacity reservation %s: %d of %d instances used", stringValue(reservation.DisplayName), used, reserved)
+	report.AddMetric("capacity reservation "+stringValue(reservation.DisplayName)+" instances used", fmt.Sprintf("%d/%d", used, reserved))
+}
@@ -0,0 +1,241 @@
+package terratest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+const (
+	instanceResourceType     = "oci_core_instance"
+	loadBalancerResourceType = "oci_load_balancer_load_balancer"
+	// resources of the web tier, by type and name
+	webTierResource      = instanceResourceType + ".WebServer"
+	greenWebTierResource = instanceResourceType + ".GreenWebServer"
+)
+
+var (
+	// policies of the plan, evaluated before apply in this order. They are Go functions standing in for Rego,
+	// OPA is no dependency of the module.
+	planPolicies = []PlanPolicy{
+		{"noPublicIpOnWebTier", noPublicIpOnWebTier},
+		{"mandatoryTags", mandatoryTags},
+		{"allowedShapes", allowedShapes},
+		{"allowedRegions", allowedRegions},
+		{"allowedImages", allowedImages},
+		{"allowedLbBandwidths", allowedLbBandwidths},
+	}
+)
+
+// PlanPoliciesExpectation parametrizes the policies the plan is evaluated against before apply.
+type PlanPoliciesExpectation struct {
+	// MandatoryTags are keys every taggable resource is tagged with, Namespace.key for defined tags
+	MandatoryTags []string `json:"mandatoryTags"`
+	// Allow-lists, not checked when empty. AllowedShapes and AllowedImages (OCIDs) are of instances.
+	AllowedShapes  []string `json:"allowedShapes"`
+	AllowedRegions []string `json:"allowedRegions"`
+	AllowedImages  []string `json:"allowedImages"`
+	// AllowedLbBandwidths are shapes of load balancers, e.g. 100Mbps, flexible shapes by their maximum bandwidth
+	AllowedLbBandwidths []string `json:"allowedLbBandwidths"`
+}
+
+// PlanPolicy is a rule of the planned resources, it returns descriptions of the violations.
+type PlanPolicy struct {
+	Name     string
+	Evaluate func(plan *StackPlan, expected *PlanPoliciesExpectation) []string
+}
+
+// StackPlan is the part of `terraform show -json <plan>` the policies need, the planned values
+// have the shape of the state.
+type StackPlan struct {
+	Variables     map[string]PlanVariable `json:"variables"`
+	PlannedValues *StateValues            `json:"planned_values"`
+}
+
+// PlanVariable is the value of a variable of the plan.
+type PlanVariable struct {
+	Value interface{} `json:"value"`
+}
+
+// enforcePlanPolicies plans the stack and fails before apply when the plan violates a policy,
+// each policy is a subtest. Without planPolicies in expectations only the unparametrised policies,
+// e.g. noPublicIpOnWebTier, find violations.
+func enforcePlanPolicies(t *testing.T) {
+	expected := loadExpectations(t).PlanPolicies
+	if expected == nil {
+		expected = &PlanPoliciesExpectation{}
+	}
+	plan := planStack(t)
+
+	failed := []string{}
+	for _, policy := range planPolicies {
+		evaluate := policy.Evaluate
+		if !t.Run("policy "+policy.Name, func(t *testing.T) {
+			for _, violation := range evaluate(plan, expected) {
+				t.Error(violation)
+			}
+		}) {
+			failed = append(failed, policy.Name)
+		}
+	}
+	if len(failed) > 0 {
+		t.Fatalf("plan violates policies %v, not applied", failed)
+	}
+}
+
+func noPublicIpOnWebTier(plan *StackPlan, expected *PlanPoliciesExpectation) []string {
+	violations := []string{}
+	for _, resource := range plan.ManagedResources() {
+		if name := resource.Type + "." + resource.Name; name != webTierResource && name != greenWebTierResource {
+			continue
+		}
+		for _, vnic := range nestedBlocks(resource.Values["create_vnic_details"]) {
+			// the provider assigns a public IP unless disabled
+			if assign, ok := vnic["assign_public_ip"]; !ok || fmt.Sprint(assign) != "false" {
+				violations = append(violations, fmt.Sprintf("%s: web tier instance gets a public IP", resource.Address))
+			}
+		}
+	}
+	return violations
+}
+
+func mandatoryTags(plan *StackPlan, expected *PlanPoliciesExpectation) []string {
+	violations := []string{}
+	for _, resource := range plan.ManagedResources() {
+		_, defined := resource.Values["defined_tags"]
+		_, freeform := resource.Values["freeform_tags"]
+		if !defined && !freeform {
+			continue
+		}
+		for _, key := range expected.MandatoryTags {
+			tags := resource.Values["freeform_tags"]
+			if strings.Contains(key, ".") {
+				tags = resource.Values["defined_tags"]
+			}
+			if values, _ := tags.(map[string]interface{}); values == nil || values[key] == nil {
+				violations = append(violations, fmt.Sprintf("%s: missing mandatory tag %s", resource.Address, key))
+			}
+		}
+	}
+	return violations
+}
+
+func allowedShapes(plan *StackPlan, expected *PlanPoliciesExpectation) []string {
+	violations := []string{}
+	if len(expected.AllowedShapes) == 0 {
+		return violations
+	}
+	for _, resource := range plan.FindByType(instanceResourceType) {
+		if shape := fmt.Sprint(resource.Values["shape"]); !containsString(expected.AllowedShapes, shape) {
+			violations = append(violations, fmt.Sprintf("%s: shape %s is not allowed, allowed are %v", resource.Address, shape, expected.AllowedShapes))
+		}
+	}
+	return violations
+}
+
+func allowedRegions(plan *StackPlan, expected *PlanPoliciesExpectation) []string {
+	violations := []string{}
+	if region := plan.Variable("region"); len(expected.AllowedRegions) > 0 && !containsString(expected.AllowedRegions, region) {
+		violations = append(violations, fmt.Sprintf("region %q is not allowed, allowed are %v", region, expected.AllowedRegions))
+	}
+	return violations
+}
+
+func allowedImages(plan *StackPlan, expected *PlanPoliciesExpectation) []string {
+	violations := []string{}
+	if len(expected.AllowedImages) == 0 {
+		return violations
+	}
+	for _, resource := range plan.FindByType(instanceResourceType) {
+		for _, source := range nestedBlocks(resource.Values["source_details"]) {
+			if source["source_type"] != "image" {
+				continue
+			}
+			if image := fmt.Sprint(source["source_id"]); !containsString(expected.AllowedImages, image) {
+				violations = append(violations, fmt.Sprintf("%s: image %s is not allowed", resource.Address, image))
+			}
+		}
+	}
+	return violations
+}
+
+func allowedLbBandwidths(plan *StackPlan, expected *PlanPoliciesExpectation) []string {
+	violations := []string{}
+	if len(expected.AllowedLbBandwidths) == 0 {
+		return violations
+	}
+	for _, resource := range plan.FindByType(loadBalancerResourceType) {
+		bandwidth := fmt.Sprint(resource.Values["shape"])
+		for _, details := range nestedBlocks(resource.Values["shape_details"]) {
+			bandwidth = fmt.Sprintf("%vMbps", details["maximum_bandwidth_in_mbps"])
+		}
+		if !containsString(expected.AllowedLbBandwidths, bandwidth) {
+			violations = append(violations, fmt.Sprintf("%s: bandwidth %s is not allowed, allowed are %v", resource.Address, bandwidth, expected.AllowedLbBandwidths))
+		}
+	}
+	return violations
+}
+
+// ManagedResources returns the planned resources which are not data sources.
+func (p *StackPlan) ManagedResources() []StateResource {
+	return p.state().ManagedResources()
+}
+
+// FindByType returns the planned resources of the type, e.g. oci_core_instance.
+func (p *StackPlan) FindByType(resourceType string) []StateResource {
+	return p.state().FindByType(resourceType)
+}
+
+// Variable returns the value of the variable, empty when it is not set.
+func (p *StackPlan) Variable(name string) string {
+	variable, ok := p.Variables[name]
+	if !ok || variable.Value == nil {
+		return ""
+	}
+	return fmt.Sprint(variable.Value)
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func (p *StackPlan) state() *State {
+	return &State{Values: p.PlannedValues}
+}
+
+// planStack saves the plan of the stack and returns it.
+func planStack(t *testing.T) *StackPlan {
+	file, err := ioutil.TempFile("", "terratest-plan-")
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	terraform.RunTerraformCommand(t, options, terraform.FormatArgs(options, "plan", "-input=false", "-lock=false", "-out="+file.Name())...)
+	out := terraform.RunTerraformCommand(t, options, "show", "-json", file.Name())
+	lastPlan.Lock()
+	lastPlan.json = out
+	lastPlan.Unlock()
+
+	plan := &StackPlan{}
+	if err := json.Unmarshal([]byte(out), plan); err != nil {
+		t.Fatalf("error in parsing terraform plan: %s", err.Error())
+	}
+	return plan
+}
+
+// nestedBlocks returns the blocks of a nested block attribute, e.g. create_vnic_details.
+func nestedBlocks(value interface{}) []map[string]interface{} {
+	blocks := []map[string]interface{}{}
+	list, _ := value.([]interface{})
+	for _, item := range list {
+		if block, ok := item.(map[string]interface{}); ok {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
@@ -0,0 +1,207 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/oracle/oci-go-sdk/core"
+	"github.com/oracle/oci-go-sdk/loadbalancer"
+)
+
+const (
+	// termination of the instance, launch of the replacement and its registration
+	poolReplacementTimeout = 20 * time.Minute
+)
+
+func checkInstancePoolBackends(t *testing.T) {
+	expected := loadExpectations(t).InstancePool
+	if expected == nil || expected.BackendSet == "" {
+		t.Skip("no instancePool backendSet in expectations")
+	}
+	client := computeManagementClient(t)
+	pool := findInstancePool(t, client, expected.DisplayName)
+	attachment := poolLoadBalancerAttachment(t, pool, expected.BackendSet)
+
+	// assertions
+	if attachment.LifecycleState != core.InstancePoolLoadBalancerAttachmentLifecycleStateAttached {
+		t.Errorf("instance pool %s: attachment to backend set %s in state %s", expected.DisplayName, expected.BackendSet, attachment.LifecycleState)
+	}
+	if lbID := terraform.Output(t, options, "lb_id"); stringValue(attachment.LoadBalancerId) != lbID {
+		t.Errorf("instance pool %s attached to load balancer %s, expected %s", expected.DisplayName, stringValue(attachment.LoadBalancerId), lbID)
+	}
+	if expected.BackendPort != 0 && *attachment.Port != expected.BackendPort {
+		t.Errorf("instance pool %s: wrong backend port: expected %d, got %d", expected.DisplayName, expected.BackendPort, *attachment.Port)
+	}
+
+	missing, unexpected, err := poolBackendDiff(context.Background(), client, pool, attachment)
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	for _, backend := range missing {
+		t.Errorf("pool instance %s is not a backend of %s", backend, expected.BackendSet)
+	}
+	for _, backend := range unexpected {
+		t.Errorf("backend %s of %s is not a running pool instance", backend, expected.BackendSet)
+	}
+}
+
+// scenarioPoolInstanceReplacement terminates one pool instance and waits until the pool replaces it
+// and the replacement (and not the terminated instance) is registered in the backend set.
+func scenarioPoolInstanceReplacement(t *testing.T) {
+	requireScenarios(t)
+	expected := loadExpectations(t).InstancePool
+	if expected == nil || expected.BackendSet == "" {
+		t.Skip("no instancePool backendSet in expectations")
+	}
+	client := computeManagementClient(t)
+	pool := findInstancePool(t, client, expected.DisplayName)
+	attachment := poolLoadBalancerAttachment(t, pool, expected.BackendSet)
+
+	instances := runningPoolInstances(t, client, pool)
+	if len(instances) == 0 {
+		t.Fatalf("no running instances in pool %s", expected.DisplayName)
+	}
+	terminated := *instances[0].Id
+	if _, err := computeClient(t).TerminateInstance(context.Background(), core.TerminateInstanceRequest{InstanceId: &terminated}); err != nil {
+		t.Fatalf("error in terminating pool instance %s: %s", terminated, err.Error())
+	}
+	t.Logf("terminated pool instance %s", terminated)
+	start := time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), poolReplacementTimeout)
+	defer cancel()
+
+	description := fmt.Sprintf("replacement of %s in instance pool %s", terminated, expected.DisplayName)
+	err := pollUntil(ctx, description, poolPollInterval, func(ctx context.Context) (bool, error) {
+		ids, err := runningPoolInstanceIDs(ctx, client, pool)
+		if err != nil {
+			return false, err
+		}
+		if len(ids) != *pool.Size || ids[terminated] {
+			return false, nil
+		}
+
+		missing, unexpected, err := poolBackendDiff(ctx, client, pool, attachment)
+		if err != nil {
+			return false, err
+		}
+		return len(missing) == 0 && len(unexpected) == 0, nil
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	t.Logf("pool instance %s replaced and registered after %s", terminated, time.Since(start))
+	report.AddMetric("time to replace pool instance", time.Since(start).Round(time.Second))
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func poolLoadBalancerAttachment(t *testing.T, pool core.InstancePool, backendSet string) core.InstancePoolLoadBalancerAttachment {
+	for _, attachment := range pool.LoadBalancers {
+		if stringValue(attachment.BackendSetName) == backendSet {
+			return attachment
+		}
+	}
+	t.Fatalf("instance pool %s is not attached to backend set %s", stringValue(pool.DisplayName), backendSet)
+	return core.InstancePoolLoadBalancerAttachment{}
+}
+
+func runningPoolInstanceIDs(ctx context.Context, client core.ComputeManagementClient, pool core.InstancePool) (map[string]bool, error) {
+	response, err := client.ListInstancePoolInstances(ctx, core.ListInstancePoolInstancesRequest{
+		CompartmentId:  pool.CompartmentId,
+		InstancePoolId: pool.Id,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := map[string]bool{}
+	for _, instance := range response.Items {
+		if isRunningPoolInstance(instance) {
+			ids[*instance.Id] = true
+		}
+	}
+	return ids, nil
+}
+
+// poolBackendDiff compares "ip:port" of running pool instances with backends of the attached backend set.
+func poolBackendDiff(ctx context.Context, client core.ComputeManagementClient, pool core.InstancePool,
+	attachment core.InstancePoolLoadBalancerAttachment) ([]string, []string, error) {
+	ids, err := runningPoolInstanceIDs(ctx, client, pool)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	compute, err := core.NewComputeClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		return nil, nil, err
+	}
+	throttle(&compute.BaseClient)
+	network, err := core.NewVirtualNetworkClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		return nil, nil, err
+	}
+	throttle(&network.BaseClient)
+
+	expected := map[string]bool{}
+	for id := range ids {
+		instanceID := id
+		attachments, err := compute.ListVnicAttachments(ctx, core.ListVnicAttachmentsRequest{
+			CompartmentId: pool.CompartmentId,
+			InstanceId:    &instanceID,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, vnicAttachment := range attachments.Items {
+			if vnicAttachment.VnicId == nil {
+				continue
+			}
+			vnic, err := network.GetVnic(ctx, core.GetVnicRequest{VnicId: vnicAttachment.VnicId})
+			if err != nil {
+				return nil, nil, err
+			}
+			if boolValue(vnic.Vnic.IsPrimary) {
+				expected[fmt.Sprintf("%s:%d", *vnic.Vnic.PrivateIp, *attachment.Port)] = true
+			}
+		}
+	}
+
+	lb, err := loadbalancer.NewLoadBalancerClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		return nil, nil, err
+	}
+	throttle(&lb.BaseClient)
+	backends, err := lb.ListBackends(ctx, loadbalancer.ListBackendsRequest{
+		LoadBalancerId: attachment.LoadBalancerId,
+		BackendSetName: attachment.BackendSetName,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	actual := map[string]bool{}
+	for _, backend := range backends.Items {
+		actual[fmt.Sprintf("%s:%d", stringValue(backend.IpAddress), *backend.Port)] = true
+	}
+
+	missing, unexpected := []string{}, []string{}
+	for backend := range expected {
+		if !actual[backend] {
+			missing = append(missing, backend)
+		}
+	}
+	for backend := range actual {
+		if !expected[backend] {
+			unexpected = append(unexpected, backend)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(unexpected)
+	return missing, unexpected, nil
+}
@@ -0,0 +1,173 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/oracle/oci-go-sdk/core"
+	"github.com/oracle/oci-go-sdk/loadbalancer"
+)
+
+const (
+	// termination of the instance and the LB health check marking its backend down
+	preemptionTimeout      = 10 * time.Minute
+	preemptionPollInterval = 10 * time.Second
+	// requests to the LB after the backend is down, none of them may fail
+	preemptionObservation = time.Minute
+	preemptionAction      = "TERMINATE"
+)
+
+// instancePreemptibleConfig has the preemptible config of the instance the SDK does not have yet.
+type instancePreemptibleConfig struct {
+	RawResponse *http.Response
+	Instance    struct {
+		DisplayName               *string `json:"displayName"`
+		PreemptibleInstanceConfig *struct {
+			PreemptionAction *struct {
+				Type               string `json:"type"`
+				PreserveBootVolume *bool  `json:"preserveBootVolume"`
+			} `json:"preemptionAction"`
+		} `json:"preemptibleInstanceConfig"`
+	} `presentIn:"body"`
+}
+
+// checkPreemptibleConfig verifies web servers are launched as preemptible instances terminated with their
+// boot volumes, and the bastion is not preemptible.
+func checkPreemptibleConfig(t *testing.T) {
+	if stringVar("PreemptibleWebServers", "false") != "true" {
+		t.Skip("no PreemptibleWebServers, web servers are not preemptible")
+	}
+	compute := computeClient(t)
+
+	for tier, output := range tierOutputs {
+		for _, instanceID := range outputValues(t, output) {
+			id := instanceID
+			var actual instancePreemptibleConfig
+			if err := callAPI(context.Background(), compute.BaseClient, http.MethodGet, computePath+"/instances/{instanceId}",
+				getInstanceRequest{InstanceId: &id}, &actual); err != nil {
+				t.Fatalf("error in calling instance %s: %s", instanceID, err.Error())
+			}
+			name := fmt.Sprintf("%s instance %s", tier, stringValue(actual.Instance.DisplayName))
+			preemptible := actual.Instance.PreemptibleInstanceConfig
+
+			// assertions
+			if tier != "web" {
+				if preemptible != nil {
+					t.Errorf("%s is preemptible", name)
+				}
+				continue
+			}
+			if preemptible == nil || preemptible.PreemptionAction == nil {
+				t.Errorf("%s is not preemptible", name)
+				continue
+			}
+			if preemptible.PreemptionAction.Type != preemptionAction {
+				t.Errorf("%s: wrong preemption action: expected %s, got %s", name, preemptionAction, preemptible.PreemptionAction.Type)
+			}
+			if boolValue(preemptible.PreemptionAction.PreserveBootVolume) {
+				t.Errorf("%s: boot volume is preserved on preemption", name)
+			}
+		}
+	}
+}
+
+// scenarioPreemption simulates preemption of the last web server by terminating it without its boot volume.
+// The LB may fail requests until its health check marks the backend down, then the remaining web servers
+// have to serve all requests. The terminated web server is applied again at the end.
+func scenarioPreemption(t *testing.T) {
+	requireScenarios(t)
+	if stringVar("PreemptibleWebServers", "false") != "true" {
+		t.Skip("no PreemptibleWebServers, web servers are not preemptible")
+	}
+	instanceIDs := outputValues(t, tierOutputs["web"])
+	privateIPs := outputValues(t, "WebServerPrivateIPs")
+	if len(instanceIDs) < 2 {
+		t.Skipf("preemption needs at least 2 web servers, got %d", len(instanceIDs))
+	}
+	last := len(instanceIDs) - 1
+	preempted := instanceIDs[last]
+	lbID := terraform.Output(t, options, "lb_id")
+	compute := computeClient(t)
+	lb := loadBalancerClient(t)
+
+	poller := startLbPoller("http://" + outputValues(t, "lb_ip")[0] + "/")
+	preserveBootVolume := false
+	if _, err := compute.TerminateInstance(context.Background(), core.TerminateInstanceRequest{
+		InstanceId:         &preempted,
+		PreserveBootVolume: &preserveBootVolume,
+	}); err != nil {
+		poller.Stop()
+		t.Fatalf("error in terminating web server %s: %s", preempted, err.Error())
+	}
+	t.Logf("terminated web server %s", preempted)
+	start := time.Now()
+
+	WaitForState(t, "preempted web server "+preempted, instanceState(compute, preempted),
+		string(core.InstanceLifecycleStateTerminated), preemptionTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), preemptionTimeout)
+	defer cancel()
+	err := pollUntil(ctx, "backend down of preempted web server "+preempted, preemptionPollInterval, func(ctx context.Context) (bool, error) {
+		status, err := backendHealth(ctx, lb, lbID, privateIPs[last])
+		return status != loadbalancer.BackendHealthStatusOk, err
+	})
+	total, failed, _ := poller.Stop()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	t.Logf("web server %s down after %s, %d of %d requests failed meanwhile", preempted, time.Since(start), failed, total)
+	report.AddMetric("time to detect preempted web server", time.Since(start).Round(time.Second))
+	report.AddMetric("failed requests during preemption", fmt.Sprintf("%d of %d", failed, total))
+
+	poller = startLbPoller(poller.url)
+	time.Sleep(preemptionObservation)
+	total, failed, errors := poller.Stop()
+
+	// assertions
+	if total == 0 {
+		t.Errorf("no requests to %s were made after preemption", poller.url)
+	}
+	if failed > 0 {
+		t.Errorf("%d of %d requests failed with the preempted backend down, errors: %v", failed, total, errors)
+	}
+	for i, instanceID := range instanceIDs[:last] {
+		id := instanceID
+		response, err := compute.GetInstance(context.Background(), core.GetInstanceRequest{InstanceId: &id})
+		if err != nil {
+			t.Fatalf("error in calling instance %s: %s", instanceID, err.Error())
+		}
+		if response.Instance.LifecycleState != core.InstanceLifecycleStateRunning {
+			t.Errorf("web server %s in state %s after preemption", stringValue(response.Instance.DisplayName), response.Instance.LifecycleState)
+		}
+		status, err := backendHealth(context.Background(), lb, lbID, privateIPs[i])
+		if err != nil {
+			t.Fatalf("error in calling backend health of %s: %s", privateIPs[i], err.Error())
+		}
+		if status != loadbalancer.BackendHealthStatusOk {
+			t.Errorf("backend %s of web server %s in status %s after preemption", privateIPs[i], stringValue(response.Instance.DisplayName), status)
+		}
+	}
+
+	// the instance is gone from the cloud, apply launches it again and replaces its backend
+	ApplyTarget(t, fmt.Sprintf("oci_core_instance.WebServer[%d]", last), fmt.Sprintf("oci_load_balancer_backend.lb-backend-web[%d]", last))
+	WaitForHealthy(t, poller.url, http.StatusOK, healthySLO(t))
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func backendHealth(ctx context.Context, client loadbalancer.LoadBalancerClient, lbID string, ip string) (loadbalancer.BackendHealthStatusEnum, error) {
+	backendSet := lbBackendSetName
+	backend := fmt.Sprintf("%s:%d", ip, lbBackendPort)
+	response, err := client.GetBackendHealth(ctx, loadbalancer.GetBackendHealthRequest{
+		LoadBalancerId: &lbID,
+		BackendSetName: &backendSet,
+		BackendName:    &backend,
+	})
+	if err != nil {
+		return "", err
+	}
+	return response.BackendHealth.Status, nil
+}
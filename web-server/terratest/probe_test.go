@@ -0,0 +1,85 @@
+package terratest
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+const (
+	probeTimeoutSeconds = 3
+)
+
+var (
+	// bash /dev/tcp connect to every host and port, prints "<host> <port> open|closed"
+	probeTemplate = template.Must(template.New("probe").Parse(`#!/bin/bash
+for host in {{range .Hosts}}{{.}} {{end}}; do
+  for port in {{range .Ports}}{{.}} {{end}}; do
+    if timeout {{.Timeout}} bash -c "</dev/tcp/$host/$port" 2>/dev/null; then
+      echo "$host $port open"
+    else
+      echo "$host $port closed"
+    fi
+  done
+done
+`))
+)
+
+func checkBastionTcpProbes(t *testing.T) {
+	expected := loadExpectations(t).Reachability
+	if len(expected.Ports) == 0 {
+		t.Skip("no reachability in expectations")
+	}
+
+	// IPv6 addresses of dual-stack web servers are probed as well
+	hosts := append(outputValues(t, "WebServerPrivateIPs"), webIpv6Addresses(t)...)
+	script := probeScript(t, hosts, expected.Ports)
+
+	result := RunRemote(t, bastionHost(t), script, RemoteOptions{Timeout: sshCommandTimeout})
+	if result.ExitCode != 0 {
+		t.Fatalf("probe script failed with exit code %d: %s", result.ExitCode, result.Stderr)
+	}
+
+	open := parseProbeOutput(result.Stdout)
+
+	// assertions
+	for _, host := range hosts {
+		for _, port := range expected.Ports {
+			actual := open[host+":"+strconv.Itoa(port)]
+			allowed := expected.Allows("bastion", "web", port)
+
+			if actual != allowed {
+				t.Errorf("bastion -> %s tcp/%d: expected open %t, got %t", host, port, allowed, actual)
+			}
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func probeScript(t *testing.T, hosts []string, ports []int) string {
+	var script bytes.Buffer
+	err := probeTemplate.Execute(&script, struct {
+		Hosts   []string
+		Ports   []int
+		Timeout int
+	}{hosts, ports, probeTimeoutSeconds})
+	if err != nil {
+		t.Fatalf("error in generating probe script: %s", err.Error())
+	}
+	return script.String()
+}
+
+// parseProbeOutput returns "host:port" of open ports.
+func parseProbeOutput(out string) map[string]bool {
+	open := map[string]bool{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[2] == "open" {
+			open[fields[0]+":"+fields[1]] = true
+		}
+	}
+	return open
+}
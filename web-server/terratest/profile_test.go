@@ -0,0 +1,128 @@
+package terratest
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// kinds of profiled operations
+	opTerraform = "terraform"
+	opSsh       = "ssh"
+	opAPI       = "api"
+	// retry loops around ssh round trips, e.g. 20 attempts 5s apart
+	opRetry = "retry"
+	// operations ranked in the profile of the report
+	profileTopOperations = 25
+	profileNameLength    = 60
+)
+
+var (
+	profiler = &Profiler{}
+
+	// OCIDs in API paths, operations on different resources are ranked together
+	pathOcidPattern = regexp.MustCompile(`ocid1\.[^/?]+`)
+)
+
+// Profiler records durations of terraform commands, ssh round trips, retry loops and API calls of the run,
+// each attributed to the check running at the time.
+type Profiler struct {
+	mu         sync.Mutex
+	check      string
+	operations []profiledOperation
+}
+
+type profiledOperation struct {
+	kind     string
+	name     string
+	check    string
+	duration time.Duration
+}
+
+// ProfileEntry is an operation of the profile, its calls aggregated.
+type ProfileEntry struct {
+	Kind  string
+	Name  string
+	Calls int
+	// TotalSeconds of all calls, the profile is ranked by it
+	TotalSeconds float64
+	MaxSeconds   float64
+}
+
+// SetCheck attributes the following operations to the check, empty outside of checks.
+func (p *Profiler) SetCheck(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.check = name
+}
+
+// Record records an operation which started at start and has just finished.
+func (p *Profiler) Record(kind string, name string, start time.Time) {
+	duration := time.Since(start)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.operations = append(p.operations, profiledOperation{kind: kind, name: name, check: p.check, duration: duration})
+}
+
+// Breakdown returns the seconds the check spent by kind of operation.
+func (p *Profiler) Breakdown(check string) map[string]float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	breakdown := map[string]float64{}
+	for _, operation := range p.operations {
+		if operation.check == check {
+			breakdown[operation.kind] += operation.duration.Seconds()
+		}
+	}
+	return breakdown
+}
+
+// Slowest returns the count operations with the most total time.
+func (p *Profiler) Slowest(count int) []ProfileEntry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	byName := map[string]*ProfileEntry{}
+	entries := []*ProfileEntry{}
+	for _, operation := range p.operations {
+		key := operation.kind + " " + operation.name
+		entry, ok := byName[key]
+		if !ok {
+			entry = &ProfileEntry{Kind: operation.kind, Name: operation.name}
+			byName[key] = entry
+			entries = append(entries, entry)
+		}
+		seconds := operation.duration.Seconds()
+		entry.Calls++
+		entry.TotalSeconds += seconds
+		if seconds > entry.MaxSeconds {
+			entry.MaxSeconds = seconds
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].TotalSeconds > entries[j].TotalSeconds })
+	slowest := []ProfileEntry{}
+	for i := 0; i < len(entries) && i < count; i++ {
+		slowest = append(slowest, *entries[i])
+	}
+	return slowest
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// profileName is the first line of the command, shortened, which names ssh operations in the profile.
+func profileName(command string) string {
+	name := strings.SplitN(strings.TrimSpace(command), "\n", 2)[0]
+	if len(name) > profileNameLength {
+		return name[:profileNameLength] + "..."
+	}
+	return name
+}
+
+// apiOperation names an API call by method and path without OCIDs, e.g. GET /20160918/instances/{ocid}.
+func apiOperation(method string, path string) string {
+	return method + " " + pathOcidPattern.ReplaceAllString(path, "{ocid}")
+}
@@ -0,0 +1,149 @@
+package terratest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/ssh"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// The runner may sit behind a corporate proxy with restricted egress:
+//   - HTTPS_PROXY, HTTP_PROXY and NO_PROXY apply to the SDK clients and the HTTP checks,
+//     OCI_HTTPS_PROXY overrides them for the SDK clients only, e.g. when the LB is reachable directly
+//   - SSH_JUMP_HOST ([user@]host[:port]) is an outer bastion the bastion of the stack is reached through,
+//     authenticated by SSH_JUMP_PRIVATE_KEY (a file) or the key of the run, its host key is verified
+//     by SSH_JUMP_KNOWN_HOSTS (a known_hosts file) or SSH_HOST_KEYS as any other host
+
+// httpTransport returns a transport for HTTP checks with their own settings, honoring the proxy env vars
+// as the default transport does.
+func httpTransport() *http.Transport {
+	return http.DefaultTransport.(*http.Transport).Clone()
+}
+
+// ociTransport returns the transport of the SDK clients, through OCI_HTTPS_PROXY when set.
+// A wrong OCI_HTTPS_PROXY fails every request of the clients.
+func ociTransport() *http.Transport {
+	transport := httpTransport()
+	if value := os.Getenv("OCI_HTTPS_PROXY"); value != "" {
+		proxy, err := url.Parse(value)
+		if err != nil {
+			err = fmt.Errorf("wrong OCI_HTTPS_PROXY %q: %s", value, err.Error())
+			transport.Proxy = func(*http.Request) (*url.URL, error) { return nil, err }
+		} else {
+			transport.Proxy = http.ProxyURL(proxy)
+		}
+	}
+	return transport
+}
+
+func sshJumpEnabled() bool {
+	return os.Getenv("SSH_JUMP_HOST") != ""
+}
+
+// sshJumpHost parses SSH_JUMP_HOST, the user defaults to the one of the stack and the port to 22.
+func sshJumpHost(t *testing.T) (ssh.Host, error) {
+	spec := os.Getenv("SSH_JUMP_HOST")
+	host := ssh.Host{SshUserName: sshUserName, CustomPort: 22}
+	if at := strings.LastIndex(spec, "@"); at >= 0 {
+		host.SshUserName, spec = spec[:at], spec[at+1:]
+	}
+	host.Hostname = spec
+	if name, port, err := net.SplitHostPort(spec); err == nil {
+		host.Hostname = name
+		if host.CustomPort, err = strconv.Atoi(port); err != nil {
+			return host, fmt.Errorf("wrong port of SSH_JUMP_HOST %q", os.Getenv("SSH_JUMP_HOST"))
+		}
+	}
+	if host.Hostname == "" {
+		return host, fmt.Errorf("no host in SSH_JUMP_HOST %q", os.Getenv("SSH_JUMP_HOST"))
+	}
+
+	if path := os.Getenv("SSH_JUMP_PRIVATE_KEY"); path != "" {
+		key, err := ioutil.ReadFile(path)
+		if err != nil {
+			return host, fmt.Errorf("error in reading SSH_JUMP_PRIVATE_KEY: %s", err.Error())
+		}
+		host.SshKeyPair = &ssh.KeyPair{PrivateKey: string(key)}
+	} else if useSshAgent(t) {
+		host.SshAgent = true
+	} else {
+		host.SshKeyPair = loadKeyPair(t)
+	}
+	return host, nil
+}
+
+// dialBastion connects to the bastion of the stack, through SSH_JUMP_HOST when set.
+func dialBastion(t *testing.T, bastion ssh.Host, config *gossh.ClientConfig) (*gossh.Client, func(), error) {
+	address := net.JoinHostPort(bastion.Hostname, sshPort)
+	if !sshJumpEnabled() {
+		client, err := gossh.Dial("tcp", address, config)
+		if err != nil {
+			return nil, nil, err
+		}
+		return client, func() { client.Close() }, nil
+	}
+
+	jump, err := sshJumpHost(t)
+	if err != nil {
+		return nil, nil, err
+	}
+	jumpConfig, err := sshClientConfig(t, jump)
+	if err != nil {
+		return nil, nil, err
+	}
+	if jumpConfig.HostKeyCallback, err = jumpHostKeyCallback(t); err != nil {
+		return nil, nil, err
+	}
+	jumpClient, err := gossh.Dial("tcp", net.JoinHostPort(jump.Hostname, strconv.Itoa(jump.CustomPort)), jumpConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error in connecting to jump host %s: %s", jump.Hostname, err.Error())
+	}
+
+	conn, err := jumpClient.Dial("tcp", address)
+	if err != nil {
+		jumpClient.Close()
+		return nil, nil, err
+	}
+	clientConn, chans, reqs, err := gossh.NewClientConn(conn, address, config)
+	if err != nil {
+		jumpClient.Close()
+		return nil, nil, err
+	}
+
+	client := gossh.NewClient(clientConn, chans, reqs)
+	return client, func() {
+		client.Close()
+		jumpClient.Close()
+	}, nil
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// jumpHostKeyCallback verifies the jump host by SSH_JUMP_KNOWN_HOSTS, the console of the jump host
+// is not available as it is no instance of the stack.
+func jumpHostKeyCallback(t *testing.T) (gossh.HostKeyCallback, error) {
+	if path := os.Getenv("SSH_JUMP_KNOWN_HOSTS"); path != "" {
+		return knownhosts.New(path)
+	}
+	if hostKeyMode() == hostKeysConsole {
+		return nil, fmt.Errorf("SSH_HOST_KEYS=%s needs SSH_JUMP_KNOWN_HOSTS for the jump host", hostKeysConsole)
+	}
+	return hostKeyCallback(t), nil
+}
+
+// sshJumpOption is the ssh option of command line ssh reaching the bastion through SSH_JUMP_HOST, empty without it.
+func sshJumpOption() string {
+	if !sshJumpEnabled() {
+		return ""
+	}
+	return "-J " + os.Getenv("SSH_JUMP_HOST") + " "
+}
@@ -0,0 +1,94 @@
+package terratest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/core"
+	"github.com/oracle/oci-go-sdk/identity"
+)
+
+func checkPublicIpExposure(t *testing.T) {
+	network := virtualNetworkClient(t)
+
+	// owners allowed to have public IPs: bastion, LB and NAT gateway (egress only)
+	allowed := map[string]string{}
+	for _, ip := range outputValues(t, "BastionPublicIP") {
+		allowed[ip] = "bastion"
+	}
+	for _, ip := range outputValues(t, "lb_ip") {
+		allowed[ip] = "load balancer"
+	}
+
+	networkCompartmentID := compartmentFor(networkCompartment)
+	vcnID := sanitizedVcnId(t)
+	nats, err := network.ListNatGateways(context.Background(), core.ListNatGatewaysRequest{
+		CompartmentId: &networkCompartmentID,
+		VcnId:         &vcnID,
+	})
+	if err != nil {
+		t.Fatalf("error in listing NAT gateways: %s", err.Error())
+	}
+	for _, nat := range nats.Items {
+		allowed[*nat.NatIp] = "NAT gateway"
+	}
+
+	// assertions
+	for _, compartmentID := range uniqueStrings(compartmentFor(networkCompartment), compartmentFor(computeCompartment), compartmentFor(loadBalancerCompartment)) {
+		for _, ip := range listPublicIps(t, network, compartmentID) {
+			owner, ok := allowed[*ip.IpAddress]
+			if !ok {
+				t.Errorf("unexpected public IP %s assigned to %s %s in %s",
+					*ip.IpAddress, ip.AssignedEntityType, stringValue(ip.AssignedEntityId), compartmentID)
+				continue
+			}
+			t.Logf("public IP %s owned by %s", *ip.IpAddress, owner)
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// listPublicIps returns regional (reserved, NAT) and AD scoped (ephemeral on VNICs) public IPs.
+func listPublicIps(t *testing.T, network core.VirtualNetworkClient, compartmentID string) []core.PublicIp {
+	requests := []core.ListPublicIpsRequest{{
+		Scope:         core.ListPublicIpsScopeRegion,
+		CompartmentId: &compartmentID,
+	}}
+
+	tenancyID := stringVar("tenancy_ocid", "")
+	ads, err := identityClient(t).ListAvailabilityDomains(context.Background(), identity.ListAvailabilityDomainsRequest{
+		CompartmentId: &tenancyID,
+	})
+	if err != nil {
+		t.Fatalf("error in listing availability domains: %s", err.Error())
+	}
+	for _, ad := range ads.Items {
+		requests = append(requests, core.ListPublicIpsRequest{
+			Scope:              core.ListPublicIpsScopeAvailabilityDomain,
+			AvailabilityDomain: ad.Name,
+			CompartmentId:      &compartmentID,
+		})
+	}
+
+	ips := []core.PublicIp{}
+	for _, request := range requests {
+		for {
+			response, err := network.ListPublicIps(context.Background(), request)
+			if err != nil {
+				t.Fatalf("error in listing public IPs: %s", err.Error())
+			}
+			for _, ip := range response.Items {
+				if ip.LifecycleState != core.PublicIpLifecycleStateTerminated {
+					ips = append(ips, ip)
+				}
+			}
+
+			if response.OpcNextPage == nil {
+				break
+			}
+			request.Page = response.OpcNextPage
+		}
+	}
+	return ips
+}
@@ -0,0 +1,66 @@
+package terratest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/limits"
+)
+
+func checkQuotaPolicies(t *testing.T) {
+	expected := loadExpectations(t).Quotas
+	if len(expected) == 0 {
+		t.Skip("no quotas in expectations")
+	}
+
+	client, err := limits.NewQuotasClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	throttle(&client.BaseClient)
+
+	for _, quota := range expected {
+		compartmentID := quota.CompartmentID
+		if compartmentID == "" {
+			compartmentID = stringVar("tenancy_ocid", "")
+		}
+		name := quota.Name
+
+		list, err := client.ListQuotas(context.Background(), limits.ListQuotasRequest{
+			CompartmentId: &compartmentID,
+			Name:          &name,
+		})
+		if err != nil {
+			t.Fatalf("error in listing quotas: %s", err.Error())
+		}
+		if len(list.Items) == 0 {
+			t.Errorf("missing quota %q in %s", name, compartmentID)
+			continue
+		}
+
+		response, err := client.GetQuota(context.Background(), limits.GetQuotaRequest{QuotaId: list.Items[0].Id})
+		if err != nil {
+			t.Fatalf("error in calling quota %q: %s", name, err.Error())
+		}
+
+		actual := map[string]bool{}
+		for _, statement := range response.Quota.Statements {
+			actual[normalizeStatement(statement)] = true
+		}
+
+		// assertions
+		for _, statement := range quota.Statements {
+			if !actual[normalizeStatement(statement)] {
+				t.Errorf("quota %q misses statement %q", name, statement)
+			}
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// normalizeStatement ignores case and whitespace differences of policy statements.
+func normalizeStatement(statement string) string {
+	return strings.ToLower(strings.Join(strings.Fields(statement), " "))
+}
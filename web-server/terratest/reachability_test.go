@@ -0,0 +1,202 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/core"
+)
+
+const (
+	internetTier = "internet"
+	// any tier, wildcard in reachability expectations
+	anyTier = "*"
+	// TEST-NET-3 address representing the internet
+	internetAddress = "203.0.113.10"
+	// documentation address representing the IPv6 internet
+	internetAddressIpv6 = "2001:db8::10"
+	tcpProtocol         = "6"
+	// first port of the Linux ephemeral range, clients of stateless rules get the return traffic on it
+	ephemeralPort = 32768
+)
+
+// endpoint is one instance or LB of a tier as seen by the reachability evaluator.
+type endpoint struct {
+	tier string
+	name string
+	// address used as peer by other endpoints, IP or subnet CIDR
+	address string
+	// public endpoints have public IP (or are public LB)
+	public bool
+	// internetRoute is set when the subnet routes 0.0.0.0/0 (::/0 for IPv6) to an enabled internet gateway
+	internetRoute bool
+	// ipv6 endpoints reach only other ipv6 endpoints
+	ipv6  bool
+	rules []SecurityRule
+}
+
+func checkTierReachability(t *testing.T) {
+	expected := loadExpectations(t).Reachability
+	if len(expected.Ports) == 0 {
+		t.Skip("no reachability in expectations")
+	}
+
+	endpoints := reachabilityEndpoints(t)
+	for _, src := range endpoints {
+		for _, dst := range endpoints {
+			if src.tier == dst.tier || dst.tier == internetTier || src.ipv6 != dst.ipv6 {
+				continue
+			}
+
+			for _, port := range expected.Ports {
+				actual := reachable(src, dst, tcpProtocol, port)
+				allowed := expected.Allows(src.tier, dst.tier, port)
+
+				if actual != allowed {
+					t.Errorf("%s -> %s tcp/%d: expected permitted %t, got %t", src.name, dst.name, port, allowed, actual)
+				} else {
+					t.Logf("%s -> %s tcp/%d: permitted %t", src.name, dst.name, port, actual)
+				}
+			}
+		}
+	}
+}
+
+// Allows reports whether traffic from tier src to tier dst on port is expected to be permitted.
+// Flows are written as "src->dst:port", src can be "*" for any tier.
+func (r ReachabilityExpectation) Allows(src string, dst string, port int) bool {
+	for _, flow := range r.Allowed {
+		if flow == fmt.Sprintf("%s->%s:%d", src, dst, port) || flow == fmt.Sprintf("%s->%s:%d", anyTier, dst, port) {
+			return true
+		}
+	}
+	return false
+}
+
+// reachable evaluates routing and security rules of both sides, including the return traffic of stateless rules.
+func reachable(src endpoint, dst endpoint, protocol string, port int) bool {
+	if src.tier == internetTier {
+		return dst.public && dst.internetRoute && connects(dst.rules, ingress, protocol, port, src.address)
+	}
+	// inside VCN traffic is always routed locally
+	return connects(src.rules, egress, protocol, port, dst.address) && connects(dst.rules, ingress, protocol, port, src.address)
+}
+
+func allows(rules []SecurityRule, direction string, protocol string, port int, peer string) bool {
+	return VnicRules{Rules: rules}.Allows(direction, protocol, port, peer)
+}
+
+// connects reports whether the rules of one side permit a connection with peer: the return traffic of a stateful
+// rule is tracked, a stateless rule needs another rule for it, to or from the ephemeral port of the client.
+// Source port ranges are not captured, return rules are matched by their destination ports.
+func connects(rules []SecurityRule, direction string, protocol string, port int, peer string) bool {
+	back := ingress
+	if direction == ingress {
+		back = egress
+	}
+	for _, rule := range rules {
+		if !rule.Allows(direction, protocol, port, peer) {
+			continue
+		}
+		if !rule.Stateless || allows(rules, back, protocol, ephemeralPort, peer) {
+			return true
+		}
+	}
+	return false
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func reachabilityEndpoints(t *testing.T) []endpoint {
+	network := virtualNetworkClient(t)
+	endpoints := []endpoint{
+		{tier: internetTier, name: internetTier, address: internetAddress},
+		{tier: internetTier, name: internetTier + " IPv6", address: internetAddressIpv6, ipv6: true},
+	}
+
+	for _, tier := range []string{"bastion", "web"} {
+		for _, instanceID := range outputValues(t, tierOutputs[tier]) {
+			for _, vnic := range instanceVnicRules(t, instanceID) {
+				endpoints = append(endpoints, endpoint{
+					tier:          tier,
+					name:          tier + " " + vnic.PrivateIP,
+					address:       vnic.PrivateIP,
+					public:        vnic.PublicIP != "",
+					internetRoute: subnetInternetRoute(t, network, vnic.SubnetID),
+					rules:         vnic.Rules,
+				})
+				for _, address := range vnic.Ipv6 {
+					endpoints = append(endpoints, endpoint{
+						tier:          tier,
+						name:          tier + " " + *address.IpAddress,
+						address:       *address.IpAddress,
+						public:        boolValue(address.IsInternetAccessAllowed),
+						internetRoute: subnetDefaultRoute(t, network, vnic.SubnetID, ipv6DefaultRoute),
+						ipv6:          true,
+						rules:         vnic.Rules,
+					})
+				}
+			}
+		}
+	}
+
+	lb := getLoadBalancer(t)
+	for _, subnetID := range lb.SubnetIds {
+		subnet, err := network.GetSubnet(context.Background(), core.GetSubnetRequest{SubnetId: &subnetID})
+		if err != nil {
+			t.Fatalf("error in calling subnet %s: %s", subnetID, err.Error())
+		}
+
+		rules := subnetSecurityListRules(t, network, subnetID)
+		for _, nsgID := range lb.NetworkSecurityGroupIds {
+			rules = append(rules, nsgRules(t, network, nsgID)...)
+		}
+
+		endpoints = append(endpoints, endpoint{
+			tier: "lb",
+			name: "lb " + *subnet.Subnet.CidrBlock,
+			// LB private addresses are not known, whole subnet is the source
+			address:       *subnet.Subnet.CidrBlock,
+			public:        !boolValue(lb.IsPrivate),
+			internetRoute: subnetInternetRoute(t, network, subnetID),
+			rules:         rules,
+		})
+	}
+	return endpoints
+}
+
+// subnetInternetRoute reports whether the subnet routes 0.0.0.0/0 to an enabled internet gateway.
+func subnetInternetRoute(t *testing.T, network core.VirtualNetworkClient, subnetID string) bool {
+	return subnetDefaultRoute(t, network, subnetID, "0.0.0.0/0")
+}
+
+// subnetDefaultRoute reports whether the subnet routes destination, 0.0.0.0/0 or ::/0, to an enabled internet gateway.
+func subnetDefaultRoute(t *testing.T, network core.VirtualNetworkClient, subnetID string, destination string) bool {
+	subnet, err := network.GetSubnet(context.Background(), core.GetSubnetRequest{SubnetId: &subnetID})
+	if err != nil {
+		t.Fatalf("error in calling subnet %s: %s", subnetID, err.Error())
+	}
+
+	table, err := network.GetRouteTable(context.Background(), core.GetRouteTableRequest{RtId: subnet.Subnet.RouteTableId})
+	if err != nil {
+		t.Fatalf("error in calling route table of subnet %s: %s", subnetID, err.Error())
+	}
+
+	for _, rule := range table.RouteTable.RouteRules {
+		if rule.Destination == nil || *rule.Destination != destination {
+			continue
+		}
+		if !strings.HasPrefix(*rule.NetworkEntityId, "ocid1.internetgateway.") {
+			continue
+		}
+
+		igw, err := network.GetInternetGateway(context.Background(), core.GetInternetGatewayRequest{IgId: rule.NetworkEntityId})
+		if err != nil {
+			t.Fatalf("error in calling internet gateway %s: %s", *rule.NetworkEntityId, err.Error())
+		}
+		return boolValue(igw.InternetGateway.IsEnabled)
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/identity"
+)
+
+var (
+	// home region of the tenancy, identity resources are written there
+	homeRegion string
+)
+
+// checkRegionSubscription fails early when the tenancy is not subscribed to the region of the run,
+// instead of 404s of the first calls. It also finds the home region of the tenancy.
+func checkRegionSubscription(t *testing.T) {
+	subscriptions := regionSubscriptions(t)
+
+	subscribed := false
+	names := []string{}
+	for _, subscription := range subscriptions {
+		name := stringValue(subscription.RegionName)
+		names = append(names, name)
+		if boolValue(subscription.IsHomeRegion) {
+			homeRegion = name
+		}
+		if subscription.Status == identity.RegionSubscriptionStatusReady && regionMatches(subscription, config.Region) {
+			subscribed = true
+		}
+	}
+
+	if !subscribed {
+		t.Fatalf("tenancy is not subscribed to region %s, subscribed regions: %s", config.Region, strings.Join(names, ", "))
+	}
+	t.Logf("region %s, home region %s", config.Region, homeRegion)
+}
+
+// homeIdentityClient is the identity client for writes (e.g. compartments), they are accepted in the home region only.
+func homeIdentityClient(t *testing.T) identity.IdentityClient {
+	client := identityClient(t)
+	if homeRegion != "" {
+		client.SetRegion(homeRegion)
+	}
+	return client
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// regionSubscriptions lists the subscriptions in a region known to be subscribed, an unsubscribed region
+// of the run fails the call with a misleading auth error. These are tried in order: OCI_HOME_REGION,
+// the region of the OCI config profile and the region of the run.
+func regionSubscriptions(t *testing.T) []identity.RegionSubscription {
+	tenancyID := config.TenancyOCID
+	client := identityClient(t)
+
+	errors := []string{}
+	for _, region := range subscriptionRegions() {
+		client.SetRegion(region)
+		response, err := client.ListRegionSubscriptions(context.Background(), identity.ListRegionSubscriptionsRequest{
+			TenancyId: &tenancyID,
+		})
+		if err == nil {
+			return response.Items
+		}
+		errors = append(errors, fmt.Sprintf("%s: %s", region, err.Error()))
+	}
+	t.Fatalf("error in listing region subscriptions, set OCI_HOME_REGION to the home region of the tenancy: %s",
+		strings.Join(errors, "; "))
+	return nil
+}
+
+// subscriptionRegions returns the distinct regions regionSubscriptions tries.
+func subscriptionRegions() []string {
+	regions := []string{}
+	for _, region := range []string{os.Getenv("OCI_HOME_REGION"), ociProfileValues["region"], config.Region} {
+		if region != "" && !containsString(regions, region) {
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
+
+// regionMatches compares by name (eu-frankfurt-1) or key (FRA) like the SDK accepts both.
+func regionMatches(subscription identity.RegionSubscription, region string) bool {
+	return strings.EqualFold(stringValue(subscription.RegionName), region) ||
+		strings.EqualFold(stringValue(subscription.RegionKey), region)
+}
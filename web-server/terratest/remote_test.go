@@ -0,0 +1,240 @@
+package terratest
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+const (
+	sshPort        = "22"
+	sshDialTimeout = 30 * time.Second
+	// extra time for the remote `timeout` to kill the command before we give up locally
+	remoteTimeoutGrace = 10 * time.Second
+)
+
+// RemoteOptions modify how RunRemote executes the command.
+type RemoteOptions struct {
+	// Sudo runs the command as root (sudo -n)
+	Sudo bool
+	// Timeout kills the command on the host when exceeded, 0 means no timeout
+	Timeout time.Duration
+	// Env is exported to the command, sshd usually refuses session env vars
+	Env map[string]string
+}
+
+// RemoteResult is the outcome of a command executed by RunRemote.
+type RemoteResult struct {
+	Host     string
+	Command  string
+	ExitCode int
+	Stdout   string
+	Stderr   string
+	Duration time.Duration
+}
+
+// RemoteTimeoutError is returned by RunRemoteE when the command did not finish within its Timeout.
+type RemoteTimeoutError struct {
+	Host    string
+	Command string
+	Timeout time.Duration
+}
+
+func (e RemoteTimeoutError) Error() string {
+	return fmt.Sprintf("command %q on %s did not finish within %s", e.Command, e.Host, e.Timeout)
+}
+
+// RunRemote runs command on host (the bastion directly, other hosts through the bastion).
+// Connection errors are retried, a timeout is not, so a hung command runs once.
+// A non-zero exit code is not an error, check ExitCode.
+func RunRemote(t *testing.T, host ssh.Host, command string, opts RemoteOptions) *RemoteResult {
+	description := fmt.Sprintf("run %q on %s", command, host.Hostname)
+	var result *RemoteResult
+	defer profiler.Record(opRetry, profileName(command), time.Now())
+
+	retry.DoWithRetry(t, description, maxRetries, sleepBetweenRetries, func() (string, error) {
+		var err error
+		result, err = RunRemoteE(t, host, command, opts)
+		if _, ok := err.(RemoteTimeoutError); ok {
+			return "", retry.FatalError{Underlying: err}
+		}
+		return "", err
+	})
+	return result
+}
+
+// RunRemoteE runs command on host once, returns error only when the command could not be run at all.
+func RunRemoteE(t *testing.T, host ssh.Host, command string, opts RemoteOptions) (*RemoteResult, error) {
+	client, closeClient, err := dialHost(t, host)
+	if err != nil {
+		return nil, err
+	}
+	defer closeClient()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+
+	result := &RemoteResult{Host: host.Hostname, Command: command}
+	start := time.Now()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Run(remoteScript(command, opts))
+	}()
+
+	var runErr error
+	if opts.Timeout > 0 {
+		select {
+		case runErr = <-done:
+		case <-time.After(opts.Timeout + remoteTimeoutGrace):
+			err := RemoteTimeoutError{Host: host.Hostname, Command: command, Timeout: opts.Timeout}
+			recordSession(t, SessionEntry{Host: host.Hostname, Command: command, Env: copyEnv(opts.Env)}, err)
+			return nil, err
+		}
+	} else {
+		runErr = <-done
+	}
+
+	result.Duration = time.Since(start)
+	profiler.Record(opSsh, profileName(command), start)
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+
+	entry := SessionEntry{Host: host.Hostname, Command: command, Env: copyEnv(opts.Env), Output: result.Stdout + result.Stderr}
+	switch err := runErr.(type) {
+	case nil:
+		result.ExitCode = 0
+	case *gossh.ExitError:
+		result.ExitCode = err.ExitStatus()
+	default:
+		recordSession(t, entry, err)
+		return nil, err
+	}
+	entry.ExitCode = &result.ExitCode
+	recordSession(t, entry, nil)
+
+	t.Logf("%s on %s: exit code %d in %s", command, host.Hostname, result.ExitCode, result.Duration)
+	return result, nil
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func copyEnv(env map[string]string) map[string]string {
+	if len(env) == 0 {
+		return nil
+	}
+	copied := map[string]string{}
+	for name, value := range env {
+		copied[name] = value
+	}
+	return copied
+}
+
+// remoteScript wraps command with env exports, timeout and sudo.
+func remoteScript(command string, opts RemoteOptions) string {
+	script := command
+
+	if len(opts.Env) > 0 {
+		names := []string{}
+		for name := range opts.Env {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		exports := []string{}
+		for _, name := range names {
+			exports = append(exports, fmt.Sprintf("export %s=%s;", name, shellQuote(opts.Env[name])))
+		}
+		script = strings.Join(exports, " ") + " " + script
+	}
+
+	if opts.Timeout > 0 {
+		script = fmt.Sprintf("timeout %d sh -c %s", timeoutSeconds(opts.Timeout), shellQuote(script))
+	}
+
+	if opts.Sudo {
+		script = "sudo -n sh -c " + shellQuote(script)
+	}
+	return script
+}
+
+// timeoutSeconds rounds the timeout up to whole seconds, timeout 0 would disable the timeout.
+func timeoutSeconds(timeout time.Duration) int {
+	return int((timeout + time.Second - 1) / time.Second)
+}
+
+// shellQuote quotes s for POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'"'"'`, -1) + "'"
+}
+
+// dialHost connects to host, via bastion when host is not the bastion itself (and via SSH_JUMP_HOST when set).
+func dialHost(t *testing.T, host ssh.Host) (*gossh.Client, func(), error) {
+	bastion := bastionHost(t)
+
+	bastionConfig, err := sshClientConfig(t, bastion)
+	if err != nil {
+		return nil, nil, err
+	}
+	bastionClient, closeBastion, err := dialBastion(t, bastion, bastionConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if host.Hostname == bastion.Hostname {
+		return bastionClient, closeBastion, nil
+	}
+
+	address := net.JoinHostPort(host.Hostname, sshPort)
+	conn, err := bastionClient.Dial("tcp", address)
+	if err != nil {
+		closeBastion()
+		return nil, nil, err
+	}
+
+	config, err := sshClientConfig(t, host)
+	if err != nil {
+		closeBastion()
+		return nil, nil, err
+	}
+	clientConn, chans, reqs, err := gossh.NewClientConn(conn, address, config)
+	if err != nil {
+		closeBastion()
+		return nil, nil, err
+	}
+
+	client := gossh.NewClient(clientConn, chans, reqs)
+	return client, func() {
+		client.Close()
+		closeBastion()
+	}, nil
+}
+
+func sshClientConfig(t *testing.T, host ssh.Host) (*gossh.ClientConfig, error) {
+	auth, err := hostAuth(host)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gossh.ClientConfig{
+		User:            host.SshUserName,
+		Auth:            []gossh.AuthMethod{auth},
+		HostKeyCallback: hostKeyCallback(t),
+		Timeout:         sshDialTimeout,
+	}, nil
+}
@@ -0,0 +1,211 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/core"
+	"github.com/oracle/oci-go-sdk/objectstorage"
+)
+
+const (
+	defaultReplicationMaxAge = 24 * time.Hour
+)
+
+// ReplicationExpectation is the cross-region replication of the stack to a DR region.
+type ReplicationExpectation struct {
+	// Region is the secondary region, e.g. us-phoenix-1
+	Region string `json:"region"`
+	// MaxAgeHours is the max age of the latest replicated copy, 24 when 0
+	MaxAgeHours int `json:"maxAgeHours"`
+	// BootVolumeBackups requires copies of the boot volume backups of all instances in the region
+	BootVolumeBackups bool `json:"bootVolumeBackups"`
+	// Buckets are the source buckets replicated to the region
+	Buckets []string `json:"buckets"`
+}
+
+// regionClients are the clients of the services checked in the secondary region.
+type regionClients struct {
+	region        string
+	blockstorage  core.BlockstorageClient
+	objectStorage objectstorage.ObjectStorageClient
+}
+
+func checkBootVolumeBackupReplication(t *testing.T) {
+	expected := loadExpectations(t).Replication
+	if expected == nil || !expected.BootVolumeBackups {
+		t.Skip("no replication bootVolumeBackups in expectations")
+	}
+	maxAge := replicationMaxAge(expected)
+	dr := newRegionClients(t, expected.Region)
+	compute := computeClient(t)
+	blockstorage := blockstorageClient(t)
+
+	for tier, output := range tierOutputs {
+		for _, instanceID := range outputValues(t, output) {
+			instance := getInstance(t, compute, instanceID)
+			name := fmt.Sprintf("%s instance %s", tier, stringValue(instance.DisplayName))
+			bootVolumeID := instanceBootVolumeId(t, compute, instance)
+
+			backups, err := blockstorage.ListBootVolumeBackups(context.Background(), core.ListBootVolumeBackupsRequest{
+				CompartmentId: instance.CompartmentId,
+				BootVolumeId:  &bootVolumeID,
+			})
+			if err != nil {
+				t.Fatalf("error in listing boot volume backups of %s: %s", name, err.Error())
+			}
+
+			var latest time.Time
+			for _, backup := range backups.Items {
+				copies, err := dr.blockstorage.ListBootVolumeBackups(context.Background(), core.ListBootVolumeBackupsRequest{
+					CompartmentId:            instance.CompartmentId,
+					SourceBootVolumeBackupId: backup.Id,
+					LifecycleState:           core.BootVolumeBackupLifecycleStateAvailable,
+				})
+				if err != nil {
+					t.Fatalf("error in listing copies of boot volume backup %s in %s: %s", *backup.Id, dr.region, err.Error())
+				}
+				for _, backupCopy := range copies.Items {
+					if backupCopy.TimeCreated.Time.After(latest) {
+						latest = backupCopy.TimeCreated.Time
+					}
+				}
+			}
+
+			// assertions
+			if latest.IsZero() {
+				t.Errorf("%s: no copy of its boot volume backups in %s", name, dr.region)
+				continue
+			}
+			age := time.Since(latest)
+			report.AddMetric("age of boot volume backup copy "+name, age.Round(time.Minute))
+			if age > maxAge {
+				t.Errorf("%s: latest boot volume backup copy in %s is %s old, expected at most %s", name, dr.region, age.Round(time.Minute), maxAge)
+			}
+		}
+	}
+}
+
+// checkBucketReplication verifies the replication policies of the buckets are active and synced recently, and the
+// latest object synced from the source bucket is in the destination bucket.
+func checkBucketReplication(t *testing.T) {
+	expected := loadExpectations(t).Replication
+	if expected == nil || len(expected.Buckets) == 0 {
+		t.Skip("no replication buckets in expectations")
+	}
+	maxAge := replicationMaxAge(expected)
+	dr := newRegionClients(t, expected.Region)
+	source := objectStorageClient(t)
+	namespace := objectStorageNamespace(t)
+
+	for _, bucket := range expected.Buckets {
+		policy, ok := bucketReplicationPolicy(t, source, namespace, bucket, dr.region)
+		if !ok {
+			t.Errorf("bucket %s has no replication policy to %s", bucket, dr.region)
+			continue
+		}
+		destination := *policy.DestinationBucketName
+		lastSync := policy.TimeLastSync.Time
+
+		// assertions
+		if policy.Status != objectstorage.ReplicationPolicySummaryStatusActive {
+			t.Errorf("replication policy %s of bucket %s in status %s: %s", stringValue(policy.Name), bucket, policy.Status, stringValue(policy.StatusMessage))
+		}
+		age := time.Since(lastSync)
+		report.AddMetric("age of replication of bucket "+bucket, age.Round(time.Minute))
+		if age > maxAge {
+			t.Errorf("bucket %s last synced to %s/%s %s ago, expected at most %s", bucket, dr.region, destination, age.Round(time.Minute), maxAge)
+		}
+
+		sources, err := dr.objectStorage.ListReplicationSources(context.Background(), objectstorage.ListReplicationSourcesRequest{
+			NamespaceName: &namespace,
+			BucketName:    &destination,
+		})
+		if err != nil {
+			t.Fatalf("error in listing replication sources of %s in %s: %s", destination, dr.region, err.Error())
+		}
+		replicated := false
+		for _, replicationSource := range sources.Items {
+			if stringValue(replicationSource.SourceBucketName) == bucket {
+				replicated = true
+			}
+		}
+		if !replicated {
+			t.Errorf("bucket %s in %s does not list %s as its replication source", destination, dr.region, bucket)
+		}
+
+		object, ok := latestObjectBefore(t, source, namespace, bucket, lastSync)
+		if !ok {
+			continue
+		}
+		if _, err := dr.objectStorage.HeadObject(context.Background(), objectstorage.HeadObjectRequest{
+			NamespaceName: &namespace,
+			BucketName:    &destination,
+			ObjectName:    &object,
+		}); err != nil {
+			t.Errorf("object %s of bucket %s is not replicated to %s/%s: %s", object, bucket, dr.region, destination, err.Error())
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func newRegionClients(t *testing.T, region string) regionClients {
+	if region == "" {
+		t.Fatal("no region of the replication in expectations")
+	}
+	clients := regionClients{region: region, blockstorage: blockstorageClient(t), objectStorage: objectStorageClient(t)}
+	clients.blockstorage.SetRegion(region)
+	clients.objectStorage.SetRegion(region)
+	return clients
+}
+
+func replicationMaxAge(expected *ReplicationExpectation) time.Duration {
+	if expected.MaxAgeHours == 0 {
+		return defaultReplicationMaxAge
+	}
+	return time.Duration(expected.MaxAgeHours) * time.Hour
+}
+
+func bucketReplicationPolicy(t *testing.T, client objectstorage.ObjectStorageClient, namespace string, bucket string,
+	region string) (objectstorage.ReplicationPolicySummary, bool) {
+	response, err := client.ListReplicationPolicies(context.Background(), objectstorage.ListReplicationPoliciesRequest{
+		NamespaceName: &namespace,
+		BucketName:    &bucket,
+	})
+	if err != nil {
+		t.Fatalf("error in listing replication policies of bucket %s: %s", bucket, err.Error())
+	}
+	for _, policy := range response.Items {
+		if stringValue(policy.DestinationRegionName) == region {
+			return policy, true
+		}
+	}
+	return objectstorage.ReplicationPolicySummary{}, false
+}
+
+// latestObjectBefore returns the name of the newest object created before the time, of the first page of the bucket.
+func latestObjectBefore(t *testing.T, client objectstorage.ObjectStorageClient, namespace string, bucket string, before time.Time) (string, bool) {
+	fields := "name,timeCreated"
+	response, err := client.ListObjects(context.Background(), objectstorage.ListObjectsRequest{
+		NamespaceName: &namespace,
+		BucketName:    &bucket,
+		Fields:        &fields,
+	})
+	if err != nil {
+		t.Fatalf("error in listing objects of bucket %s: %s", bucket, err.Error())
+	}
+
+	name, latest := "", time.Time{}
+	for _, object := range response.Objects {
+		if object.TimeCreated == nil || !object.TimeCreated.Time.Before(before) {
+			continue
+		}
+		if object.TimeCreated.Time.After(latest) {
+			name, latest = *object.Name, object.TimeCreated.Time
+		}
+	}
+	return name, name != ""
+}
@@ -0,0 +1,219 @@
+package terratest
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	defaultReportDir = "report"
+	reportJSONFile   = "report.json"
+	reportHTMLFile   = "report.html"
+)
+
+var (
+	report = &Report{Started: time.Now()}
+
+	reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Terratest report {{.Started.Format "2006-01-02 15:04:05"}}</title></head>
+<body>
+<h1>Terratest report</h1>
+<p>Started: {{.Started.Format "2006-01-02 15:04:05"}}, finished: {{.Finished.Format "2006-01-02 15:04:05"}}</p>
+{{if .Status}}<h2 style="color: red">{{.Status}}</h2>
+<p>The run exceeded its budget, remaining checks were skipped. Slowest phases:</p>
+<table border="1">
+<tr><th>Phase</th><th>Seconds</th><th>Finished</th></tr>
+{{range .SlowestPhases}}<tr><td><b>{{.Name}}</b></td><td>{{printf "%.0f" .Seconds}}</td><td>{{.Finished}}</td></tr>
+{{end}}</table>
+{{end}}{{if .Results}}<h2>Checks</h2>
+<table border="1">
+<tr><th>Name</th><th>Result</th><th>Attempts</th><th>Failure rate</th><th>Quarantined</th><th>Seconds</th><th>Breakdown</th></tr>
+{{range .Results}}<tr><td>{{.Name}}</td><td>{{.Result}}</td><td>{{.Attempts}}</td><td>{{printf "%.0f%%" .Percent}}</td><td>{{if .Quarantined}}yes{{end}}</td><td>{{printf "%.1f" .Seconds}}</td><td>{{range $kind, $seconds := .Breakdown}}{{$kind}} {{printf "%.1f" $seconds}}s {{end}}</td></tr>
+{{end}}</table>
+{{end}}{{if .Profile}}<h2>Slowest operations</h2>
+<table border="1">
+<tr><th>Kind</th><th>Operation</th><th>Calls</th><th>Total seconds</th><th>Max seconds</th></tr>
+{{range .Profile}}<tr><td>{{.Kind}}</td><td>{{.Name}}</td><td>{{.Calls}}</td><td>{{printf "%.1f" .TotalSeconds}}</td><td>{{printf "%.1f" .MaxSeconds}}</td></tr>
+{{end}}</table>
+{{end}}<h2>Metrics</h2>
+<table border="1">
+<tr><th>Name</th><th>Value</th></tr>
+{{range .Metrics}}<tr><td>{{.Name}}</td><td>{{.Value}}</td></tr>
+{{end}}</table>
+{{if .Links}}<h2>Resources</h2>
+<table border="1">
+<tr><th>Name</th><th>OCID</th></tr>
+{{range .Links}}<tr><td>{{.Name}}</td><td><a href="{{.URL}}">{{.OCID}}</a></td></tr>
+{{end}}</table>
+{{end}}{{if .Attachments}}<h2>Attachments</h2>
+{{range .Attachments}}<h3>{{.Name}}</h3>
+<pre>{{.Content}}</pre>
+{{end}}{{end}}</body>
+</html>
+`))
+)
+
+// Report collects results of the run which are not visible in the test output.
+type Report struct {
+	mu       sync.Mutex
+	Started  time.Time
+	Finished time.Time
+	// Status is TIMED_OUT when the run exceeded RUN_BUDGET
+	Status string
+	// SlowestPhases of a timed out run
+	SlowestPhases []SummaryPhase
+	Metrics       []ReportMetric
+	// Profile ranks the operations of the run by their total time
+	Profile []ProfileEntry
+	// Results of the checks, FAIL or FLAKY when RERUN_FAILED_CHECKS passed on a rerun
+	Results []ReportResult
+	// Attachments are e.g. logs collected on failures
+	Attachments []ReportAttachment
+	// Links are OCI Console URLs of resources seen during the run
+	Links []ReportLink
+}
+
+// ReportMetric is one measured value, e.g. time to healthy.
+type ReportMetric struct {
+	Name  string
+	Value string
+}
+
+// ReportResult is the result of a check of the run.
+type ReportResult struct {
+	Name     string
+	Result   string
+	Attempts int
+	// FailureRate is the share of recent runs the check did not pass at the first attempt
+	FailureRate float64
+	// Quarantined checks were repeatedly flaky, their failures are likely infra noise
+	Quarantined bool
+	Seconds     float64
+	// Breakdown are the seconds of the check by kind of operation, e.g. ssh or api
+	Breakdown map[string]float64
+}
+
+// ReportAttachment is a named text content, e.g. host log.
+type ReportAttachment struct {
+	Name    string
+	Content string
+}
+
+// ReportLink is an OCI Console URL of a resource.
+type ReportLink struct {
+	Name string
+	OCID string
+	URL  string
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	closeSessionLog()
+	if !summary.Ran() {
+		// keep the report of the last run of the stack
+		os.Exit(code)
+	}
+
+	report.Finished = time.Now()
+	report.SetProfile(profiler.Slowest(profileTopOperations))
+	if budgetExceeded() {
+		summary.SetTimedOut()
+		report.MarkTimedOut(summary.SlowestPhases(slowestPhasesCount))
+	}
+	if err := report.Write(reportDir()); err != nil {
+		fmt.Fprintf(os.Stderr, "error in writing report: %s\n", err.Error())
+	}
+
+	code = summary.ExitCodeOf(code)
+	if err := summary.Write(reportDir(), code); err != nil {
+		fmt.Fprintf(os.Stderr, "error in writing exit summary: %s\n", err.Error())
+	}
+
+	os.Exit(code)
+}
+
+// AddMetric records a named value in the report.
+func (r *Report) AddMetric(name string, value interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Metrics = append(r.Metrics, ReportMetric{Name: name, Value: fmt.Sprint(value)})
+}
+
+// AddResult records the result of a check in the report.
+func (r *Report) AddResult(result ReportResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Results = append(r.Results, result)
+}
+
+// Percent is the failure rate in percent.
+func (r ReportResult) Percent() float64 {
+	return r.FailureRate * 100
+}
+
+// SetProfile records the slowest operations of the run.
+func (r *Report) SetProfile(profile []ProfileEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Profile = profile
+}
+
+// AddAttachment records a named text content in the report.
+func (r *Report) AddAttachment(name string, content string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Attachments = append(r.Attachments, ReportAttachment{Name: name, Content: content})
+}
+
+// AddLink records a console link, returns false when the OCID is already linked.
+func (r *Report) AddLink(name string, ocid string, url string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, link := range r.Links {
+		if link.OCID == ocid {
+			return false
+		}
+	}
+	r.Links = append(r.Links, ReportLink{Name: name, OCID: ocid, URL: url})
+	return true
+}
+
+// Write stores the report as json and html into dir.
+func (r *Report) Write(dir string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, reportJSONFile), content, 0644); err != nil {
+		return err
+	}
+
+	f, err := os.Create(filepath.Join(dir, reportHTMLFile))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return reportTemplate.Execute(f, r)
+}
+
+func reportDir() string {
+	if dir := os.Getenv("REPORT_DIR"); dir != "" {
+		return dir
+	}
+	return defaultReportDir
+}
@@ -0,0 +1,190 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/oracle/oci-go-sdk/core"
+)
+
+const (
+	// max share of failed LB requests during rolling replacement
+	rollingMaxErrorRate = 0.01
+	lbPollInterval      = 500 * time.Millisecond
+	// web server replaced by the rolling scenario
+	rollingInstance = "oci_core_instance.WebServer[0]"
+	rollingBackend  = "oci_load_balancer_backend.lb-backend-web[0]"
+)
+
+var (
+	// terraform addresses of the compute layer
+	computeTargets = []string{"oci_core_instance.WebServer", "oci_core_instance.Bastion"}
+)
+
+// lbPoller requests the LB in the background and counts failed requests.
+type lbPoller struct {
+	url    string
+	stop   chan struct{}
+	done   chan struct{}
+	mu     sync.Mutex
+	total  int
+	failed int
+	errors []string
+}
+
+func scenarioReservedIpsSurviveReplacement(t *testing.T) {
+	requireScenarios(t)
+	network := virtualNetworkClient(t)
+
+	addresses := append(outputValues(t, "BastionPublicIP"), outputValues(t, "lb_ip")...)
+	reserved := map[string]string{}
+	for _, address := range addresses {
+		ip, ok := reservedPublicIp(network, address)
+		if ok {
+			reserved[address] = *ip.Id
+		}
+	}
+	if len(reserved) == 0 {
+		t.Skip("stack uses no reserved public IPs")
+	}
+	t.Logf("reserved public IPs before replacement: %v", reserved)
+
+	DestroyTarget(t, computeTargets...)
+	withStateLock(t, "apply", func() (string, error) {
+		return terraform.ApplyE(t, options)
+	})
+	revalidateOutputs(t)
+
+	// assertions
+	for address, id := range reserved {
+		ip, ok := reservedPublicIp(network, address)
+		if !ok {
+			t.Errorf("reserved public IP %s (%s) lost after compute replacement", address, id)
+			continue
+		}
+		if *ip.Id != id {
+			t.Errorf("reserved public IP %s changed: expected %s, got %s", address, id, *ip.Id)
+		}
+	}
+
+	current := map[string]bool{}
+	for _, address := range append(outputValues(t, "BastionPublicIP"), outputValues(t, "lb_ip")...) {
+		current[address] = true
+	}
+	for address := range reserved {
+		if !current[address] {
+			t.Errorf("reserved public IP %s is no longer used by the stack", address)
+		}
+	}
+}
+
+func scenarioRollingReplacement(t *testing.T) {
+	requireScenarios(t)
+
+	webCount := len(outputValues(t, "WebServerPrivateIPs"))
+	if webCount < 2 {
+		t.Skipf("rolling replacement needs at least 2 web servers, got %d", webCount)
+	}
+
+	poller := startLbPoller("http://" + outputValues(t, "lb_ip")[0] + "/")
+	replacedAt := time.Now()
+
+	withStateLock(t, "taint", func() (string, error) {
+		return terraform.RunTerraformCommandE(t, options, "taint", rollingInstance)
+	})
+	ApplyTarget(t, rollingInstance, rollingBackend)
+	// backend set updates finish asynchronously, then the LB health check picks up the new backend
+	WaitForLoadBalancerWorkRequests(t, terraform.Output(t, options, "lb_id"), replacedAt, defaultWorkRequestTimeout)
+	WaitForHealthy(t, poller.url, http.StatusOK, healthySLO(t))
+
+	total, failed, errors := poller.Stop()
+	if total == 0 {
+		t.Fatalf("no requests to %s were made during replacement", poller.url)
+	}
+	rate := float64(failed) / float64(total)
+	t.Logf("requests during replacement: %d, failed: %d (%.2f%%)", total, failed, rate*100)
+	report.AddMetric("rolling replacement error rate", fmt.Sprintf("%.2f%% of %d requests", rate*100, total))
+
+	// assertions
+	if rate > rollingMaxErrorRate {
+		t.Fatalf("error rate %.2f%% during rolling replacement exceeds %.2f%%, errors: %v",
+			rate*100, rollingMaxErrorRate*100, errors)
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func startLbPoller(url string) *lbPoller {
+	p := &lbPoller{url: url, stop: make(chan struct{}), done: make(chan struct{})}
+	client := &http.Client{Timeout: httpTimeout}
+
+	go func() {
+		defer close(p.done)
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-time.After(lbPollInterval):
+			}
+
+			err := lbRequest(client, url)
+			p.mu.Lock()
+			p.total++
+			if err != nil {
+				p.failed++
+				p.errors = append(p.errors, err.Error())
+			}
+			p.mu.Unlock()
+		}
+	}()
+	return p
+}
+
+// Stop ends polling and returns count of all and failed requests and the errors.
+func (p *lbPoller) Stop() (int, int, []string) {
+	close(p.stop)
+	<-p.done
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.total, p.failed, p.errors
+}
+
+func lbRequest(client *http.Client, url string) error {
+	response, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", response.StatusCode)
+	}
+	return nil
+}
+
+// requireScenarios skips destructive scenarios unless enabled by RUN_SCENARIOS=1 on a stack applied by this run.
+func requireScenarios(t *testing.T) {
+	if os.Getenv("RUN_SCENARIOS") == "" {
+		t.Skip("scenarios are enabled by RUN_SCENARIOS=1")
+	}
+	if appliedAt.IsZero() {
+		t.Skip("scenarios need the stack applied by this run")
+	}
+}
+
+func reservedPublicIp(network core.VirtualNetworkClient, address string) (core.PublicIp, bool) {
+	response, err := network.GetPublicIpByIpAddress(context.Background(), core.GetPublicIpByIpAddressRequest{
+		GetPublicIpByIpAddressDetails: core.GetPublicIpByIpAddressDetails{IpAddress: &address},
+	})
+	if err != nil || response.PublicIp.Lifetime != core.PublicIpLifetimeReserved {
+		return core.PublicIp{}, false
+	}
+	return response.PublicIp, true
+}
@@ -0,0 +1,147 @@
+package terratest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+const (
+	// SECRETS_SCAN modes, a found secret fails the run by default
+	secretsScanRedact = "redact"
+	secretsScanOff    = "off"
+)
+
+var (
+	// plan of the run in JSON, kept by planStack for the secrets scan
+	lastPlan struct {
+		sync.Mutex
+		json string
+	}
+)
+
+// SecretFinding is a secret found in an artifact of the run, the secret itself is never reported.
+type SecretFinding struct {
+	Artifact string
+	Line     int
+}
+
+// scanSecrets checks the terraform outputs, the plan and the logs collected by the run for private keys,
+// passwords and auth tokens, so that they do not leak into CI artifacts. Found secrets fail the run,
+// with SECRETS_SCAN=redact the logs are redacted instead and SECRETS_SCAN=off disables the scan.
+func scanSecrets(t *testing.T) {
+	mode := os.Getenv("SECRETS_SCAN")
+	if mode == secretsScanOff {
+		t.Skip("secrets scan is disabled by SECRETS_SCAN=off")
+	}
+
+	findings := []SecretFinding{}
+	outputs, err := terraform.RunTerraformCommandAndGetStdoutE(t, options, "output", "-json")
+	if err != nil {
+		t.Errorf("error in reading terraform outputs: %s", err.Error())
+	} else {
+		findings = append(findings, scanOutputs(t, outputs)...)
+	}
+
+	lastPlan.Lock()
+	findings = append(findings, findSecrets("terraform plan", lastPlan.json)...)
+	lastPlan.Unlock()
+
+	findings = append(findings, report.scanAttachments(mode == secretsScanRedact)...)
+	findings = append(findings, scanDir(t, diagnosticsDir(), mode == secretsScanRedact)...)
+
+	// assertions
+	for _, finding := range findings {
+		t.Errorf("secret in %s, line %d", finding.Artifact, finding.Line)
+	}
+}
+
+// scanAttachments finds secrets in the attachments of the report, and redacts them when asked.
+// Redacted attachments are not reported.
+func (r *Report) scanAttachments(redactSecrets bool) []SecretFinding {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	findings := []SecretFinding{}
+	for i, attachment := range r.Attachments {
+		found := findSecrets("report attachment "+attachment.Name, attachment.Content)
+		if redactSecrets && len(found) > 0 {
+			r.Attachments[i].Content = redact(attachment.Content)
+			continue
+		}
+		findings = append(findings, found...)
+	}
+	return findings
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// findSecrets returns the lines of text with secrets.
+func findSecrets(artifact string, text string) []SecretFinding {
+	findings := []SecretFinding{}
+	for _, pattern := range secretPatterns {
+		for _, match := range pattern.FindAllStringIndex(text, -1) {
+			line := strings.Count(text[:match[0]], "\n") + 1
+			findings = append(findings, SecretFinding{Artifact: artifact, Line: line})
+		}
+	}
+	return findings
+}
+
+// scanOutputs finds secrets in outputs not marked sensitive, terraform hides sensitive outputs in its logs.
+func scanOutputs(t *testing.T, outputs string) []SecretFinding {
+	values := map[string]StateOutput{}
+	if err := json.Unmarshal([]byte(outputs), &values); err != nil {
+		t.Errorf("error in parsing terraform outputs: %s", err.Error())
+		return nil
+	}
+
+	findings := []SecretFinding{}
+	for name, output := range values {
+		if output.Sensitive {
+			continue
+		}
+		value, err := json.MarshalIndent(output.Value, "", "  ")
+		if err != nil {
+			t.Errorf("error in reading output %s: %s", name, err.Error())
+			continue
+		}
+		findings = append(findings, findSecrets(fmt.Sprintf("output %s", name), string(value))...)
+	}
+	return findings
+}
+
+// scanDir finds secrets in the files of dir, and redacts them when asked. Redacted files are not reported.
+func scanDir(t *testing.T, dir string, redactSecrets bool) []SecretFinding {
+	findings := []SecretFinding{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		found := findSecrets(path, string(content))
+		if redactSecrets && len(found) > 0 {
+			return ioutil.WriteFile(path, []byte(redact(string(content))), info.Mode())
+		}
+		findings = append(findings, found...)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("error in scanning %s for secrets: %s", dir, err.Error())
+	}
+	return findings
+}
@@ -0,0 +1,344 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/core"
+)
+
+const (
+	ingress = "INGRESS"
+	egress  = "EGRESS"
+	// protocol of rules allowing everything
+	allProtocols = "all"
+	// source and destination types of security list and NSG rules
+	cidrPeer    = "CIDR_BLOCK"
+	servicePeer = "SERVICE_CIDR_BLOCK"
+	nsgPeer     = "NETWORK_SECURITY_GROUP"
+)
+
+var (
+	// instances of each tier are taken from these terraform outputs
+	tierOutputs = map[string]string{
+		"web":     "WebServerIDs",
+		"bastion": "BastionIDs",
+	}
+)
+
+// SecurityRule is an ingress or egress rule, no matter whether it comes from a security list or a NSG.
+type SecurityRule struct {
+	Direction string
+	// Protocol number as in OCI API ("6" tcp, "17" udp, "1" icmp) or "all"
+	Protocol string
+	// Peer is source of ingress or destination of egress rule, a CIDR or a service CIDR label (which contains
+	// no address of instances or the internet), NSG peers are resolved to one rule per member address
+	Peer string
+	// PortMin and PortMax of destination ports, 0 when the rule is for all ports
+	PortMin   int
+	PortMax   int
+	Stateless bool
+	// Origin is the security list or NSG defining the rule
+	Origin string
+}
+
+// VnicRules are effective security rules of one VNIC: security lists of its subnet and its NSGs.
+type VnicRules struct {
+	VnicID    string
+	SubnetID  string
+	PrivateIP string
+	// PublicIP is empty for private VNICs
+	PublicIP string
+	// IPv6 addresses of VNICs in dual-stack subnets
+	Ipv6  []core.Ipv6
+	Rules []SecurityRule
+}
+
+// Allows reports whether the rule permits traffic of protocol to port from (ingress) or to (egress) peer.
+func (r SecurityRule) Allows(direction string, protocol string, port int, peer string) bool {
+	if r.Direction != direction {
+		return false
+	}
+	if r.Protocol != allProtocols && r.Protocol != protocol {
+		return false
+	}
+	if r.PortMin != 0 && (port < r.PortMin || port > r.PortMax) {
+		return false
+	}
+	return cidrContains(r.Peer, peer)
+}
+
+// Allows reports whether any of the rules permits the traffic.
+func (v VnicRules) Allows(direction string, protocol string, port int, peer string) bool {
+	for _, rule := range v.Rules {
+		if rule.Allows(direction, protocol, port, peer) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkSecurityPolicies(t *testing.T) {
+	policies := loadExpectations(t).SecurityPolicies
+	if len(policies) == 0 {
+		t.Skip("no security policies in expectations")
+	}
+
+	for tier, policy := range policies {
+		output, ok := tierOutputs[tier]
+		if !ok {
+			t.Fatalf("unknown tier %q in expectations", tier)
+		}
+
+		for _, instanceID := range outputValues(t, output) {
+			for _, vnic := range instanceVnicRules(t, instanceID) {
+				for _, expected := range policy {
+					peer := resolvePeer(expected.Peer)
+					actual := vnic.Allows(expected.Direction, expected.Protocol, expected.Port, peer)
+					if actual != expected.Allowed {
+						t.Errorf("%s vnic %s (%s): %s protocol %s port %d peer %s: expected allowed %t, got %t",
+							tier, vnic.PrivateIP, vnic.VnicID, expected.Direction, expected.Protocol, expected.Port,
+							peer, expected.Allowed, actual)
+					}
+				}
+			}
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// instanceVnicRules returns effective rules of every attached VNIC of the instance.
+func instanceVnicRules(t *testing.T, instanceID string) []VnicRules {
+	network := virtualNetworkClient(t)
+
+	result := []VnicRules{}
+	for _, vnic := range instanceVnics(t, instanceID) {
+		rules := subnetSecurityListRules(t, network, *vnic.SubnetId)
+		for _, nsgID := range vnic.NsgIds {
+			rules = append(rules, nsgRules(t, network, nsgID)...)
+		}
+
+		result = append(result, VnicRules{
+			VnicID:    *vnic.Id,
+			SubnetID:  *vnic.SubnetId,
+			PrivateIP: *vnic.PrivateIp,
+			PublicIP:  stringValue(vnic.PublicIp),
+			Ipv6:      vnicIpv6s(t, network, *vnic.Id),
+			Rules:     rules,
+		})
+	}
+	return result
+}
+
+// instanceVnics returns attached VNICs of the instance.
+func instanceVnics(t *testing.T, instanceID string) []core.Vnic {
+	compute := computeClient(t)
+	network := virtualNetworkClient(t)
+	compartmentID := compartmentFor(computeCompartment)
+
+	attachments, err := compute.ListVnicAttachments(context.Background(), core.ListVnicAttachmentsRequest{
+		CompartmentId: &compartmentID,
+		InstanceId:    &instanceID,
+	})
+	if err != nil {
+		t.Fatalf("error in listing vnic attachments of %s: %s", instanceID, err.Error())
+	}
+
+	vnics := []core.Vnic{}
+	for _, attachment := range attachments.Items {
+		if attachment.LifecycleState != core.VnicAttachmentLifecycleStateAttached || attachment.VnicId == nil {
+			continue
+		}
+
+		vnic, err := network.GetVnic(context.Background(), core.GetVnicRequest{VnicId: attachment.VnicId})
+		if err != nil {
+			t.Fatalf("error in calling vnic %s: %s", *attachment.VnicId, err.Error())
+		}
+		vnics = append(vnics, vnic.Vnic)
+	}
+	return vnics
+}
+
+func subnetSecurityListRules(t *testing.T, network core.VirtualNetworkClient, subnetID string) []SecurityRule {
+	subnet, err := network.GetSubnet(context.Background(), core.GetSubnetRequest{SubnetId: &subnetID})
+	if err != nil {
+		t.Fatalf("error in calling subnet %s: %s", subnetID, err.Error())
+	}
+
+	rules := []SecurityRule{}
+	for _, id := range subnet.Subnet.SecurityListIds {
+		listID := id
+		list, err := network.GetSecurityList(context.Background(), core.GetSecurityListRequest{SecurityListId: &listID})
+		if err != nil {
+			t.Fatalf("error in calling security list %s: %s", id, err.Error())
+		}
+
+		origin := "security list " + *list.SecurityList.DisplayName
+		for _, r := range list.SecurityList.IngressSecurityRules {
+			requireCidrPeer(t, origin, string(r.SourceType))
+			min, max := portRange(r.TcpOptions, r.UdpOptions)
+			rules = append(rules, SecurityRule{
+				Direction: ingress, Protocol: *r.Protocol, Peer: *r.Source,
+				PortMin: min, PortMax: max, Stateless: boolValue(r.IsStateless), Origin: origin,
+			})
+		}
+		for _, r := range list.SecurityList.EgressSecurityRules {
+			requireCidrPeer(t, origin, string(r.DestinationType))
+			min, max := portRange(r.TcpOptions, r.UdpOptions)
+			rules = append(rules, SecurityRule{
+				Direction: egress, Protocol: *r.Protocol, Peer: *r.Destination,
+				PortMin: min, PortMax: max, Stateless: boolValue(r.IsStateless), Origin: origin,
+			})
+		}
+	}
+	return rules
+}
+
+func nsgRules(t *testing.T, network core.VirtualNetworkClient, nsgID string) []SecurityRule {
+	response, err := network.ListNetworkSecurityGroupSecurityRules(context.Background(), core.ListNetworkSecurityGroupSecurityRulesRequest{
+		NetworkSecurityGroupId: &nsgID,
+	})
+	if err != nil {
+		t.Fatalf("error in listing rules of NSG %s: %s", nsgID, err.Error())
+	}
+
+	rules := []SecurityRule{}
+	for _, r := range response.Items {
+		rule := SecurityRule{
+			Direction: string(r.Direction),
+			Protocol:  *r.Protocol,
+			Stateless: boolValue(r.IsStateless),
+			Origin:    "NSG " + nsgID,
+		}
+		peerType := ""
+		if r.Direction == core.SecurityRuleDirectionIngress && r.Source != nil {
+			rule.Peer, peerType = *r.Source, string(r.SourceType)
+		}
+		if r.Direction == core.SecurityRuleDirectionEgress && r.Destination != nil {
+			rule.Peer, peerType = *r.Destination, string(r.DestinationType)
+		}
+		rule.PortMin, rule.PortMax = portRange(r.TcpOptions, r.UdpOptions)
+
+		if peerType != nsgPeer {
+			requireCidrPeer(t, rule.Origin, peerType)
+			rules = append(rules, rule)
+			continue
+		}
+		rules = append(rules, nsgPeerRules(rule, nsgMemberCidrs(t, network, rule.Peer))...)
+	}
+	return rules
+}
+
+// nsgPeerRules replaces the NSG peer of the rule by the addresses of the NSG members, one rule per address.
+// A NSG without members allows nothing.
+func nsgPeerRules(rule SecurityRule, members []string) []SecurityRule {
+	rules := []SecurityRule{}
+	for _, member := range members {
+		resolved := rule
+		resolved.Peer = member
+		resolved.Origin = fmt.Sprintf("%s (peer NSG %s)", rule.Origin, rule.Peer)
+		rules = append(rules, resolved)
+	}
+	return rules
+}
+
+// nsgMemberCidrs returns host CIDRs of the VNICs in the NSG and subnet CIDRs of the LB when it is in the NSG,
+// the same addresses the evaluators use for instances and the LB.
+func nsgMemberCidrs(t *testing.T, network core.VirtualNetworkClient, nsgID string) []string {
+	response, err := network.ListNetworkSecurityGroupVnics(context.Background(), core.ListNetworkSecurityGroupVnicsRequest{
+		NetworkSecurityGroupId: &nsgID,
+	})
+	if err != nil {
+		t.Fatalf("error in listing vnics of NSG %s: %s", nsgID, err.Error())
+	}
+
+	members := []string{}
+	for _, member := range response.Items {
+		vnic, err := network.GetVnic(context.Background(), core.GetVnicRequest{VnicId: member.VnicId})
+		if err != nil {
+			t.Fatalf("error in calling vnic %s: %s", *member.VnicId, err.Error())
+		}
+		members = append(members, *vnic.Vnic.PrivateIp+"/32")
+		for _, address := range vnicIpv6s(t, network, *member.VnicId) {
+			members = append(members, *address.IpAddress+"/128")
+		}
+	}
+
+	lb := getLoadBalancer(t)
+	if containsString(lb.NetworkSecurityGroupIds, nsgID) {
+		for _, subnetID := range lb.SubnetIds {
+			subnet, err := network.GetSubnet(context.Background(), core.GetSubnetRequest{SubnetId: &subnetID})
+			if err != nil {
+				t.Fatalf("error in calling subnet %s: %s", subnetID, err.Error())
+			}
+			members = append(members, *subnet.Subnet.CidrBlock)
+		}
+	}
+	return members
+}
+
+// requireCidrPeer fails on rules whose peer the evaluators do not understand, rather than treating them as deny.
+func requireCidrPeer(t *testing.T, origin string, peerType string) {
+	switch peerType {
+	case "", cidrPeer, servicePeer:
+	default:
+		t.Fatalf("%s has a rule with unsupported peer type %q", origin, peerType)
+	}
+}
+
+// resolvePeer translates symbolic peers of expectations to CIDRs of the stack variables,
+// so that expectations hold also for stacks with different CIDRs (e.g. stamped copies).
+func resolvePeer(peer string) string {
+	switch peer {
+	case "vcn":
+		return stringVar("VCNCIDR", defaultVcnCidr)
+	case "private-subnet":
+		return stringVar("PrivateSubnetCIDR", "10.0.0.0/24")
+	case "bastion-subnet":
+		return listVar("BastionSubnetCIDRs", []string{"10.0.100.0/28"})[0]
+	case "lb-subnet":
+		return stringVar("LBSubnetCIDR", "10.0.200.0/28")
+	case internetTier:
+		return "0.0.0.0/0"
+	}
+	return peer
+}
+
+// portRange returns destination port range of tcp or udp options, 0, 0 for all ports.
+func portRange(tcp *core.TcpOptions, udp *core.UdpOptions) (int, int) {
+	var r *core.PortRange
+	if tcp != nil {
+		r = tcp.DestinationPortRange
+	}
+	if udp != nil {
+		r = udp.DestinationPortRange
+	}
+	if r == nil {
+		return 0, 0
+	}
+	return *r.Min, *r.Max
+}
+
+// cidrContains reports whether network outer contains address or network inner.
+func cidrContains(outer string, inner string) bool {
+	_, outerNet, err := net.ParseCIDR(outer)
+	if err != nil {
+		return false
+	}
+
+	innerIP, innerNet, err := net.ParseCIDR(inner)
+	if err != nil {
+		innerIP = net.ParseIP(inner)
+		if innerIP == nil {
+			return false
+		}
+		return outerNet.Contains(innerIP)
+	}
+
+	outerOnes, _ := outerNet.Mask.Size()
+	innerOnes, _ := innerNet.Mask.Size()
+	return outerNet.Contains(innerIP) && outerOnes <= innerOnes
+}
@@ -0,0 +1,115 @@
+package terratest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	securityTokenAuth = "security_token"
+	// minimal validity of the token at the start, a run with apply and destroy takes tens of minutes
+	minSecurityTokenValidity = 10 * time.Minute
+)
+
+// securityTokenEnabled is true for sessions of `oci session authenticate`, enabled by OCI_CLI_AUTH=security_token
+// like for the OCI CLI. The token file, key and fingerprint are taken from the OCI config profile.
+func securityTokenEnabled() bool {
+	return os.Getenv("OCI_CLI_AUTH") == securityTokenAuth
+}
+
+// securityTokenFile is the security_token_file of the OCI config profile.
+func securityTokenFile() string {
+	return expandHome(ociProfileValues["security_token_file"])
+}
+
+// readSecurityToken returns the current token, read on every request as `oci session refresh` rewrites the file.
+func readSecurityToken() (string, error) {
+	if securityTokenFile() == "" {
+		return "", fmt.Errorf("security_token_file missing in profile %s", ociProfile())
+	}
+	content, err := ioutil.ReadFile(securityTokenFile())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// securityTokenClaims are the claims of the token the suite uses.
+type securityTokenClaims struct {
+	Exp int64 `json:"exp"`
+	// Sub is the OCID of the user of the session
+	Sub string `json:"sub"`
+}
+
+// securityTokenExpiry returns the exp claim of the token, a JWT.
+func securityTokenExpiry(token string) (time.Time, error) {
+	claims, err := parseSecurityToken(token)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(claims.Exp, 0), nil
+}
+
+// securityTokenPrincipal returns the OCID of the user of the current session, the principal of its API calls.
+func securityTokenPrincipal() (string, error) {
+	token, err := readSecurityToken()
+	if err != nil {
+		return "", err
+	}
+	claims, err := parseSecurityToken(token)
+	if err != nil {
+		return "", err
+	}
+	if claims.Sub == "" {
+		return "", fmt.Errorf("no sub claim in security token")
+	}
+	return claims.Sub, nil
+}
+
+// securityTokenError describes a missing or (nearly) expired token, empty when the token is usable.
+func securityTokenError() string {
+	token, err := readSecurityToken()
+	if err != nil {
+		return err.Error()
+	}
+	expiry, err := securityTokenExpiry(token)
+	if err != nil {
+		return err.Error()
+	}
+	if time.Until(expiry) < minSecurityTokenValidity {
+		return fmt.Sprintf("expires at %s, run `oci session refresh --profile %s`", expiry.Format(time.RFC3339), ociProfile())
+	}
+	return ""
+}
+
+// securityTokenEnvVars configures the terraform OCI provider for the same session,
+// its auth and config_file_profile are read from OCI_* env vars.
+func securityTokenEnvVars() map[string]string {
+	if !securityTokenEnabled() {
+		return map[string]string{}
+	}
+	return map[string]string{
+		"OCI_AUTH":                "SecurityToken",
+		"OCI_CONFIG_FILE_PROFILE": ociProfile(),
+	}
+}
+
+// parseSecurityToken decodes the payload of the token, a JWT, without verifying it.
+func parseSecurityToken(token string) (securityTokenClaims, error) {
+	claims := securityTokenClaims{}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, fmt.Errorf("security token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return claims, err
+	}
+	err = json.Unmarshal(payload, &claims)
+	return claims, err
+}
@@ -0,0 +1,51 @@
+package terratest
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+// SelinuxExpectation is the SELinux mode and booleans of all hosts.
+type SelinuxExpectation struct {
+	// Mode as printed by getenforce, e.g. Enforcing
+	Mode string `json:"mode"`
+	// Booleans by tier, name to on/off
+	Booleans map[string]map[string]string `json:"booleans"`
+}
+
+func checkSelinux(t *testing.T) {
+	expected := loadExpectations(t).Selinux
+	if expected.Mode == "" {
+		t.Skip("no selinux in expectations")
+	}
+
+	for tier := range tierOutputs {
+		booleans := expected.Booleans[tier]
+		names := []string{}
+		for name := range booleans {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, host := range tierHosts(t, tier) {
+			mode := RunRemote(t, host, "getenforce", RemoteOptions{Timeout: sshCommandTimeout})
+			if actual := strings.TrimSpace(mode.Stdout); actual != expected.Mode {
+				t.Errorf("%s %s: wrong SELinux mode: expected %q, got %q", tier, host.Hostname, expected.Mode, actual)
+			}
+
+			for _, name := range names {
+				// getsebool prints "name --> on"
+				result := RunRemote(t, host, "getsebool "+shellQuote(name), RemoteOptions{Timeout: sshCommandTimeout})
+				fields := strings.Fields(result.Stdout)
+				if result.ExitCode != 0 || len(fields) != 3 {
+					t.Errorf("%s %s: error in reading SELinux boolean %s: %s%s", tier, host.Hostname, name, result.Stdout, result.Stderr)
+					continue
+				}
+				if fields[2] != booleans[name] {
+					t.Errorf("%s %s: wrong SELinux boolean %s: expected %q, got %q", tier, host.Hostname, name, booleans[name], fields[2])
+				}
+			}
+		}
+	}
+}
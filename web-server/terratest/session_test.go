@@ -0,0 +1,146 @@
+package terratest
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/ssh"
+)
+
+const (
+	redacted = "[REDACTED]"
+	// session log is evidence, nobody but the owner reads it
+	sessionLogMode = 0600
+)
+
+var (
+	sessionLog struct {
+		sync.Mutex
+		once sync.Once
+		file *os.File
+		err  error
+	}
+
+	// secrets that may appear in commands or their output
+	secretPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?s)-----BEGIN [A-Z ]*PRIVATE KEY-----.*?-----END [A-Z ]*PRIVATE KEY-----`),
+		regexp.MustCompile(`(?i)((?:password|passwd|secret|token|api[_-]?key)\s*[=:]\s*)\S+`),
+		regexp.MustCompile(`(?i)(authorization:\s*)[^\r\n]+`),
+		regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/-]{20,}=*`),
+		regexp.MustCompile(`(?i)(secret_access_key\s*[=:]\s*)\S+`),
+	}
+	// env var names of RemoteOptions.Env whose values are recorded redacted
+	secretEnvPattern = regexp.MustCompile(`(?i)password|passwd|secret|token|key`)
+)
+
+// SessionEntry is one command recorded in the session log, the log has one JSON entry per line.
+type SessionEntry struct {
+	Time     time.Time         `json:"time"`
+	Test     string            `json:"test"`
+	Host     string            `json:"host"`
+	Via      string            `json:"via,omitempty"`
+	Command  string            `json:"command"`
+	Env      map[string]string `json:"env,omitempty"`
+	ExitCode *int              `json:"exitCode,omitempty"`
+	Output   string            `json:"output"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// sshCommandE runs command on the bastion (or any public host) and records it.
+// RunRemote records the commands itself when host keys are verified or a jump host is used.
+func sshCommandE(t *testing.T, host ssh.Host, command string) (string, error) {
+	if hostKeyMode() != hostKeysInsecure || sshJumpEnabled() {
+		return verifiedCommandE(t, host, command)
+	}
+
+	start := time.Now()
+	out, err := ssh.CheckSshCommandE(t, host, command)
+	profiler.Record(opSsh, profileName(command), start)
+	recordSession(t, SessionEntry{Host: host.Hostname, Command: command, Output: out}, err)
+	return out, err
+}
+
+// jumpSshCommandE runs command on a private host through the bastion and records it.
+func jumpSshCommandE(t *testing.T, bastion ssh.Host, host ssh.Host, command string) (string, error) {
+	if hostKeyMode() != hostKeysInsecure || sshJumpEnabled() {
+		// always through the bastion of the stack
+		return verifiedCommandE(t, host, command)
+	}
+
+	start := time.Now()
+	out, err := ssh.CheckPrivateSshConnectionE(t, bastion, host, command)
+	profiler.Record(opSsh, profileName(command), start)
+	recordSession(t, SessionEntry{Host: host.Hostname, Via: bastion.Hostname, Command: command, Output: out}, err)
+	return out, err
+}
+
+// recordSession appends the entry to SESSION_LOG, it does nothing when SESSION_LOG is not set.
+func recordSession(t *testing.T, entry SessionEntry, err error) {
+	path := os.Getenv("SESSION_LOG")
+	if path == "" {
+		return
+	}
+
+	entry.Time = time.Now().UTC()
+	entry.Test = t.Name()
+	entry.Command = redact(entry.Command)
+	entry.Output = redact(entry.Output)
+	if err != nil {
+		entry.Error = redact(err.Error())
+	}
+	for name, value := range entry.Env {
+		if secretEnvPattern.MatchString(name) {
+			entry.Env[name] = redacted
+		} else {
+			entry.Env[name] = redact(value)
+		}
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		t.Logf("error in recording session: %s", err.Error())
+		return
+	}
+
+	sessionLog.Lock()
+	defer sessionLog.Unlock()
+
+	sessionLog.once.Do(func() {
+		// append only, entries of previous runs are kept
+		sessionLog.file, sessionLog.err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, sessionLogMode)
+	})
+	if sessionLog.err != nil {
+		t.Fatalf("error in opening session log %s: %s", path, sessionLog.err.Error())
+	}
+
+	if _, err := sessionLog.file.Write(append(line, '\n')); err != nil {
+		t.Fatalf("error in writing session log %s: %s", path, err.Error())
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func redact(text string) string {
+	for _, pattern := range secretPatterns {
+		if pattern.NumSubexp() > 0 {
+			text = pattern.ReplaceAllString(text, "${1}"+redacted)
+		} else {
+			text = pattern.ReplaceAllString(text, redacted)
+		}
+	}
+	return text
+}
+
+// closeSessionLog closes the session log at the end of the run.
+func closeSessionLog() {
+	sessionLog.Lock()
+	defer sessionLog.Unlock()
+
+	if sessionLog.file != nil {
+		sessionLog.file.Close()
+	}
+}
@@ -0,0 +1,69 @@
+package terratest
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+)
+
+// TestStampedEnvironments applies STAMP_COUNT independent copies of the stack concurrently.
+// Every copy runs TestTerraform in its own process, stack copy and workspace, with its own CIDRs.
+func TestStampedEnvironments(t *testing.T) {
+	value := os.Getenv("STAMP_COUNT")
+	if value == "" {
+		t.Skip("stamping is enabled by STAMP_COUNT=<number of copies>")
+	}
+	count, err := strconv.Atoi(value)
+	if err != nil {
+		t.Fatalf("wrong STAMP_COUNT %q: %s", value, err.Error())
+	}
+
+	runID := random.UniqueId()
+	for i := 0; i < count; i++ {
+		index := i
+		t.Run(fmt.Sprintf("stamp%d", index), func(t *testing.T) {
+			t.Parallel()
+			runStamp(t, runID, index)
+		})
+	}
+}
+
+func runStamp(t *testing.T, runID string, index int) {
+	dir := test_structure.CopyTerraformFolderToTemp(t, "..", ".")
+	workspace := fmt.Sprintf("stamp-%s-%d", runID, index)
+	reports, err := filepath.Abs(filepath.Join(reportDir(), workspace))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the test binary itself runs the copy
+	cmd := exec.Command(os.Args[0], "-test.run", "^TestTerraform$", "-test.v", "-test.timeout", "0", "-suite", *suiteName)
+	cmd.Env = append(os.Environ(),
+		"STAMP_COUNT=",
+		"TERRATEST_DIR="+dir,
+		"TERRATEST_WORKSPACE="+workspace,
+		"REPORT_DIR="+reports,
+		"DIAGNOSTICS_DIR="+filepath.Join(reports, "diagnostics"),
+	)
+	// index 0 is the layout of the default stack
+	plan, err := PlanNetwork(index + 1)
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	for name, value := range plan.Vars() {
+		cmd.Env = append(cmd.Env, "TF_VAR_"+name+"="+value)
+	}
+
+	t.Logf("applying copy %s in %s", workspace, dir)
+	out, err := cmd.CombinedOutput()
+	t.Log(string(out))
+	if err != nil {
+		t.Fatalf("copy %s failed: %s", workspace, err.Error())
+	}
+}
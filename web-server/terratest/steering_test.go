@@ -0,0 +1,192 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/dns"
+)
+
+const (
+	// steering policies and zones, COMPARTMENT_OCID_DNS or CompartmentOCID
+	dnsCompartment = "dns"
+	// time for a policy update to reach the name servers, on top of the TTL cached by resolvers
+	steeringPropagation  = time.Minute
+	steeringPollInterval = 5 * time.Second
+)
+
+// SteeringExpectation is the traffic management steering policy of the domain of the stack.
+type SteeringExpectation struct {
+	Policy string `json:"policy"`
+	// Template is e.g. FAILOVER, LOAD_BALANCE or ROUTE_BY_GEO
+	Template string `json:"template"`
+	// Domain the policy is attached to
+	Domain string `json:"domain"`
+	// MaxTtl bounds the TTL of the answers in seconds, not checked when 0
+	MaxTtl int `json:"maxTtl"`
+	// HealthCheck requires a health check monitor of the answers
+	HealthCheck bool `json:"healthCheck"`
+	// Answers are the names of the answers of the policy
+	Answers []string `json:"answers"`
+}
+
+func checkSteeringPolicy(t *testing.T) {
+	expected := loadExpectations(t).Steering
+	if expected == nil {
+		t.Skip("no steering in expectations")
+	}
+	client := dnsClient(t)
+	policy := findSteeringPolicy(t, client, expected.Policy)
+	linkResource(t, "steering policy "+expected.Policy, *policy.Id, "")
+
+	// assertions
+	if policy.LifecycleState != dns.SteeringPolicyLifecycleStateActive {
+		t.Errorf("steering policy %s in state %s", expected.Policy, policy.LifecycleState)
+	}
+	if string(policy.Template) != expected.Template {
+		t.Errorf("steering policy %s: wrong template: expected %s, got %s", expected.Policy, expected.Template, policy.Template)
+	}
+	if ttl := intValue(policy.Ttl); expected.MaxTtl != 0 && ttl > expected.MaxTtl {
+		t.Errorf("steering policy %s: TTL %ds exceeds %ds", expected.Policy, ttl, expected.MaxTtl)
+	}
+	if expected.HealthCheck && stringValue(policy.HealthCheckMonitorId) == "" {
+		t.Errorf("steering policy %s has no health check monitor", expected.Policy)
+	}
+
+	answers := []string{}
+	for _, answer := range policy.Answers {
+		answers = append(answers, stringValue(answer.Name))
+	}
+	for _, name := range expected.Answers {
+		if !containsString(answers, name) {
+			t.Errorf("steering policy %s: missing answer %s, got %v", expected.Policy, name, answers)
+		}
+	}
+
+	compartmentID := compartmentFor(dnsCompartment)
+	attachments, err := client.ListSteeringPolicyAttachments(context.Background(), dns.ListSteeringPolicyAttachmentsRequest{
+		CompartmentId:    &compartmentID,
+		SteeringPolicyId: policy.Id,
+		Domain:           &expected.Domain,
+	})
+	if err != nil {
+		t.Fatalf("error in listing attachments of steering policy %s: %s", expected.Policy, err.Error())
+	}
+	if len(attachments.Items) == 0 {
+		t.Errorf("steering policy %s is not attached to %s", expected.Policy, expected.Domain)
+	}
+}
+
+// scenarioSteeringFailover disables the answer the domain resolves to, as if its health check failed, and verifies
+// the domain resolves to another answer within the TTL of the policy. The answer is enabled again at the end.
+func scenarioSteeringFailover(t *testing.T) {
+	requireScenarios(t)
+	expected := loadExpectations(t).Steering
+	if expected == nil {
+		t.Skip("no steering in expectations")
+	}
+	client := dnsClient(t)
+	policy := findSteeringPolicy(t, client, expected.Policy)
+	ttl := time.Duration(intValue(policy.Ttl)) * time.Second
+
+	before := resolveDomain(t, expected.Domain)
+	served := -1
+	for i, answer := range policy.Answers {
+		if stringValue(answer.Rtype) == "A" && !boolValue(answer.IsDisabled) && containsString(before, stringValue(answer.Rdata)) {
+			served = i
+		}
+	}
+	if served < 0 {
+		t.Fatalf("%s resolves to %v, which is no enabled answer of steering policy %s", expected.Domain, before, expected.Policy)
+	}
+	disabled := policy.Answers[served]
+	t.Logf("%s resolves to answer %s (%s)", expected.Domain, stringValue(disabled.Name), stringValue(disabled.Rdata))
+
+	defer updateSteeringAnswers(t, client, policy, policy.Answers)
+	answers := append([]dns.SteeringPolicyAnswer{}, policy.Answers...)
+	isDisabled := true
+	answers[served].IsDisabled = &isDisabled
+	updateSteeringAnswers(t, client, policy, answers)
+	start := time.Now()
+
+	within := ttl + steeringPropagation
+	ctx, cancel := context.WithTimeout(context.Background(), within)
+	defer cancel()
+	var after []string
+	err := pollUntil(ctx, fmt.Sprintf("%s away from %s", expected.Domain, stringValue(disabled.Rdata)), steeringPollInterval, func(ctx context.Context) (bool, error) {
+		after = resolveDomain(t, expected.Domain)
+		return len(after) > 0 && !containsString(after, stringValue(disabled.Rdata)), nil
+	})
+
+	// assertions
+	if err != nil {
+		t.Fatalf("%s still resolves to %v, %s after answer %s was disabled (TTL %s)", expected.Domain, after, within, stringValue(disabled.Name), ttl)
+	}
+	t.Logf("%s resolves to %v after %s", expected.Domain, after, time.Since(start))
+	report.AddMetric("time to steer "+expected.Domain+" away from disabled answer", time.Since(start).Round(time.Second))
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func dnsClient(t *testing.T) dns.DnsClient {
+	client, err := dns.NewDnsClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	throttle(&client.BaseClient)
+	return client
+}
+
+func findSteeringPolicy(t *testing.T, client dns.DnsClient, name string) dns.SteeringPolicy {
+	compartmentID := compartmentFor(dnsCompartment)
+	policies, err := client.ListSteeringPolicies(context.Background(), dns.ListSteeringPoliciesRequest{
+		CompartmentId: &compartmentID,
+		DisplayName:   &name,
+	})
+	if err != nil {
+		t.Fatalf("error in listing steering policies: %s", err.Error())
+	}
+	for _, summary := range policies.Items {
+		if summary.LifecycleState == dns.SteeringPolicySummaryLifecycleStateDeleted {
+			continue
+		}
+		response, err := client.GetSteeringPolicy(context.Background(), dns.GetSteeringPolicyRequest{SteeringPolicyId: summary.Id})
+		if err != nil {
+			t.Fatalf("error in calling steering policy %s: %s", name, err.Error())
+		}
+		return response.SteeringPolicy
+	}
+	t.Fatalf("steering policy %s not found in %s", name, compartmentID)
+	return dns.SteeringPolicy{}
+}
+
+// updateSteeringAnswers replaces the answers of the policy, its other settings are kept.
+func updateSteeringAnswers(t *testing.T, client dns.DnsClient, policy dns.SteeringPolicy, answers []dns.SteeringPolicyAnswer) {
+	_, err := client.UpdateSteeringPolicy(context.Background(), dns.UpdateSteeringPolicyRequest{
+		SteeringPolicyId: policy.Id,
+		UpdateSteeringPolicyDetails: dns.UpdateSteeringPolicyDetails{
+			DisplayName:          policy.DisplayName,
+			Ttl:                  policy.Ttl,
+			HealthCheckMonitorId: policy.HealthCheckMonitorId,
+			Template:             dns.UpdateSteeringPolicyDetailsTemplateEnum(policy.Template),
+			Answers:              answers,
+			Rules:                policy.Rules,
+		},
+	})
+	if err != nil {
+		t.Fatalf("error in updating answers of steering policy %s: %s", stringValue(policy.DisplayName), err.Error())
+	}
+}
+
+// resolveDomain returns the addresses of the domain by the resolver of the test host, empty when it does not resolve.
+func resolveDomain(t *testing.T, domain string) []string {
+	addresses, err := net.DefaultResolver.LookupHost(context.Background(), domain)
+	if err != nil {
+		t.Logf("error in resolving %s: %s", domain, err.Error())
+		return []string{}
+	}
+	return addresses
+}
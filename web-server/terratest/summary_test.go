@@ -0,0 +1,174 @@
+package terratest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	exitSummaryFile = "exit-summary.json"
+	// phases of the run
+	phaseSetup   = "setup"
+	phaseApply   = "apply"
+	phaseChecks  = "checks"
+	phaseDestroy = "destroy"
+	// process exit codes, pipelines branch on them
+	exitPassed    = 0
+	exitAssertion = 1
+	exitInfra     = 2
+	exitCleanup   = 3
+	exitTimedOut  = 4
+	// status of passed runs with flaky checks, so pipelines tell noise from clean runs
+	flakyStatus = "passed with flaky checks"
+)
+
+var (
+	summary = &ExitSummary{Started: time.Now()}
+
+	exitStatuses = map[int]string{
+		exitPassed:    "passed",
+		exitAssertion: "assertion failure",
+		exitInfra:     "infra failure",
+		exitCleanup:   "cleanup failure",
+		exitTimedOut:  "timed out",
+	}
+)
+
+// ExitSummary is the outcome of the run for pipelines, written to exit-summary.json of the report dir.
+type ExitSummary struct {
+	mu           sync.Mutex
+	Status       string   `json:"status"`
+	ExitCode     int      `json:"exitCode"`
+	FailedChecks []string `json:"failedChecks"`
+	// FlakyChecks failed, but passed on a rerun
+	FlakyChecks []string `json:"flakyChecks"`
+	// TimedOut runs exceeded RUN_BUDGET, their remaining checks were skipped
+	TimedOut bool      `json:"timedOut"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	Seconds  float64   `json:"seconds"`
+	// Resources are counts of managed resources by type after apply
+	Resources map[string]int `json:"resources"`
+	Phases    []SummaryPhase `json:"phases"`
+}
+
+// SummaryPhase is a phase of the run, a phase which has not finished has failed.
+type SummaryPhase struct {
+	Name     string    `json:"name"`
+	Started  time.Time `json:"started"`
+	Seconds  float64   `json:"seconds"`
+	Finished bool      `json:"finished"`
+}
+
+// Start records beginning of the phase.
+func (s *ExitSummary) Start(phase string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Phases = append(s.Phases, SummaryPhase{Name: phase, Started: time.Now()})
+}
+
+// End records successful end of the last started phase of the name.
+func (s *ExitSummary) End(phase string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.Phases) - 1; i >= 0; i-- {
+		if s.Phases[i].Name == phase {
+			s.Phases[i].Finished = true
+			s.Phases[i].Seconds = time.Since(s.Phases[i].Started).Seconds()
+			return
+		}
+	}
+}
+
+// Ran tells whether a phase of the stack started, unit tests alone run none.
+func (s *ExitSummary) Ran() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.Phases) > 0
+}
+
+// AddFailedChecks records names of failed checks.
+func (s *ExitSummary) AddFailedChecks(names []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.FailedChecks = append(s.FailedChecks, names...)
+}
+
+// AddFlakyChecks records names of checks which passed on a rerun.
+func (s *ExitSummary) AddFlakyChecks(names []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.FlakyChecks = append(s.FlakyChecks, names...)
+}
+
+// SetResources records managed resources of the applied state.
+func (s *ExitSummary) SetResources(state *State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Resources = map[string]int{}
+	for _, resource := range state.ManagedResources() {
+		s.Resources[resource.Type]++
+	}
+}
+
+// ExitCodeOf classifies the failure of the run, code is the exit code of the tests.
+// A cleanup failure (leaked resources) wins over a timeout, which wins over an infra failure, which wins over failed assertions.
+func (s *ExitSummary) ExitCodeOf(code int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unfinished := map[string]bool{}
+	for _, phase := range s.Phases {
+		if !phase.Finished {
+			unfinished[phase.Name] = true
+		}
+	}
+
+	switch {
+	case unfinished[phaseDestroy]:
+		return exitCleanup
+	case s.TimedOut:
+		return exitTimedOut
+	case code == exitPassed:
+		return exitPassed
+	case unfinished[phaseSetup] || unfinished[phaseApply]:
+		return exitInfra
+	case len(s.FailedChecks) == 0 && len(s.FlakyChecks) > 0 && len(unfinished) == 0:
+		// go test fails on the failed first attempts of flaky checks
+		return exitPassed
+	}
+	return exitAssertion
+}
+
+// Write finishes the summary with the exit code and writes it to dir.
+func (s *ExitSummary) Write(dir string, code int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ExitCode = code
+	s.Status = exitStatuses[code]
+	if code == exitPassed && len(s.FlakyChecks) > 0 {
+		s.Status = flakyStatus
+	}
+	s.Finished = time.Now()
+	s.Seconds = s.Finished.Sub(s.Started).Seconds()
+	if s.FailedChecks == nil {
+		s.FailedChecks = []string{}
+	}
+	if s.FlakyChecks == nil {
+		s.FlakyChecks = []string{}
+	}
+
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, exitSummaryFile), content, 0644)
+}
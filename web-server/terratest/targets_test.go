@@ -0,0 +1,88 @@
+package terratest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+var (
+	// outputs used by the checks, they have to have a value after apply
+	requiredOutputs = []string{"VcnID", "BastionPublicIP", "WebServerPrivateIPs", "lb_ip", "lb_id"}
+)
+
+// ApplyTarget applies only the given resource addresses (e.g. "oci_load_balancer.lb-web"),
+// then verifies the targets are in state and the outputs used by checks are valid.
+func ApplyTarget(t *testing.T, targets ...string) {
+	terraformTargeted(t, "apply", targets...)
+
+	state := stateList(t)
+	for _, target := range targets {
+		if len(stateMatches(state, target)) == 0 {
+			t.Fatalf("%s not in state after targeted apply", target)
+		}
+	}
+	revalidateOutputs(t)
+}
+
+// DestroyTarget destroys only the given resource addresses, it fails on addresses
+// which are not in state (typo protection) and verifies they are gone afterwards.
+func DestroyTarget(t *testing.T, targets ...string) {
+	state := stateList(t)
+	for _, target := range targets {
+		if len(stateMatches(state, target)) == 0 {
+			t.Fatalf("refusing to destroy %s: no such resource in state", target)
+		}
+	}
+
+	terraformTargeted(t, "destroy", targets...)
+
+	state = stateList(t)
+	for _, target := range targets {
+		if left := stateMatches(state, target); len(left) > 0 {
+			t.Fatalf("%s still in state after targeted destroy: %v", target, left)
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// terraformTargeted runs terraform command (apply, destroy) with -target for every address.
+func terraformTargeted(t *testing.T, command string, targets ...string) string {
+	args := []string{command, "-input=false", "-auto-approve"}
+	for _, target := range targets {
+		args = append(args, "-target="+target)
+	}
+	return withStateLock(t, "targeted "+command, func() (string, error) {
+		return terraform.RunTerraformCommandE(t, options, terraform.FormatArgs(options, args...)...)
+	})
+}
+
+// revalidateOutputs fails when any of the required outputs is empty.
+func revalidateOutputs(t *testing.T) {
+	for _, name := range requiredOutputs {
+		raw, err := terraform.OutputE(t, options, name)
+		if err != nil {
+			t.Fatalf("output %s not available: %s", name, err.Error())
+		}
+		if strings.Trim(raw, "[]\" \n") == "" {
+			t.Fatalf("output %s is empty", name)
+		}
+	}
+}
+
+func stateList(t *testing.T) []string {
+	return LoadState(t).Addresses()
+}
+
+// stateMatches returns state addresses of target, "a.b" matches also its instances "a.b[0]".
+func stateMatches(state []string, target string) []string {
+	matches := []string{}
+	for _, address := range state {
+		if address == target || strings.HasPrefix(address, target+"[") {
+			matches = append(matches, address)
+		}
+	}
+	return matches
+}
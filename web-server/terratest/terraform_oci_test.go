@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 	"testing"
@@ -15,7 +17,6 @@ import (
 	"github.com/gruntwork-io/terratest/modules/retry"
 	"github.com/gruntwork-io/terratest/modules/ssh"
 	"github.com/gruntwork-io/terratest/modules/terraform"
-	"github.com/oracle/oci-go-sdk/common"
 	"github.com/oracle/oci-go-sdk/core"
 	"github.com/oracle/oci-go-sdk/identity"
 )
@@ -28,58 +29,198 @@ const (
 	// Terratest retries
 	maxRetries          = 20
 	sleepBetweenRetries = 5 * time.Second
+	sshCommandTimeout   = 60 * time.Second
+	// stack defaults
+	defaultWorkspace = "default"
+	defaultVcnCidr   = "10.0.0.0/16"
+	// private, bastion and LB subnet of the VCN
+	expectedSubnetCount = 3
 )
 
 var (
 	options *terraform.Options
+
+	quotedPattern = regexp.MustCompile(`"([^"]*)"`)
 )
 
-func terraformEnvOptions() *terraform.Options {
+// terraformEnvOptions configures terraform by the validated Config, TFVARS_FILES and TF_VAR_* env vars,
+// the OCI provider uses the credentials of the Config, or the security token session when enabled.
+func terraformEnvOptions(t *testing.T) *terraform.Options {
+	files := varFiles(t)
+	loadVarFiles(t, files)
+	config = loadConfig(t)
+	auditorConfig = loadAuditorConfig(t)
+
+	// set env vars override the var files
+	vars := envVars()
+	for name, value := range config.Vars() {
+		vars[name] = value
+	}
+
+	env := config.ProviderEnvVars()
+	if passphrase, ok := keyPassphrase(ociProfileValues); ok {
+		env["TF_VAR_private_key_password"] = passphrase
+	}
+	for name, value := range securityTokenEnvVars() {
+		env[name] = value
+	}
+
 	return &terraform.Options{
-		TerraformDir: "..",
-		Vars: map[string]interface{}{
-			"region":           os.Getenv("TF_VAR_region"),
-			"tenancy_ocid":     os.Getenv("TF_VAR_tenancy_ocid"),
-			"user_ocid":        os.Getenv("TF_VAR_user_ocid"),
-			"CompartmentOCID":  os.Getenv("TF_VAR_CompartmentOCID"),
-			"fingerprint":      os.Getenv("TF_VAR_fingerprint"),
-			"private_key_path": os.Getenv("TF_VAR_private_key_path"),
-			// "pass_phrase":      oci.GetPassPhraseFromEnvVar(),
-			"ssh_public_key":  os.Getenv("TF_VAR_ssh_public_key"),
-			"ssh_private_key": os.Getenv("TF_VAR_ssh_private_key"),
-		},
+		TerraformDir: terraformDir(),
+		VarFiles:     files,
+		Vars:         vars,
+		EnvVars:      env,
 	}
 }
 
 func TestTerraform(t *testing.T) {
-	options = terraformEnvOptions()
+	summary.Start(phaseSetup)
+	defer startRunBudget(t)()
+	options = terraformEnvOptions(t)
+	if dryRunEnabled() {
+		dryRun(t)
+		summary.End(phaseSetup)
+		return
+	}
+	checkRegionSubscription(t)
+
+	if backend := remoteBackendFromEnv(t); backend != nil {
+		backend.configure(t, options)
+		defer backend.cleanup(t, options)
+	}
+
+	if createCompartmentEnabled() {
+		compartmentID := createRunCompartment(t, stringVar("CompartmentOCID", ""))
+		defer deleteRunCompartment(t, compartmentID)
+		options.Vars["CompartmentOCID"] = compartmentID
+	}
+
+	// the cost is reported after destroy
+	if tagRun(options) {
+		defer reportRunCost(t)
+	}
+
+	if ephemeralKeyEnabled() {
+		generateEphemeralKey(t)
+		defer discardEphemeralKey(t)
+	}
+
+	// the image is deleted after destroy
+	if customImageEnabled() {
+		defer deleteCustomImage(t, buildCustomImage(t))
+	}
 
-	defer terraform.Destroy(t, options)
-	// terraform.WorkspaceSelectOrNew(t, options, "terratest-vita")
-	terraform.InitAndApply(t, options)
+	if ws := workspace(); ws != defaultWorkspace {
+		start := time.Now()
+		terraform.Init(t, options)
+		profiler.Record(opTerraform, "init", start)
+		terraform.WorkspaceSelectOrNew(t, options, ws)
+	}
+
+	checkVcnOverlap(t)
+	summary.End(phaseSetup)
+
+	defer destroyAndVerify(t)
+	summary.Start(phaseApply)
+	applyStartedAt = time.Now()
+	start := time.Now()
+	terraform.Init(t, options)
+	profiler.Record(opTerraform, "init", start)
+	enforcePlanPolicies(t)
+	withStateLock(t, "apply", func() (string, error) {
+		return terraform.ApplyE(t, options)
+	})
+	appliedAt = time.Now()
+	summary.SetResources(LoadState(t))
+	summary.End(phaseApply)
 
 	runSubtests(t)
 }
 
 func TestWithoutProvisioning(t *testing.T) {
-	options = terraformEnvOptions()
+	defer startRunBudget(t)()
+	options = terraformEnvOptions(t)
+	if dryRunEnabled() {
+		dryRun(t)
+		return
+	}
+	checkRegionSubscription(t)
+
+	// existing environment applied elsewhere, its state is read from the remote backend
+	if backend := remoteBackendFromEnv(t); backend != nil {
+		if backend.generatedKey {
+			t.Fatal("TF_BACKEND_KEY of the existing environment state is required")
+		}
+		backend.configure(t, options)
+		defer backend.cleanup(t, options)
+		terraform.Init(t, options)
+	}
 
 	runSubtests(t)
 }
 
 func runSubtests(t *testing.T) {
-	t.Run("sshBastion", sshBastion)
-	t.Run("sshWeb", sshWeb)
-	t.Run("netstatNginx", netstatNginx)
-	t.Run("curlWebServer", curlWebServer)
-	t.Run("checkVpn", checkVpn)
-	t.Run("checkGetAllAvailabilityDomains", checkGetAllAvailabilityDomains)
-	t.Run("checkSubnetsCount", checkSubnetsCount)
-	t.Run("checkLoadBalancerCurl", checkLoadBalancerCurl)
+	suite := selectedSuite(t)
+	t.Logf("running suite %s", suite)
+
+	retries := checkRetries(t)
+	history := loadCheckHistory(t)
+
+	summary.Start(phaseChecks)
+	failed := []string{}
+	flaky := []string{}
+	timedOut := false
+	for _, check := range selectedChecks(suite) {
+		if budgetExceeded() {
+			if !timedOut {
+				t.Logf("run budget exceeded, skipping remaining checks from %s", check.Name)
+				timedOut = true
+			}
+			report.AddResult(ReportResult{Name: check.Name, Result: resultSkip})
+			continue
+		}
+		run := check.Run
+		if auditorConfig != nil && check.Matches(auditorTags()) {
+			run = asAuditor(run)
+		}
+		profiler.SetCheck(check.Name)
+		result := runCheck(t, check, run, retries)
+		profiler.SetCheck("")
+		switch result.Result {
+		case resultFail:
+			failed = append(failed, check.Name)
+		case resultFlaky:
+			flaky = append(flaky, check.Name)
+		}
+		history.Record(check.Name, result)
+		report.AddResult(ReportResult{
+			Name:        check.Name,
+			Result:      result.Result,
+			Attempts:    result.Attempts,
+			Seconds:     time.Since(result.Time).Seconds(),
+			Breakdown:   profiler.Breakdown(check.Name),
+			FailureRate: history.FailureRate(check.Name),
+			Quarantined: history.Quarantined(check.Name),
+		})
+	}
+	summary.AddFailedChecks(failed)
+	summary.AddFlakyChecks(flaky)
+	summary.End(phaseChecks)
+
+	if err := history.Write(checkHistoryFile()); err != nil {
+		t.Errorf("error in writing check history: %s", err.Error())
+	}
+
+	if len(failed) > 0 {
+		collectDiagnostics(t, failed)
+	}
+	t.Run("scanSecrets", scanSecrets)
 }
 
 func sshBastion(t *testing.T) {
-	ssh.CheckSshConnection(t, bastionHost(t))
+	if _, err := sshCommandE(t, bastionHost(t), "exit"); err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
 }
 
 func sshWeb(t *testing.T) {
@@ -96,9 +237,8 @@ func curlWebServer(t *testing.T) {
 
 func checkVpn(t *testing.T) {
 	// client
-	config := common.CustomProfileConfigProvider("", "CzechEdu")
-	c, _ := core.NewVirtualNetworkClientWithConfigurationProvider(config)
-	// c, _ := core.NewVirtualNetworkClientWithConfigurationProvider(common.DefaultConfigProvider())
+	c, _ := core.NewVirtualNetworkClientWithConfigurationProvider(ociConfigProvider())
+	throttle(&c.BaseClient)
 
 	// request
 	request := core.GetVcnRequest{}
@@ -113,14 +253,14 @@ func checkVpn(t *testing.T) {
 	}
 
 	// assertions
-	expected := "Web VCN-default"
+	expected := "Web VCN-" + workspace()
 	actual := response.Vcn.DisplayName
 
 	if expected != *actual {
 		t.Fatalf("wrong vcn display name: expected %q, got %q", expected, *actual)
 	}
 
-	expected = "10.0.0.0/16"
+	expected = stringVar("VCNCIDR", defaultVcnCidr)
 	actual = response.Vcn.CidrBlock
 
 	if expected != *actual {
@@ -129,14 +269,14 @@ func checkVpn(t *testing.T) {
 }
 
 func checkGetAllAvailabilityDomains(t *testing.T) {
-	options = terraformEnvOptions()
-	configProvider := common.DefaultConfigProvider()
+	configProvider := ociConfigProvider()
 	client, err := identity.NewIdentityClientWithConfigurationProvider(configProvider)
 	if err != nil {
 		t.Fatalf("error occured: %s", err.Error())
 	}
+	throttle(&client.BaseClient)
 
-	compartmentID := options.Vars["CompartmentOCID"].(string)
+	compartmentID := stringVar("CompartmentOCID", "")
 
 	request := identity.ListAvailabilityDomainsRequest{CompartmentId: &compartmentID}
 	response, err := client.ListAvailabilityDomains(context.Background(), request)
@@ -168,45 +308,30 @@ func availabilityDomainsNames(ads []identity.AvailabilityDomain) []string {
 }
 
 func checkSubnetsCount(t *testing.T) {
-	options = terraformEnvOptions()
-	configProvider := common.DefaultConfigProvider()
-	client, err := core.NewVirtualNetworkClientWithConfigurationProvider(configProvider)
-	if err != nil {
-		t.Fatalf("error occured: %s", err.Error())
-	}
-
-	compartmentID := options.Vars["CompartmentOCID"].(string)
-	vcnIDs, err := GetAllVcnIDsE(t, compartmentID)
-	if err != nil {
-		t.Fatalf("error occured: %s", err.Error())
-	}
+	client := virtualNetworkClient(t)
+	compartmentID := compartmentFor(networkCompartment)
+	vcnIDs := WaitForIDs(t, "vcns", listVcns(client, compartmentID), []string{sanitizedVcnId(t)}, listConsistencyTimeout)
 
 	for _, vcnID := range vcnIDs {
-		request := core.ListSubnetsRequest{
-			CompartmentId: &compartmentID,
-			VcnId:         &vcnID,
-		}
-		response, err := client.ListSubnets(context.Background(), request)
-		if err != nil {
-			t.Fatalf("error occured: %s", err.Error())
-		}
-
 		// assertions
-		expected := 3
-		t.Logf(vcnID+", subnets count: %i", len(response.Items))
-		if len(response.Items) != expected {
-			t.Fatalf("Wrong number of subnets")
+		subnets := []core.Subnet{}
+		WaitForCount(t, "subnets of vcn "+vcnID, listSubnets(client, compartmentID, vcnID, &subnets), expectedSubnetCount, listConsistencyTimeout)
+		t.Logf(vcnID+", subnets count: %d", len(subnets))
+
+		for _, subnet := range subnets {
+			linkResource(t, *subnet.DisplayName, *subnet.Id, vcnID)
 		}
 	}
 }
 
 // GetAllVcnIDsE gets the list of VCNs available in the given compartment.
 func GetAllVcnIDsE(t *testing.T, compartmentID string) ([]string, error) {
-	configProvider := common.DefaultConfigProvider()
+	configProvider := ociConfigProvider()
 	client, err := core.NewVirtualNetworkClientWithConfigurationProvider(configProvider)
 	if err != nil {
 		return nil, err
 	}
+	throttle(&client.BaseClient)
 
 	request := core.ListVcnsRequest{CompartmentId: &compartmentID}
 	response, err := client.ListVcns(context.Background(), request)
@@ -245,7 +370,9 @@ func checkLoadBalancerCurl(t *testing.T) {
 
 func sanitizedVcnId(t *testing.T) string {
 	raw := terraform.Output(t, options, "VcnID")
-	return strings.Split(raw, "\"")[1]
+	vcnID := strings.Split(raw, "\"")[1]
+	linkResource(t, "vcn", vcnID, vcnID)
+	return vcnID
 }
 
 // ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
@@ -261,25 +388,36 @@ func webHost(t *testing.T) ssh.Host {
 }
 
 func sshHost(t *testing.T, ip string) ssh.Host {
-	return ssh.Host{
+	host := ssh.Host{
 		Hostname:    ip,
 		SshUserName: sshUserName,
-		SshKeyPair:  loadKeyPair(t),
 	}
+
+	if useSshAgent(t) {
+		host.SshAgent = true
+	} else {
+		host.SshKeyPair = loadKeyPair(t)
+	}
+	return host
 }
 
 func curlService(t *testing.T, serviceName string, path string, port string, returnCode string) {
 	bastionHost := bastionHost(t)
 	webIPs := webServerIPs(t)
 
+	var current *ssh.Host
+	defer collectLogsOnFailure(t, func() *ssh.Host { return current })
+
 	for _, cp := range webIPs {
 		re := strings.NewReplacer("[", "", "]", "")
 		host := re.Replace(cp)
+		webHost := sshHost(t, host)
+		current = &webHost
 		command := curl(host, port, path)
 		description := fmt.Sprintf("curl to %s on %s:%s%s", serviceName, cp, port, path)
 
 		out := retry.DoWithRetry(t, description, maxRetries, sleepBetweenRetries, func() (string, error) {
-			out, err := ssh.CheckSshCommandE(t, bastionHost, command)
+			out, err := sshCommandE(t, bastionHost, command)
 			if err != nil {
 				return "", err
 			}
@@ -295,20 +433,112 @@ func curlService(t *testing.T, serviceName string, path string, port string, ret
 }
 
 func curl(host string, port string, path string) string {
-	return fmt.Sprintf("curl -s -o /dev/null -w '%%{http_code}' http://%s:%s%s", host, port, path)
+	// -g for IPv6 literals in brackets
+	return fmt.Sprintf("curl -g -s -o /dev/null -w '%%{http_code}' http://%s%s", net.JoinHostPort(host, port), path)
 }
 
 func webServerIPs(t *testing.T) []string {
 	return terraform.OutputList(t, options, "WebServerPrivateIPs")
 }
 
+// outputValues flattens list outputs like [["a", "b"]] to plain values.
+func outputValues(t *testing.T, name string) []string {
+	values := []string{}
+	re := strings.NewReplacer("[", "", "]", "", "\"", "")
+	start := time.Now()
+	list := terraform.OutputList(t, options, name)
+	profiler.Record(opTerraform, "output "+name, start)
+	for _, raw := range list {
+		values = append(values, strings.Fields(re.Replace(raw))...)
+	}
+	return values
+}
+
+// stringVar returns the terraform variable value as passed to the test,
+// falling back to TF_VAR_<name>, the var files and then to the default of the stack.
+func stringVar(name string, fallback string) string {
+	if value, ok := options.Vars[name]; ok {
+		return value.(string)
+	}
+	if value, ok := os.LookupEnv("TF_VAR_" + name); ok {
+		return value
+	}
+	if value, ok := varFileValues[name]; ok {
+		return value
+	}
+	return fallback
+}
+
+// listVar returns values of a list variable given as HCL, e.g. ["a", "b"].
+func listVar(name string, fallback []string) []string {
+	raw := stringVar(name, "")
+	if raw == "" {
+		return fallback
+	}
+
+	values := []string{}
+	for _, match := range quotedPattern.FindAllStringSubmatch(raw, -1) {
+		values = append(values, match[1])
+	}
+	return values
+}
+
+// terraformDir is the stack directory, TERRATEST_DIR is set e.g. for stamped copies.
+func terraformDir() string {
+	if dir := os.Getenv("TERRATEST_DIR"); dir != "" {
+		return dir
+	}
+	return ".."
+}
+
+// workspace is the terraform workspace of the run, part of all resource names.
+func workspace() string {
+	if ws := os.Getenv("TERRATEST_WORKSPACE"); ws != "" {
+		return ws
+	}
+	return defaultWorkspace
+}
+
+func webHosts(t *testing.T) []ssh.Host {
+	hosts := []ssh.Host{}
+	for _, ip := range outputValues(t, "WebServerPrivateIPs") {
+		hosts = append(hosts, sshHost(t, ip))
+	}
+	return hosts
+}
+
+// jumpSshHost runs command on the given private host via the bastion, retrying on ssh errors.
+func jumpSshHost(t *testing.T, host ssh.Host, command string) string {
+	bastionHost := bastionHost(t)
+	description := fmt.Sprintf("ssh jump to %q with command %q", host.Hostname, command)
+	defer profiler.Record(opRetry, profileName(command), time.Now())
+
+	out, err := retry.DoWithRetryE(t, description, maxRetries, sleepBetweenRetries, func() (string, error) {
+		out, err := jumpSshCommandE(t, bastionHost, host, command)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(out), nil
+	})
+	if err != nil {
+		t.Errorf("error occured: %s", err.Error())
+		captureUnreachableConsole(t, bastionHost, host)
+		t.FailNow()
+	}
+	return out
+}
+
 func jumpSsh(t *testing.T, command string, expected string, retryAssert bool) string {
 	bastionHost := bastionHost(t)
 	webHost := webHost(t)
 	description := fmt.Sprintf("ssh jump to %q with command %q", webHost.Hostname, command)
+	defer profiler.Record(opRetry, profileName(command), time.Now())
 
-	out := retry.DoWithRetry(t, description, maxRetries, sleepBetweenRetries, func() (string, error) {
-		out, err := ssh.CheckPrivateSshConnectionE(t, bastionHost, webHost, command)
+	// the last ssh error, nil when the host was reached and only the assert failed
+	var sshErr error
+	out, err := retry.DoWithRetryE(t, description, maxRetries, sleepBetweenRetries, func() (string, error) {
+		out, err := jumpSshCommandE(t, bastionHost, webHost, command)
+		sshErr = err
 		if err != nil {
 			return "", err
 		}
@@ -319,6 +549,13 @@ func jumpSsh(t *testing.T, command string, expected string, retryAssert bool) st
 		}
 		return out, nil
 	})
+	if err != nil {
+		t.Errorf("error occured: %s", err.Error())
+		if sshErr != nil {
+			captureUnreachableConsole(t, bastionHost, webHost)
+		}
+		t.FailNow()
+	}
 
 	if out != expected {
 		t.Fatalf("command %q on %s: expected %q, got %q", command, webHost.Hostname, expected, out)
@@ -328,16 +565,18 @@ func jumpSsh(t *testing.T, command string, expected string, retryAssert bool) st
 }
 
 func loadKeyPair(t *testing.T) *ssh.KeyPair {
-	publicKeyPath := options.Vars["ssh_public_key"].(string)
+	publicKeyPath := stringVar("ssh_public_key", "")
 	publicKey, err := ioutil.ReadFile(publicKeyPath)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	privateKeyPath := options.Vars["ssh_private_key"].(string)
-	privateKey, err := ioutil.ReadFile(privateKeyPath)
-	if err != nil {
-		t.Fatal(err)
+	privateKey := readPrivateKey(t)
+	if privateKeyEncrypted(t, privateKey) {
+		privateKey, err = decryptPrivateKey(privateKey, os.Getenv("SSH_KEY_PASSPHRASE"))
+		if err != nil {
+			t.Fatalf("error in decrypting ssh private key: %s", err.Error())
+		}
 	}
 
 	return &ssh.KeyPair{
@@ -347,6 +586,60 @@ func loadKeyPair(t *testing.T) *ssh.KeyPair {
 }
 
 func netstatService(t *testing.T, service string, port string, expectedCount int) {
-	command := fmt.Sprintf("sudo netstat -tnlp | grep '%s' | grep ':%s' | wc -l", service, port)
-	jumpSsh(t, command, strconv.Itoa(expectedCount), true)
+	command := fmt.Sprintf("netstat -tnlp | grep '%s' | grep ':%s' | wc -l", service, port)
+	expected := strconv.Itoa(expectedCount)
+	host := webHost(t)
+	defer collectLogsOnFailure(t, func() *ssh.Host { return &host })
+
+	description := fmt.Sprintf("netstat %s:%s", service, port)
+	if !assertHost(t, host, description, command, true, func(result *RemoteResult) error {
+		if result.ExitCode != 0 {
+			return fmt.Errorf("exit code %d, stderr: %s", result.ExitCode, result.Stderr)
+		}
+		if out := strings.TrimSpace(result.Stdout); out != expected {
+			return fmt.Errorf("command %q: expected %q, got %q", command, expected, out)
+		}
+		return nil
+	}) {
+		t.FailNow()
+	}
+}
+
+func uniqueStrings(values ...string) []string {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func boolValue(b *bool) bool {
+	return b != nil && *b
+}
+
+func intValue(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
 }
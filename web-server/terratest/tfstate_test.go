@@ -0,0 +1,153 @@
+package terratest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+const (
+	managedMode = "managed"
+)
+
+// State is the terraform state as printed by `terraform show -json`.
+type State struct {
+	FormatVersion    string `json:"format_version"`
+	TerraformVersion string `json:"terraform_version"`
+	// Values are missing when the state is empty
+	Values *StateValues `json:"values"`
+}
+
+// StateValues are outputs and resources of the root module and its children.
+type StateValues struct {
+	Outputs    map[string]StateOutput `json:"outputs"`
+	RootModule StateModule            `json:"root_module"`
+}
+
+// StateOutput is one terraform output.
+type StateOutput struct {
+	Sensitive bool        `json:"sensitive"`
+	Value     interface{} `json:"value"`
+}
+
+// StateModule holds resources of one module.
+type StateModule struct {
+	Address      string          `json:"address"`
+	Resources    []StateResource `json:"resources"`
+	ChildModules []StateModule   `json:"child_modules"`
+}
+
+// StateResource is a resource instance, e.g. oci_core_instance.WebServer[0].
+type StateResource struct {
+	Address      string                 `json:"address"`
+	Mode         string                 `json:"mode"`
+	Type         string                 `json:"type"`
+	Name         string                 `json:"name"`
+	Index        interface{}            `json:"index"`
+	ProviderName string                 `json:"provider_name"`
+	Values       map[string]interface{} `json:"values"`
+	DependsOn    []string               `json:"depends_on"`
+}
+
+// LoadState reads the current state of the stack.
+func LoadState(t *testing.T) *State {
+	out := terraform.RunTerraformCommand(t, options, "show", "-json")
+
+	state, err := ParseState([]byte(out))
+	if err != nil {
+		t.Fatalf("error in parsing terraform state: %s", err.Error())
+	}
+	return state
+}
+
+// ParseState parses output of `terraform show -json`.
+func ParseState(content []byte) (*State, error) {
+	state := &State{}
+	if err := json.Unmarshal(content, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Resources returns all resources (managed and data) of all modules.
+func (s *State) Resources() []StateResource {
+	if s.Values == nil {
+		return nil
+	}
+	return moduleResources(s.Values.RootModule)
+}
+
+// ManagedResources returns resources which are not data sources.
+func (s *State) ManagedResources() []StateResource {
+	resources := []StateResource{}
+	for _, r := range s.Resources() {
+		if r.Mode == managedMode {
+			resources = append(resources, r)
+		}
+	}
+	return resources
+}
+
+// Addresses returns addresses of managed resources, as `terraform state list` does.
+func (s *State) Addresses() []string {
+	addresses := []string{}
+	for _, r := range s.ManagedResources() {
+		addresses = append(addresses, r.Address)
+	}
+	return addresses
+}
+
+// FindByType returns managed resources of the type, e.g. oci_core_instance.
+func (s *State) FindByType(resourceType string) []StateResource {
+	resources := []StateResource{}
+	for _, r := range s.ManagedResources() {
+		if r.Type == resourceType {
+			resources = append(resources, r)
+		}
+	}
+	return resources
+}
+
+// FindByAddress returns the resource instance with the address.
+func (s *State) FindByAddress(address string) (StateResource, bool) {
+	for _, r := range s.Resources() {
+		if r.Address == address {
+			return r, true
+		}
+	}
+	return StateResource{}, false
+}
+
+// AttributesOf returns attributes of the resource instance, nil when it is not in state.
+func (s *State) AttributesOf(address string) map[string]interface{} {
+	r, ok := s.FindByAddress(address)
+	if !ok {
+		return nil
+	}
+	return r.Values
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func moduleResources(module StateModule) []StateResource {
+	resources := append([]StateResource{}, module.Resources...)
+	for _, child := range module.ChildModules {
+		resources = append(resources, moduleResources(child)...)
+	}
+	return resources
+}
+
+// destroyAndVerify destroys the stack and fails when any managed resource is left in state.
+func destroyAndVerify(t *testing.T) {
+	summary.Start(phaseDestroy)
+	withStateLock(t, "destroy", func() (string, error) {
+		return terraform.DestroyE(t, options)
+	})
+
+	if left := LoadState(t).Addresses(); len(left) > 0 {
+		t.Errorf("resources left in state after destroy: %v", left)
+		return
+	}
+	summary.End(phaseDestroy)
+}
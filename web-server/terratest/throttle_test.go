@@ -0,0 +1,130 @@
+package terratest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/common"
+)
+
+const (
+	defaultRequestsPerSecond = 10
+	maxThrottledRetries      = 8
+	maxRetryAfter            = 60 * time.Second
+)
+
+var (
+	// shared by all SDK clients of the run, so parallel subtests stay within the tenancy API limits together
+	ociLimiter = newRateLimiter(requestsPerSecond())
+)
+
+// rateLimiter spaces requests evenly, a throttled response delays the next slot of all callers.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(perSecond int) *rateLimiter {
+	return &rateLimiter{interval: time.Second / time.Duration(perSecond)}
+}
+
+// Wait blocks until the next free slot.
+func (l *rateLimiter) Wait() {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	slot := l.next
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	time.Sleep(time.Until(slot))
+}
+
+// Backoff moves the next slot at least delay from now.
+func (l *rateLimiter) Backoff(delay time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(delay); l.next.Before(until) {
+		l.next = until
+	}
+}
+
+// throttledDispatcher sends requests of a client through the shared limiter
+// and retries 429 responses after their Retry-After (exponential backoff without it).
+type throttledDispatcher struct {
+	next common.HTTPRequestDispatcher
+}
+
+// throttle makes the client use the shared limiter, called right after creating each SDK client.
+// With OCI_DEBUG=1 each attempt is logged as well. Requests go through the proxy of ociTransport.
+func throttle(client *common.BaseClient) {
+	if dispatcher, ok := client.HTTPClient.(*http.Client); ok {
+		dispatcher.Transport = ociTransport()
+	}
+	next := client.HTTPClient
+	if ociDebugEnabled() {
+		next = debugDispatcher{next: next}
+	}
+	client.HTTPClient = throttledDispatcher{next: next}
+}
+
+func (d throttledDispatcher) Do(request *http.Request) (*http.Response, error) {
+	// the body is read once, every attempt sends it again
+	var body []byte
+	if request.Body != nil {
+		content, err := ioutil.ReadAll(request.Body)
+		if err != nil {
+			return nil, err
+		}
+		request.Body.Close()
+		body = content
+	}
+
+	for attempt := 0; ; attempt++ {
+		ociLimiter.Wait()
+		if body != nil {
+			request.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		start := time.Now()
+		response, err := d.next.Do(request)
+		profiler.Record(opAPI, apiOperation(request.Method, request.URL.Host+request.URL.Path), start)
+		if err != nil || response.StatusCode != http.StatusTooManyRequests || attempt == maxThrottledRetries {
+			return response, err
+		}
+
+		delay := retryAfter(response, attempt)
+		response.Body.Close()
+		ociLimiter.Backoff(delay)
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// requestsPerSecond is the limit of SDK requests of the run, OCI_REQUESTS_PER_SECOND or 10.
+func requestsPerSecond() int {
+	if value, err := strconv.Atoi(os.Getenv("OCI_REQUESTS_PER_SECOND")); err == nil && value > 0 {
+		return value
+	}
+	return defaultRequestsPerSecond
+}
+
+// retryAfter is the Retry-After of the response in seconds, or 1s doubled with every attempt.
+func retryAfter(response *http.Response, attempt int) time.Duration {
+	delay := time.Second << uint(attempt)
+	if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+		delay = time.Duration(seconds) * time.Second
+	}
+	if delay > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return delay
+}
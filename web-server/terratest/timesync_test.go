@@ -0,0 +1,60 @@
+package terratest
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	// OCI instances sync time from the metadata service
+	ociTimeSource     = "169.254.169.254"
+	maxClockOffset    = 100 * time.Millisecond
+	chronySynced      = "Normal"
+	trackingCommand   = "chronyc -c tracking"
+	trackingFields    = 14
+	trackingSourceIP  = 1
+	trackingOffset    = 4
+	trackingLeapState = 13
+)
+
+func checkTimeSync(t *testing.T) {
+	for tier := range tierOutputs {
+		for _, host := range tierHosts(t, tier) {
+			active := RunRemote(t, host, "systemctl is-active chronyd", RemoteOptions{Timeout: sshCommandTimeout})
+			if state := strings.TrimSpace(active.Stdout); state != "active" {
+				t.Errorf("%s %s: chronyd is not active: %q", tier, host.Hostname, state)
+				continue
+			}
+
+			tracking := RunRemote(t, host, trackingCommand, RemoteOptions{Timeout: sshCommandTimeout})
+			fields := strings.Split(strings.TrimSpace(tracking.Stdout), ",")
+			if tracking.ExitCode != 0 || len(fields) < trackingFields {
+				t.Errorf("%s %s: error in reading chrony tracking: %q %s", tier, host.Hostname, tracking.Stdout, tracking.Stderr)
+				continue
+			}
+
+			seconds, err := strconv.ParseFloat(fields[trackingOffset], 64)
+			if err != nil {
+				t.Errorf("%s %s: wrong chrony offset %q", tier, host.Hostname, fields[trackingOffset])
+				continue
+			}
+			offset := time.Duration(seconds * float64(time.Second))
+			t.Logf("%s %s: source %s, offset %s, leap status %s", tier, host.Hostname, fields[trackingSourceIP], offset, fields[trackingLeapState])
+			report.AddMetric("clock offset "+host.Hostname, offset)
+
+			// assertions
+			if fields[trackingSourceIP] != ociTimeSource {
+				t.Errorf("%s %s: wrong time source: expected %s, got %s", tier, host.Hostname, ociTimeSource, fields[trackingSourceIP])
+			}
+			if fields[trackingLeapState] != chronySynced {
+				t.Errorf("%s %s: clock is not synchronized: %s", tier, host.Hostname, fields[trackingLeapState])
+			}
+			if time.Duration(math.Abs(float64(offset))) > maxClockOffset {
+				t.Errorf("%s %s: clock offset %s exceeds %s", tier, host.Hostname, offset, maxClockOffset)
+			}
+		}
+	}
+}
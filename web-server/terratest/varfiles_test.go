@@ -0,0 +1,108 @@
+package terratest
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var (
+	// values of the var files by variable name, raw HCL for lists and maps
+	varFileValues = map[string]string{}
+
+	varAssignment = regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_-]*)\s*=\s*(.*)$`)
+)
+
+// varFiles returns absolute paths of TFVARS_FILES (comma separated), later files override earlier ones
+// and TF_VAR_* env vars override all of them.
+func varFiles(t *testing.T) []string {
+	files := []string{}
+	for _, file := range envList("TFVARS_FILES") {
+		path, err := filepath.Abs(file)
+		if err != nil {
+			t.Fatalf("error occured: %s", err.Error())
+		}
+		files = append(files, path)
+	}
+	return files
+}
+
+// loadVarFiles reads values of the files for the helpers reading variables (stringVar),
+// terraform reads the files itself.
+func loadVarFiles(t *testing.T, files []string) {
+	varFileValues = map[string]string{}
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatalf("error in reading var file: %s", err.Error())
+		}
+		for name, value := range parseVarFile(content) {
+			varFileValues[name] = value
+		}
+	}
+}
+
+// parseVarFile reads top level assignments of a tfvars file, string values are unquoted,
+// lists and maps are kept as HCL text up to their closing bracket.
+func parseVarFile(content []byte) map[string]string {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+
+	name, value, depth := "", "", 0
+	for scanner.Scan() {
+		line := stripComment(scanner.Text())
+		if depth > 0 {
+			value += "\n" + line
+			depth += bracketDepth(line)
+			if depth <= 0 {
+				values[name] = value
+			}
+			continue
+		}
+
+		match := varAssignment.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		name, value = match[1], strings.TrimSpace(match[2])
+		if depth = bracketDepth(value); depth > 0 {
+			continue
+		}
+		if unquoted := quotedPattern.FindStringSubmatch(value); unquoted != nil && unquoted[0] == value {
+			value = unquoted[1]
+		}
+		values[name] = value
+	}
+	return values
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func bracketDepth(line string) int {
+	return strings.Count(line, "[") + strings.Count(line, "{") - strings.Count(line, "]") - strings.Count(line, "}")
+}
+
+func stripComment(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") {
+		return ""
+	}
+	return line
+}
+
+// envVars returns all TF_VAR_* env vars which are not empty, passed as -var they override the var files.
+func envVars() map[string]interface{} {
+	vars := map[string]interface{}{}
+	for _, env := range os.Environ() {
+		pair := strings.SplitN(env, "=", 2)
+		if len(pair) == 2 && strings.HasPrefix(pair[0], "TF_VAR_") && pair[1] != "" {
+			vars[strings.TrimPrefix(pair[0], "TF_VAR_")] = pair[1]
+		}
+	}
+	return vars
+}
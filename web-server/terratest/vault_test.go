@@ -0,0 +1,289 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/terratest/modules/ssh"
+	"github.com/oracle/oci-go-sdk/common"
+	"github.com/oracle/oci-go-sdk/keymanagement"
+	"github.com/oracle/oci-go-sdk/secrets"
+	"github.com/oracle/oci-go-sdk/vault"
+)
+
+const (
+	// vaults, keys and secrets, COMPARTMENT_OCID_VAULT or CompartmentOCID
+	vaultCompartment  = "vault"
+	kmsManagementPath = "/20180608"
+)
+
+// VaultExpectation is the vault of the stack with its keys and the secrets referenced by the stack.
+type VaultExpectation struct {
+	DisplayName string `json:"displayName"`
+	// VaultType is DEFAULT or VIRTUAL_PRIVATE
+	VaultType string              `json:"vaultType"`
+	Keys      []KeyExpectation    `json:"keys"`
+	Secrets   []SecretExpectation `json:"secrets"`
+}
+
+// KeyExpectation is a master encryption key of the vault.
+type KeyExpectation struct {
+	DisplayName string `json:"displayName"`
+	// ProtectionMode is HSM or SOFTWARE
+	ProtectionMode string `json:"protectionMode"`
+	// RotationDays is the max age of the current key version, not checked when 0
+	RotationDays int `json:"rotationDays"`
+}
+
+// SecretExpectation is a secret of the vault, e.g. the TLS certificate bundle.
+type SecretExpectation struct {
+	Name string `json:"name"`
+	// Tier of the instances reading the secret by instance principal, e.g. web
+	Tier string `json:"tier"`
+}
+
+// keyProtection is the part of the key the SDK does not have yet.
+type keyProtection struct {
+	RawResponse *http.Response
+	Key         struct {
+		ProtectionMode string `json:"protectionMode"`
+	} `presentIn:"body"`
+}
+
+type getKeyRequest struct {
+	KeyId *string `mandatory:"true" contributesTo:"path" name:"keyId"`
+}
+
+func checkVault(t *testing.T) {
+	expected := loadExpectations(t).Vault
+	if expected == nil {
+		t.Skip("no vault in expectations")
+	}
+	actual := findVault(t, expected.DisplayName)
+
+	// assertions
+	if actual.LifecycleState != keymanagement.VaultSummaryLifecycleStateActive {
+		t.Errorf("vault %s in state %s", expected.DisplayName, actual.LifecycleState)
+	}
+	if expected.VaultType != "" && string(actual.VaultType) != expected.VaultType {
+		t.Errorf("vault %s: wrong type: expected %s, got %s", expected.DisplayName, expected.VaultType, actual.VaultType)
+	}
+
+	client := kmsManagementClient(t, *actual.ManagementEndpoint)
+	keys := vaultKeys(t, client, actual)
+	for _, key := range expected.Keys {
+		summary, ok := keys[key.DisplayName]
+		if !ok {
+			t.Errorf("missing key %s in vault %s", key.DisplayName, expected.DisplayName)
+			continue
+		}
+		linkResource(t, "key "+key.DisplayName, *summary.Id, "")
+		assertKey(t, client, summary, key)
+	}
+}
+
+// checkVaultSecrets verifies the secrets are ACTIVE, readable by the instance principal of their tier
+// and not readable by the auditor (AUDITOR_PROFILE) as an unauthorized principal.
+func checkVaultSecrets(t *testing.T) {
+	expected := loadExpectations(t).Vault
+	if expected == nil || len(expected.Secrets) == 0 {
+		t.Skip("no vault secrets in expectations")
+	}
+	vaultID := *findVault(t, expected.DisplayName).Id
+
+	for _, secret := range expected.Secrets {
+		name := secret.Name
+		actual, ok := findSecret(t, vaultID, name)
+		if !ok {
+			t.Errorf("missing secret %s in vault %s", name, expected.DisplayName)
+			continue
+		}
+		linkResource(t, "secret "+name, *actual.Id, "")
+
+		// assertions
+		if actual.LifecycleState != vault.SecretSummaryLifecycleStateActive {
+			t.Errorf("secret %s in state %s", name, actual.LifecycleState)
+		}
+		if secret.Tier != "" {
+			for _, host := range tierHosts(t, secret.Tier) {
+				assertInstancePrincipalSecret(t, host, secret.Tier, name, *actual.Id)
+			}
+		}
+		if auditorConfig == nil {
+			t.Logf("secret %s: no AUDITOR_PROFILE, unauthorized access is not checked", name)
+			continue
+		}
+		assertSecretDenied(t, name, *actual.Id)
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func kmsVaultClient(t *testing.T) keymanagement.KmsVaultClient {
+	client, err := keymanagement.NewKmsVaultClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	throttle(&client.BaseClient)
+	return client
+}
+
+func kmsManagementClient(t *testing.T, endpoint string) keymanagement.KmsManagementClient {
+	client, err := keymanagement.NewKmsManagementClientWithConfigurationProvider(ociConfigProvider(), endpoint)
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	throttle(&client.BaseClient)
+	return client
+}
+
+func vaultsClient(t *testing.T) vault.VaultsClient {
+	client, err := vault.NewVaultsClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	throttle(&client.BaseClient)
+	return client
+}
+
+func findVault(t *testing.T, name string) keymanagement.VaultSummary {
+	client := kmsVaultClient(t)
+	compartmentID := compartmentFor(vaultCompartment)
+	request := keymanagement.ListVaultsRequest{CompartmentId: &compartmentID}
+
+	for {
+		response, err := client.ListVaults(context.Background(), request)
+		if err != nil {
+			t.Fatalf("error in listing vaults: %s", err.Error())
+		}
+		for _, summary := range response.Items {
+			// deleted vaults keep the name until they are gone
+			if stringValue(summary.DisplayName) == name && summary.LifecycleState != keymanagement.VaultSummaryLifecycleStateDeleted {
+				linkResource(t, "vault "+name, *summary.Id, "")
+				return summary
+			}
+		}
+
+		if response.OpcNextPage == nil {
+			t.Fatalf("vault %s not found in %s", name, compartmentID)
+		}
+		request.Page = response.OpcNextPage
+	}
+}
+
+// vaultKeys returns the keys of the vault by display name.
+func vaultKeys(t *testing.T, client keymanagement.KmsManagementClient, summary keymanagement.VaultSummary) map[string]keymanagement.KeySummary {
+	request := keymanagement.ListKeysRequest{CompartmentId: summary.CompartmentId}
+
+	keys := map[string]keymanagement.KeySummary{}
+	for {
+		response, err := client.ListKeys(context.Background(), request)
+		if err != nil {
+			t.Fatalf("error in listing keys of vault %s: %s", stringValue(summary.DisplayName), err.Error())
+		}
+		for _, key := range response.Items {
+			if key.LifecycleState != keymanagement.KeySummaryLifecycleStateDeleted {
+				keys[stringValue(key.DisplayName)] = key
+			}
+		}
+
+		if response.OpcNextPage == nil {
+			return keys
+		}
+		request.Page = response.OpcNextPage
+	}
+}
+
+func assertKey(t *testing.T, client keymanagement.KmsManagementClient, summary keymanagement.KeySummary, expected KeyExpectation) {
+	if summary.LifecycleState != keymanagement.KeySummaryLifecycleStateEnabled {
+		t.Errorf("key %s in state %s", expected.DisplayName, summary.LifecycleState)
+	}
+
+	if expected.ProtectionMode != "" {
+		var protection keyProtection
+		err := callAPI(context.Background(), client.BaseClient, http.MethodGet, kmsManagementPath+"/keys/{keyId}",
+			getKeyRequest{KeyId: summary.Id}, &protection)
+		if err != nil {
+			t.Fatalf("error in calling key %s: %s", expected.DisplayName, err.Error())
+		}
+		if protection.Key.ProtectionMode != expected.ProtectionMode {
+			t.Errorf("key %s: wrong protection mode: expected %s, got %s", expected.DisplayName, expected.ProtectionMode, protection.Key.ProtectionMode)
+		}
+	}
+
+	if expected.RotationDays > 0 {
+		key, err := client.GetKey(context.Background(), keymanagement.GetKeyRequest{KeyId: summary.Id})
+		if err != nil {
+			t.Fatalf("error in calling key %s: %s", expected.DisplayName, err.Error())
+		}
+		version, err := client.GetKeyVersion(context.Background(), keymanagement.GetKeyVersionRequest{
+			KeyId:        summary.Id,
+			KeyVersionId: key.Key.CurrentKeyVersion,
+		})
+		if err != nil {
+			t.Fatalf("error in calling current version of key %s: %s", expected.DisplayName, err.Error())
+		}
+
+		age := time.Since(version.KeyVersion.TimeCreated.Time)
+		report.AddMetric("age of key "+expected.DisplayName, age.Round(time.Hour))
+		if age > time.Duration(expected.RotationDays)*24*time.Hour {
+			t.Errorf("key %s: current version created %s is older than %d days, the key is not rotated",
+				expected.DisplayName, version.KeyVersion.TimeCreated.Format(time.RFC3339), expected.RotationDays)
+		}
+	}
+}
+
+func findSecret(t *testing.T, vaultID string, name string) (vault.SecretSummary, bool) {
+	compartmentID := compartmentFor(vaultCompartment)
+	response, err := vaultsClient(t).ListSecrets(context.Background(), vault.ListSecretsRequest{
+		CompartmentId: &compartmentID,
+		VaultId:       &vaultID,
+		Name:          &name,
+	})
+	if err != nil {
+		t.Fatalf("error in listing secrets: %s", err.Error())
+	}
+	if len(response.Items) == 0 {
+		return vault.SecretSummary{}, false
+	}
+	return response.Items[0], true
+}
+
+// assertInstancePrincipalSecret reads the secret bundle on the host by OCI CLI with the instance principal.
+func assertInstancePrincipalSecret(t *testing.T, host ssh.Host, tier string, name string, secretID string) {
+	command := fmt.Sprintf(`oci secrets secret-bundle get --auth instance_principal --secret-id %s --query 'data."secret-id"' --raw-output`, secretID)
+	result := RunRemote(t, host, command, RemoteOptions{Timeout: sshCommandTimeout})
+	if result.ExitCode != 0 || strings.TrimSpace(result.Stdout) != secretID {
+		t.Errorf("%s %s: secret %s not readable by instance principal (exit code %d): %s",
+			tier, host.Hostname, name, result.ExitCode, strings.TrimSpace(result.Stderr))
+	}
+}
+
+// assertSecretDenied reads the secret bundle with the auditor credentials, the request has to be refused.
+func assertSecretDenied(t *testing.T, name string, secretID string) {
+	client, err := secrets.NewSecretsClientWithConfigurationProvider(configProvider{config: auditorConfig, profile: auditorProfileValues})
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	throttle(&client.BaseClient)
+
+	_, err = client.GetSecretBundle(context.Background(), secrets.GetSecretBundleRequest{SecretId: &secretID})
+	if err == nil {
+		t.Errorf("secret %s is readable by auditor profile %s", name, auditorProfile())
+		return
+	}
+	failure, ok := common.IsServiceError(err)
+	if !ok {
+		t.Fatalf("error in reading secret %s as auditor: %s", name, err.Error())
+	}
+	switch failure.GetHTTPStatusCode() {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		t.Logf("secret %s refused to auditor: %d %s", name, failure.GetHTTPStatusCode(), failure.GetCode())
+	default:
+		t.Errorf("secret %s as auditor: expected refusal, got %d %s", name, failure.GetHTTPStatusCode(), failure.GetMessage())
+	}
+}
@@ -0,0 +1,84 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/oracle/oci-go-sdk/core"
+)
+
+const privateIpResourceType = "oci_core_private_ip"
+
+// checkInstanceVnics verifies the VNICs of every instance of the tiers in expectations, the secondary IPs
+// of each VNIC are the private IPs of the stack assigned to it.
+func checkInstanceVnics(t *testing.T) {
+	expected := loadExpectations(t).Vnics
+	if len(expected) == 0 {
+		t.Skip("no vnics in expectations")
+	}
+	network := virtualNetworkClient(t)
+	secondaryIps := stateSecondaryIps(t)
+
+	for tier, expectation := range expected {
+		output, ok := tierOutputs[tier]
+		if !ok {
+			t.Fatalf("unknown tier %q in expectations", tier)
+		}
+
+		for _, instanceID := range outputValues(t, output) {
+			vnics := instanceVnics(t, instanceID)
+
+			// assertions
+			if len(vnics) != expectation.Count {
+				t.Errorf("%s instance %s: wrong number of vnics: expected %d, got %d", tier, instanceID, expectation.Count, len(vnics))
+			}
+
+			for _, vnic := range vnics {
+				subnet, err := network.GetSubnet(context.Background(), core.GetSubnetRequest{SubnetId: vnic.SubnetId})
+				if err != nil {
+					t.Fatalf("error in calling subnet %s: %s", *vnic.SubnetId, err.Error())
+				}
+
+				if boolValue(vnic.IsPrimary) && !strings.HasPrefix(stringValue(subnet.Subnet.DnsLabel), expectation.SubnetDnsLabel) {
+					t.Errorf("%s vnic %s in wrong subnet: expected dns label %q, got %q",
+						tier, *vnic.PrivateIp, expectation.SubnetDnsLabel, stringValue(subnet.Subnet.DnsLabel))
+				}
+
+				if boolValue(vnic.SkipSourceDestCheck) != expectation.SkipSourceDestCheck {
+					t.Errorf("%s vnic %s: wrong skip_source_dest_check: expected %t, got %t",
+						tier, *vnic.PrivateIp, expectation.SkipSourceDestCheck, boolValue(vnic.SkipSourceDestCheck))
+				}
+
+				privateIps, err := network.ListPrivateIps(context.Background(), core.ListPrivateIpsRequest{VnicId: vnic.Id})
+				if err != nil {
+					t.Fatalf("error in listing private IPs of vnic %s: %s", *vnic.Id, err.Error())
+				}
+
+				secondary := []string{}
+				for _, ip := range privateIps.Items {
+					if !boolValue(ip.IsPrimary) {
+						secondary = append(secondary, *ip.IpAddress)
+					}
+				}
+
+				if len(secondary) != secondaryIps[*vnic.Id] {
+					t.Errorf("%s vnic %s: wrong number of secondary IPs: expected %d, got %d %v",
+						tier, *vnic.PrivateIp, secondaryIps[*vnic.Id], len(secondary), secondary)
+				}
+			}
+		}
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// stateSecondaryIps counts the private IPs of the stack by VNIC.
+func stateSecondaryIps(t *testing.T) map[string]int {
+	counts := map[string]int{}
+	for _, ip := range LoadState(t).FindByType(privateIpResourceType) {
+		counts[fmt.Sprint(ip.Values["vnic_id"])]++
+	}
+	return counts
+}
@@ -0,0 +1,149 @@
+package terratest
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+const (
+	// web app firewalls and policies, COMPARTMENT_OCID_WAF or CompartmentOCID
+	wafCompartment = "waf"
+	// the SDK has no waf package, the checks call the REST API of the service
+	wafEndpointTemplate  = "https://waf.{region}.oci.{secondLevelDomain}"
+	wafPath              = "/20210930"
+	defaultBlockedStatus = http.StatusForbidden
+	// SQL injection in a query string, blocked by the protection rules of the policy
+	sqlInjectionQuery = "1' OR '1'='1"
+)
+
+// WafExpectation is the web app firewall of the load balancer.
+type WafExpectation struct {
+	// Policy is the display name of the web app firewall policy
+	Policy string `json:"policy"`
+	// BlockedStatus is the response code of blocked requests, 403 when 0
+	BlockedStatus int `json:"blockedStatus"`
+}
+
+type webAppFirewallSummary struct {
+	Id                     *string `json:"id"`
+	DisplayName            *string `json:"displayName"`
+	LoadBalancerId         *string `json:"loadBalancerId"`
+	WebAppFirewallPolicyId *string `json:"webAppFirewallPolicyId"`
+	LifecycleState         string  `json:"lifecycleState"`
+}
+
+type listWebAppFirewallsRequest struct {
+	CompartmentId *string `mandatory:"true" contributesTo:"query" name:"compartmentId"`
+	Page          *string `mandatory:"false" contributesTo:"query" name:"page"`
+}
+
+type listWebAppFirewallsResponse struct {
+	RawResponse              *http.Response
+	WebAppFirewallCollection struct {
+		Items []webAppFirewallSummary `json:"items"`
+	} `presentIn:"body"`
+	OpcNextPage *string `presentIn:"header" name:"opc-next-page"`
+}
+
+type getWebAppFirewallPolicyRequest struct {
+	WebAppFirewallPolicyId *string `mandatory:"true" contributesTo:"path" name:"webAppFirewallPolicyId"`
+}
+
+type getWebAppFirewallPolicyResponse struct {
+	RawResponse          *http.Response
+	WebAppFirewallPolicy struct {
+		DisplayName    *string `json:"displayName"`
+		LifecycleState string  `json:"lifecycleState"`
+	} `presentIn:"body"`
+}
+
+func checkWafPolicy(t *testing.T) {
+	expected := loadExpectations(t).Waf
+	if expected == nil {
+		t.Skip("no waf in expectations")
+	}
+	firewall := loadBalancerFirewall(t)
+	linkResource(t, "web app firewall "+stringValue(firewall.DisplayName), *firewall.Id, "")
+
+	var policy getWebAppFirewallPolicyResponse
+	err := callAPI(context.Background(), apiClient(t, wafEndpointTemplate), http.MethodGet, wafPath+"/webAppFirewallPolicies/{webAppFirewallPolicyId}",
+		getWebAppFirewallPolicyRequest{WebAppFirewallPolicyId: firewall.WebAppFirewallPolicyId}, &policy)
+	if err != nil {
+		t.Fatalf("error in calling web app firewall policy %s: %s", stringValue(firewall.WebAppFirewallPolicyId), err.Error())
+	}
+
+	// assertions
+	if firewall.LifecycleState != "ACTIVE" {
+		t.Errorf("web app firewall %s in state %s", stringValue(firewall.DisplayName), firewall.LifecycleState)
+	}
+	if name := stringValue(policy.WebAppFirewallPolicy.DisplayName); name != expected.Policy {
+		t.Errorf("web app firewall %s: wrong policy: expected %s, got %s", stringValue(firewall.DisplayName), expected.Policy, name)
+	}
+	if policy.WebAppFirewallPolicy.LifecycleState != "ACTIVE" {
+		t.Errorf("web app firewall policy %s in state %s", expected.Policy, policy.WebAppFirewallPolicy.LifecycleState)
+	}
+}
+
+// checkWafAttackSimulation sends a normal request and a request with an SQL injection pattern to the load balancer,
+// only the latter has to be blocked.
+func checkWafAttackSimulation(t *testing.T) {
+	expected := loadExpectations(t).Waf
+	if expected == nil {
+		t.Skip("no waf in expectations")
+	}
+	blocked := expected.BlockedStatus
+	if blocked == 0 {
+		blocked = defaultBlockedStatus
+	}
+	base := "http://" + outputValues(t, "lb_ip")[0] + "/"
+	attack := base + "?" + url.Values{"id": {sqlInjectionQuery}}.Encode()
+	client := &http.Client{Timeout: httpTimeout}
+
+	// assertions
+	if status := httpStatus(t, client, base); status != http.StatusOK {
+		t.Errorf("normal request %s: expected status %d, got %d", base, http.StatusOK, status)
+	}
+	if status := httpStatus(t, client, attack); status != blocked {
+		t.Errorf("SQL injection %s not blocked: expected status %d, got %d", attack, blocked, status)
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+// loadBalancerFirewall returns the web app firewall attached to the load balancer of the stack.
+func loadBalancerFirewall(t *testing.T) webAppFirewallSummary {
+	lbID := terraform.Output(t, options, "lb_id")
+	compartmentID := compartmentFor(wafCompartment)
+	client := apiClient(t, wafEndpointTemplate)
+	request := listWebAppFirewallsRequest{CompartmentId: &compartmentID}
+
+	for {
+		var response listWebAppFirewallsResponse
+		if err := callAPI(context.Background(), client, http.MethodGet, wafPath+"/webAppFirewalls", request, &response); err != nil {
+			t.Fatalf("error in listing web app firewalls: %s", err.Error())
+		}
+		for _, firewall := range response.WebAppFirewallCollection.Items {
+			if stringValue(firewall.LoadBalancerId) == lbID && firewall.LifecycleState != "DELETED" {
+				return firewall
+			}
+		}
+
+		if response.OpcNextPage == nil {
+			t.Fatalf("no web app firewall of load balancer %s in %s", lbID, compartmentID)
+		}
+		request.Page = response.OpcNextPage
+	}
+}
+
+func httpStatus(t *testing.T, client *http.Client, target string) int {
+	response, err := client.Get(target)
+	if err != nil {
+		t.Fatalf("error in calling %s: %s", target, err.Error())
+	}
+	response.Body.Close()
+	return response.StatusCode
+}
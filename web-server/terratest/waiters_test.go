@@ -0,0 +1,196 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/core"
+	"github.com/oracle/oci-go-sdk/loadbalancer"
+)
+
+const (
+	statePollInterval = 10 * time.Second
+	// max duration of lifecycle state changes of instances, load balancers and subnets
+	defaultStateTimeout = 20 * time.Minute
+	// list operations right after apply may miss just created resources for a while
+	listConsistencyTimeout = 2 * time.Minute
+	listPollInterval       = 5 * time.Second
+)
+
+var (
+	// states a resource does not leave, waiting for another state fails right away
+	terminalStates = []string{"FAILED", "TERMINATED", "DELETED"}
+)
+
+// StateGetter returns the current lifecycle state of a resource.
+type StateGetter func(ctx context.Context) (string, error)
+
+// WaitForState polls getter until the resource is in targetState and returns how long it took.
+// It fails when the resource gets into a terminal state or is not in targetState within timeout.
+func WaitForState(t *testing.T, resource string, getter StateGetter, targetState string, timeout time.Duration) time.Duration {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	state := ""
+	description := fmt.Sprintf("%s in state %s", resource, targetState)
+	err := pollUntil(ctx, description, statePollInterval, func(ctx context.Context) (bool, error) {
+		var err error
+		state, err = getter(ctx)
+		if err != nil {
+			return false, err
+		}
+		if state != targetState && containsString(terminalStates, state) {
+			return false, fmt.Errorf("terminal state %s", state)
+		}
+		return state == targetState, nil
+	})
+	if err != nil {
+		t.Fatalf("%s (last state %q, timeout %s)", err.Error(), state, timeout)
+	}
+
+	waited := time.Since(start)
+	t.Logf("%s in state %s after %s", resource, targetState, waited.Round(time.Second))
+	return waited
+}
+
+// ListGetter returns the IDs of the resources of a list operation.
+type ListGetter func(ctx context.Context) ([]string, error)
+
+// WaitForCount polls list until it returns expectedCount IDs and returns them.
+// It fails when the list does not converge within timeout.
+func WaitForCount(t *testing.T, resources string, list ListGetter, expectedCount int, timeout time.Duration) []string {
+	return waitForList(t, fmt.Sprintf("%d %s", expectedCount, resources), list, timeout, func(ids []string) bool {
+		return len(ids) == expectedCount
+	})
+}
+
+// WaitForIDs polls list until it returns all of expectedIDs, possibly among others, and returns the IDs.
+// It fails when the list does not converge within timeout.
+func WaitForIDs(t *testing.T, resources string, list ListGetter, expectedIDs []string, timeout time.Duration) []string {
+	return waitForList(t, fmt.Sprintf("%s %v", resources, expectedIDs), list, timeout, func(ids []string) bool {
+		for _, id := range expectedIDs {
+			if !containsString(ids, id) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func instanceState(compute core.ComputeClient, instanceID string) StateGetter {
+	return func(ctx context.Context) (string, error) {
+		response, err := compute.GetInstance(ctx, core.GetInstanceRequest{InstanceId: &instanceID})
+		return string(response.Instance.LifecycleState), err
+	}
+}
+
+func loadBalancerState(client loadbalancer.LoadBalancerClient, lbID string) StateGetter {
+	return func(ctx context.Context) (string, error) {
+		response, err := client.GetLoadBalancer(ctx, loadbalancer.GetLoadBalancerRequest{LoadBalancerId: &lbID})
+		return string(response.LoadBalancer.LifecycleState), err
+	}
+}
+
+func subnetState(network core.VirtualNetworkClient, subnetID string) StateGetter {
+	return func(ctx context.Context) (string, error) {
+		response, err := network.GetSubnet(ctx, core.GetSubnetRequest{SubnetId: &subnetID})
+		return string(response.Subnet.LifecycleState), err
+	}
+}
+
+func waitForList(t *testing.T, description string, list ListGetter, timeout time.Duration, complete func(ids []string) bool) []string {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var ids []string
+	err := pollUntil(ctx, description, listPollInterval, func(ctx context.Context) (bool, error) {
+		var err error
+		ids, err = list(ctx)
+		return err == nil && complete(ids), err
+	})
+	if err != nil {
+		t.Fatalf("%s (last listed %v, timeout %s)", err.Error(), ids, timeout)
+	}
+	if waited := time.Since(start); waited > listPollInterval {
+		t.Logf("listed %s after %s", description, waited.Round(time.Second))
+	}
+	return ids
+}
+
+func listVcns(network core.VirtualNetworkClient, compartmentID string) ListGetter {
+	return func(ctx context.Context) ([]string, error) {
+		ids := []string{}
+		request := core.ListVcnsRequest{CompartmentId: &compartmentID}
+		for {
+			response, err := network.ListVcns(ctx, request)
+			if err != nil {
+				return nil, err
+			}
+			for _, vcn := range response.Items {
+				ids = append(ids, *vcn.Id)
+			}
+			if response.OpcNextPage == nil {
+				return ids, nil
+			}
+			request.Page = response.OpcNextPage
+		}
+	}
+}
+
+// listSubnets lists the subnets of the VCN, the last listed subnets are kept in subnets.
+func listSubnets(network core.VirtualNetworkClient, compartmentID string, vcnID string, subnets *[]core.Subnet) ListGetter {
+	return func(ctx context.Context) ([]string, error) {
+		response, err := network.ListSubnets(ctx, core.ListSubnetsRequest{
+			CompartmentId: &compartmentID,
+			VcnId:         &vcnID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		*subnets = response.Items
+		ids := []string{}
+		for _, subnet := range response.Items {
+			ids = append(ids, *subnet.Id)
+		}
+		return ids, nil
+	}
+}
+
+// listBackendSets returns the names of the backend sets of the load balancer.
+func listBackendSets(client loadbalancer.LoadBalancerClient, lbID string) ListGetter {
+	return func(ctx context.Context) ([]string, error) {
+		response, err := client.ListBackendSets(ctx, loadbalancer.ListBackendSetsRequest{LoadBalancerId: &lbID})
+		if err != nil {
+			return nil, err
+		}
+		names := []string{}
+		for _, backendSet := range response.Items {
+			names = append(names, *backendSet.Name)
+		}
+		return names, nil
+	}
+}
+
+// listBackends returns the names, ip:port, of the backends of the backend set.
+func listBackends(client loadbalancer.LoadBalancerClient, lbID string, backendSetName string) ListGetter {
+	return func(ctx context.Context) ([]string, error) {
+		response, err := client.ListBackends(ctx, loadbalancer.ListBackendsRequest{
+			LoadBalancerId: &lbID,
+			BackendSetName: &backendSetName,
+		})
+		if err != nil {
+			return nil, err
+		}
+		names := []string{}
+		for _, backend := range response.Items {
+			names = append(names, *backend.Name)
+		}
+		return names, nil
+	}
+}
@@ -0,0 +1,122 @@
+package terratest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/loadbalancer"
+	"github.com/oracle/oci-go-sdk/workrequests"
+)
+
+const (
+	workRequestPollInterval = 5 * time.Second
+	// max duration of work requests started by the checks, e.g. backend set updates
+	defaultWorkRequestTimeout = 10 * time.Minute
+)
+
+// pollUntil calls poll every interval until it reports done, returns its error or an error when ctx is done first.
+func pollUntil(ctx context.Context, description string, interval time.Duration, poll func(ctx context.Context) (bool, error)) error {
+	for {
+		done, err := poll(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %s", description, err.Error())
+		}
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %s", description, ctx.Err().Error())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// WaitForWorkRequest waits until the work request (e.g. opc-work-request-id of an instance action) succeeds,
+// it fails when the work request fails, is canceled or does not finish within timeout.
+func WaitForWorkRequest(t *testing.T, workRequestID string, timeout time.Duration) {
+	client := workRequestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	description := fmt.Sprintf("work request %s", workRequestID)
+	err := pollUntil(ctx, description, workRequestPollInterval, func(ctx context.Context) (bool, error) {
+		response, err := client.GetWorkRequest(ctx, workrequests.GetWorkRequestRequest{WorkRequestId: &workRequestID})
+		if err != nil {
+			return false, err
+		}
+
+		switch response.WorkRequest.Status {
+		case workrequests.WorkRequestStatusSucceeded:
+			return true, nil
+		case workrequests.WorkRequestStatusFailed, workrequests.WorkRequestStatusCanceled:
+			return false, fmt.Errorf("%s %s: %s",
+				stringValue(response.WorkRequest.OperationType), response.WorkRequest.Status, workRequestErrors(ctx, client, workRequestID))
+		}
+		return false, nil
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+// WaitForLoadBalancerWorkRequests waits until work requests of the load balancer accepted since the time
+// (e.g. backend changes of a targeted apply) are finished, it fails when any of them failed.
+func WaitForLoadBalancerWorkRequests(t *testing.T, lbID string, since time.Time, timeout time.Duration) {
+	client := loadBalancerClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	description := fmt.Sprintf("work requests of load balancer %s", lbID)
+	err := pollUntil(ctx, description, workRequestPollInterval, func(ctx context.Context) (bool, error) {
+		response, err := client.ListWorkRequests(ctx, loadbalancer.ListWorkRequestsRequest{LoadBalancerId: &lbID})
+		if err != nil {
+			return false, err
+		}
+
+		pending := 0
+		for _, request := range response.Items {
+			if request.TimeAccepted == nil || request.TimeAccepted.Time.Before(since) {
+				continue
+			}
+			switch request.LifecycleState {
+			case loadbalancer.WorkRequestLifecycleStateFailed:
+				return false, fmt.Errorf("%s %s failed: %s", stringValue(request.Type), stringValue(request.Id), stringValue(request.Message))
+			case loadbalancer.WorkRequestLifecycleStateAccepted, loadbalancer.WorkRequestLifecycleStateInProgress:
+				pending++
+			}
+		}
+		return pending == 0, nil
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+// ~~~~~~~~~~~~~~~~ Helper functions ~~~~~~~~~~~~~~~~
+
+func workRequestClient(t *testing.T) workrequests.WorkRequestClient {
+	client, err := workrequests.NewWorkRequestClientWithConfigurationProvider(ociConfigProvider())
+	if err != nil {
+		t.Fatalf("error occured: %s", err.Error())
+	}
+	throttle(&client.BaseClient)
+	return client
+}
+
+func workRequestErrors(ctx context.Context, client workrequests.WorkRequestClient, workRequestID string) string {
+	response, err := client.ListWorkRequestErrors(ctx, workrequests.ListWorkRequestErrorsRequest{WorkRequestId: &workRequestID})
+	if err != nil {
+		return err.Error()
+	}
+
+	messages := []string{}
+	for _, item := range response.Items {
+		messages = append(messages, stringValue(item.Message))
+	}
+	return strings.Join(messages, "; ")
+}
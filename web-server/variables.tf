@@ -1,7 +1,9 @@
 variable "tenancy_ocid" {
 }
 
+# empty with security token auth (oci session authenticate)
 variable "user_ocid" {
+  default = ""
 }
 
 variable "fingerprint" {
@@ -29,6 +31,19 @@ variable "TestServerShape" {
   default = "VM.Standard2.1"
 }
 
+# burstable instances of a flexible TestServerShape: BASELINE_1_8 or BASELINE_1_2, empty for regular instances
+variable "BaselineOcpuUtilization" {
+  default = ""
+}
+
+variable "InstanceOcpus" {
+  default = 1
+}
+
+variable "PreemptibleWebServers" {
+  default = false
+}
+
 variable "InstanceImageOCID" {
   type = map(string)
 
@@ -55,6 +70,20 @@ variable "WebVMCount" {
   default = 1
 }
 
+# blue/green switch of the web tier: the green tier runs next to the blue one (WebServer),
+# the LB backend set serves the tiers enabled below
+variable "GreenWebVMCount" {
+  default = 0
+}
+
+variable "BlueWebTierInLB" {
+  default = true
+}
+
+variable "GreenWebTierInLB" {
+  default = false
+}
+
 variable "BastionVMCount" {
   default = 1
 }
@@ -73,6 +102,12 @@ variable "BastionSubnetCIDRs" {
   default = ["10.0.100.0/28", "10.0.100.16/28", "10.0.100.32/28"]
 }
 
+# cost-tracking defined tags ("namespace.key" = value) of the billable resources, set by the tests per run
+variable "defined_tags" {
+  type    = map(string)
+  default = {}
+}
+
 variable "CompartmentOCID" {
   default = "ocid1.compartment.oc1..aaaaaaaa5ho3ftokbmcdpn34mxhjcmuear2tnwyx54sxy6qpcqtaiwqucqlq"
 }